@@ -156,6 +156,38 @@ func (f BucketFilter) String() string {
 	return "[" + strings.Join(parts, ", ") + "]"
 }
 
+// NewBucketFilter returns a filter matching every bucket, to be narrowed
+// with its With methods rather than setting pointers by hand:
+//
+//	filter := influxdb.NewBucketFilter().WithOrgID(orgID).WithName(name)
+func NewBucketFilter() BucketFilter {
+	return BucketFilter{}
+}
+
+// WithID returns a copy of f restricted to the bucket id.
+func (f BucketFilter) WithID(id ID) BucketFilter {
+	f.ID = &id
+	return f
+}
+
+// WithName returns a copy of f restricted to buckets named name.
+func (f BucketFilter) WithName(name string) BucketFilter {
+	f.Name = &name
+	return f
+}
+
+// WithOrgID returns a copy of f restricted to the buckets of the org orgID.
+func (f BucketFilter) WithOrgID(orgID ID) BucketFilter {
+	f.OrganizationID = &orgID
+	return f
+}
+
+// WithOrg returns a copy of f restricted to the buckets of the org named org.
+func (f BucketFilter) WithOrg(org string) BucketFilter {
+	f.Org = &org
+	return f
+}
+
 func ErrInternalBucketServiceError(op string, err error) *Error {
 	return &Error{
 		Code: EInternal,
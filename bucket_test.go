@@ -0,0 +1,50 @@
+package influxdb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+func TestBucketFilter_With(t *testing.T) {
+	id, orgID := influxdb.ID(1), influxdb.ID(2)
+	name, org := "telegraf", "acme"
+
+	tests := []struct {
+		name   string
+		filter influxdb.BucketFilter
+		want   influxdb.BucketFilter
+	}{
+		{
+			name:   "empty",
+			filter: influxdb.NewBucketFilter(),
+			want:   influxdb.BucketFilter{},
+		},
+		{
+			name:   "by name in an org",
+			filter: influxdb.NewBucketFilter().WithOrgID(orgID).WithName(name),
+			want:   influxdb.BucketFilter{OrganizationID: &orgID, Name: &name},
+		},
+		{
+			name:   "by id in a named org",
+			filter: influxdb.NewBucketFilter().WithOrg(org).WithID(id),
+			want:   influxdb.BucketFilter{Org: &org, ID: &id},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.filter, tt.want) {
+				t.Errorf("unexpected filter: got %s want %s", tt.filter, tt.want)
+			}
+		})
+	}
+
+	t.Run("copies are independent", func(t *testing.T) {
+		base := influxdb.NewBucketFilter().WithOrgID(orgID)
+		a, b := base.WithName("a"), base.WithName("b")
+		if base.Name != nil || *a.Name != "a" || *b.Name != "b" {
+			t.Errorf("unexpected filters: base %s a %s b %s", base, a, b)
+		}
+	})
+}
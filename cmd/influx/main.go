@@ -62,7 +62,7 @@ func newHTTPClient() (*httpc.Client, error) {
 	)
 
 	opts := []httpc.ClientOptFn{
-		httpc.WithUserAgentHeader(userAgent),
+		httpc.WithUserAgent(userAgent),
 	}
 	// This is useful for forcing tracing on a given endpoint.
 	if flags.traceDebugID != "" {
@@ -360,6 +360,30 @@ func launcherOpts(l *Launcher) []cli.Opt {
 			Default: 0,
 			Desc:    "the number of page faults allowed per second in the storage engine",
 		},
+		{
+			DestP:   &l.writeMaxAttempts,
+			Flag:    "storage-write-max-attempts",
+			Default: 1,
+			Desc:    "the number of attempts of a write failing with a transient storage error, such as a full cache, before it fails",
+		},
+		{
+			DestP:   &l.writeRetryDelay,
+			Flag:    "storage-write-retry-delay",
+			Default: 100 * time.Millisecond,
+			Desc:    "the delay before the first retry of a write, doubled before each next retry",
+		},
+		{
+			DestP:   &l.orgCacheSize,
+			Flag:    "org-id-cache-size",
+			Default: 0,
+			Desc:    "the number of organizations found by ID, as the organizations of writes are, to cache; 0 disables the cache",
+		},
+		{
+			DestP:   &l.orgCacheTTL,
+			Flag:    "org-id-cache-ttl",
+			Default: time.Minute,
+			Desc:    "how long an organization is cached by org-id-cache-size",
+		},
 		{
 			DestP: &l.featureFlags,
 			Flag:  "feature-flags",
@@ -432,6 +456,12 @@ type Launcher struct {
 	apibackend *http.APIBackend
 
 	pageFaultRate int
+
+	writeMaxAttempts int
+	writeRetryDelay  time.Duration
+
+	orgCacheSize int
+	orgCacheTTL  time.Duration
 }
 
 type stoppingScheduler interface {
@@ -668,6 +698,9 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 
 	tenantStore := tenant.NewStore(m.kvStore)
 	ts := tenant.NewSystem(tenantStore, m.log.With(zap.String("store", "new")), m.reg, metric.WithSuffix("new"))
+	if m.orgCacheSize > 0 {
+		ts.OrganizationService = tenant.NewOrgCache(ts.OrganizationService, m.orgCacheSize, m.orgCacheTTL)
+	}
 
 	secretStore, err := secret.NewStore(m.kvStore)
 	if err != nil {
@@ -733,6 +766,9 @@ func (m *Launcher) run(ctx context.Context) (err error) {
 		pointsWriter  storage.PointsWriter   = m.engine
 		backupService platform.BackupService = m.engine
 	)
+	if m.writeMaxAttempts > 1 {
+		pointsWriter = storage.NewRetryPointsWriter(m.engine, m.writeMaxAttempts, m.writeRetryDelay)
+	}
 
 	deps, err := influxdb.NewDependencies(
 		storageflux.NewReader(readservice.NewStore(m.engine)),
@@ -66,3 +66,31 @@ func GetUserID(ctx context.Context) (influxdb.ID, error) {
 	}
 	return a.GetUserID(), nil
 }
+
+// GetOrgID retrieves the org of the authorization on the context, when the
+// authorization is a token unambiguously scoped to that org: all of its
+// permissions are within the org, or on resources outside of any org such as
+// the user of the token. It returns false for sessions, and for tokens that
+// may reach other orgs.
+func GetOrgID(ctx context.Context) (influxdb.ID, bool) {
+	auth, ok := ctx.Value(authorizerCtxKey).(*influxdb.Authorization)
+	if !ok || auth == nil || !auth.OrgID.Valid() {
+		return 0, false
+	}
+	for _, p := range auth.Permissions {
+		r := p.Resource
+		switch {
+		case r.Type == influxdb.OrgsResourceType:
+			if r.ID == nil || *r.ID != auth.OrgID {
+				return 0, false
+			}
+		case r.OrgID != nil:
+			if *r.OrgID != auth.OrgID {
+				return 0, false
+			}
+		case r.ID == nil:
+			return 0, false
+		}
+	}
+	return auth.OrgID, true
+}
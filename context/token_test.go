@@ -52,3 +52,62 @@ func TestGetUserID(t *testing.T) {
 		t.Errorf("GetUserID() want %s, got %s", want, got)
 	}
 }
+
+func TestGetOrgID(t *testing.T) {
+	orgID, otherOrgID, userID := influxdb.ID(1), influxdb.ID(2), influxdb.ID(3)
+	tests := []struct {
+		name string
+		auth influxdb.Authorizer
+		want influxdb.ID
+		ok   bool
+	}{
+		{
+			name: "single org token",
+			auth: &influxdb.Authorization{
+				OrgID:       orgID,
+				Permissions: append(influxdb.OwnerPermissions(orgID), influxdb.MePermissions(userID)...),
+			},
+			want: orgID,
+			ok:   true,
+		},
+		{
+			name: "token with permissions in another org",
+			auth: &influxdb.Authorization{
+				OrgID: orgID,
+				Permissions: []influxdb.Permission{
+					{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID}},
+					{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &otherOrgID}},
+				},
+			},
+		},
+		{
+			name: "token reading another org",
+			auth: &influxdb.Authorization{
+				OrgID: orgID,
+				Permissions: []influxdb.Permission{
+					{Action: influxdb.ReadAction, Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: &otherOrgID}},
+				},
+			},
+		},
+		{
+			name: "operator token",
+			auth: &influxdb.Authorization{OrgID: orgID, Permissions: influxdb.OperPermissions()},
+		},
+		{
+			name: "session",
+			auth: &influxdb.Session{UserID: userID, Permissions: influxdb.OwnerPermissions(orgID)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := icontext.GetOrgID(icontext.SetAuthorizer(context.Background(), tt.auth))
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("GetOrgID() want %s, %t, got %s, %t", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+
+	if _, ok := icontext.GetOrgID(context.Background()); ok {
+		t.Error("GetOrgID() without an authorizer want false")
+	}
+}
@@ -25,6 +25,8 @@ const (
 	EUnauthorized        = "unauthorized"
 	EMethodNotAllowed    = "method not allowed"
 	ETooLarge            = "request too large"
+	ETimeout             = "timeout"
+	EUpgradeRequired     = "upgrade required"
 )
 
 // Error is the error struct of platform.
@@ -117,6 +119,13 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("<%s>", e.Code)
 }
 
+// Unwrap returns the underlying error, allowing errors.Is and errors.As to
+// inspect the stack of errors, such as to tell a client call aborted with
+// context.Canceled from the other internal errors it is wrapped as.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // ErrorCode returns the code of the root error, if available; otherwise returns EINTERNAL.
 func ErrorCode(err error) string {
 	if err == nil {
@@ -1,6 +1,7 @@
 package influxdb_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -195,6 +196,53 @@ func TestErrorCode(t *testing.T) {
 	}
 }
 
+func TestErrorUnwrap(t *testing.T) {
+	t.Run("errors.Is", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			err    error
+			target error
+			want   bool
+		}{
+			{
+				name:   "wrapped error",
+				err:    &platform.Error{Code: platform.EInternal, Err: context.Canceled},
+				target: context.Canceled,
+				want:   true,
+			},
+			{
+				name:   "embedded error",
+				err:    &platform.Error{Err: &platform.Error{Code: platform.EInvalid, Err: context.DeadlineExceeded}},
+				target: context.DeadlineExceeded,
+				want:   true,
+			},
+			{
+				name:   "other error",
+				err:    &platform.Error{Code: platform.EInternal, Err: context.Canceled},
+				target: context.DeadlineExceeded,
+			},
+			{
+				name:   "no wrapped error",
+				err:    &platform.Error{Code: platform.EInternal},
+				target: context.Canceled,
+			},
+		}
+		for _, c := range cases {
+			if result := errors.Is(c.err, c.target); c.want != result {
+				t.Errorf("%s failed, want %t, got %t", c.name, c.want, result)
+			}
+		}
+	})
+
+	t.Run("errors.As", func(t *testing.T) {
+		var jerr *json.SyntaxError
+		err := &platform.Error{Code: platform.EInvalid, Err: fmt.Errorf("decoding: %w", &json.SyntaxError{Offset: 3})}
+		if !errors.As(err, &jerr) || jerr.Offset != 3 {
+			t.Errorf("expected the wrapped syntax error, got %v", jerr)
+		}
+	})
+}
+
 func TestJSON(t *testing.T) {
 	cases := []struct {
 		name    string
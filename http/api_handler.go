@@ -47,6 +47,10 @@ type APIBackend struct {
 	// write request. A value of zero specifies there is no limit.
 	WriteParserMaxValues int
 
+	// V1Authorizer authenticates the v1 writes carrying a username and
+	// password. When nil those writes require a token.
+	V1Authorizer V1Authorizer
+
 	NewBucketService func(*influxdb.Source) (influxdb.BucketService, error)
 	NewQueryService  func(*influxdb.Source) (query.ProxyQueryService, error)
 
@@ -197,14 +201,16 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	h.Mount(dbrp.PrefixDBRP, dbrp.NewHTTPHandler(b.Logger, b.DBRPService, b.OrganizationService))
 
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
-	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
+	writeHandler := NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
 		WithParserOptions(
 			models.WithParserMaxBytes(b.WriteParserMaxBytes),
 			models.WithParserMaxLines(b.WriteParserMaxLines),
 			models.WithParserMaxValues(b.WriteParserMaxValues),
 		),
-	))
+	)
+	h.Mount(prefixWrite, writeHandler)
+	h.Mount(prefixPromWrite, writeHandler)
 
 	for _, o := range opts {
 		o(h)
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// AuthorizationFindOptions are the options of
+// AuthorizationService.FindAuthorizationsWithOptions.
+type AuthorizationFindOptions struct {
+	influxdb.FindOptions
+
+	// ExpandPermissions names the org, the user and the resources of the
+	// permissions of each authorization, as resolved by the server.
+	ExpandPermissions bool
+}
+
+// ExpandedAuthorization is an authorization with the names of what it
+// grants access to, such as for the audit of the tokens of an instance.
+type ExpandedAuthorization struct {
+	// Authorization is the authorization, with its raw permissions.
+	*influxdb.Authorization
+
+	// Org and User are the names of the org and the user of the
+	// authorization.
+	Org  string
+	User string
+	// ExpandedPermissions are the permissions of the authorization, in the
+	// order of its raw permissions.
+	ExpandedPermissions []ExpandedPermission
+}
+
+// ExpandedPermission is a permission with the names of its resource and of
+// the org of its resource. A name is empty when the permission is not scoped
+// to a single resource or org, or the server could not find it.
+type ExpandedPermission struct {
+	influxdb.Permission
+
+	Name string
+	Org  string
+}
+
+// String describes p by names, such as "write buckets mybucket in org myorg"
+// or "read all dashboards in org myorg".
+func (p ExpandedPermission) String() string {
+	var s string
+	switch {
+	case p.Resource.ID == nil:
+		s = fmt.Sprintf("%s all %s", p.Action, p.Resource.Type)
+	case p.Name != "":
+		s = fmt.Sprintf("%s %s %s", p.Action, p.Resource.Type, p.Name)
+	default:
+		s = fmt.Sprintf("%s %s %s", p.Action, p.Resource.Type, p.Resource.ID)
+	}
+	switch {
+	case p.Org != "":
+		s += " in org " + p.Org
+	case p.Resource.OrgID != nil:
+		s += " in org " + p.Resource.OrgID.String()
+	}
+	return s
+}
+
+// FindAuthorizationsWithOptions returns the authorizations that match
+// filter, as FindAuthorizations, with their permissions expanded when asked
+// by opts.
+func (s *AuthorizationService) FindAuthorizationsWithOptions(ctx context.Context, filter influxdb.AuthorizationFilter, opts AuthorizationFindOptions) ([]*ExpandedAuthorization, error) {
+	as, err := s.findAuthorizations(ctx, filter, opts.FindOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	auths := make([]*ExpandedAuthorization, 0, len(as.Auths))
+	for _, a := range as.Auths {
+		ea := &ExpandedAuthorization{Authorization: a.toPlatform()}
+		if opts.ExpandPermissions {
+			ea.Org, ea.User = a.Org, a.User
+			ea.ExpandedPermissions = make([]ExpandedPermission, 0, len(a.Permissions))
+			for _, p := range a.Permissions {
+				ea.ExpandedPermissions = append(ea.ExpandedPermissions, ExpandedPermission{
+					Permission: influxdb.Permission{Action: p.Action, Resource: p.Resource.Resource},
+					Name:       p.Resource.Name,
+					Org:        p.Resource.Organization,
+				})
+			}
+		}
+		auths = append(auths, ea)
+	}
+	return auths, nil
+}
+
+// GroupAuthorizationsByOrg groups auths by the ID of their org, each group
+// sorted by ID.
+func GroupAuthorizationsByOrg(auths []*ExpandedAuthorization) map[influxdb.ID][]*ExpandedAuthorization {
+	groups := make(map[influxdb.ID][]*ExpandedAuthorization)
+	for _, a := range auths {
+		groups[a.OrgID] = append(groups[a.OrgID], a)
+	}
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+	}
+	return groups
+}
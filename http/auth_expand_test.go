@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAuthorizationService_FindAuthorizationsWithOptions(t *testing.T) {
+	var (
+		org1     = influxtesting.MustIDBase16("020f755c3c083000")
+		org2     = influxtesting.MustIDBase16("020f755c3c083001")
+		bucketID = influxtesting.MustIDBase16("04504b356e23b000")
+		userID   = influxtesting.MustIDBase16("06e3d9f8f8e2b000")
+	)
+	writeBucket := influxdb.Permission{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &org1, ID: &bucketID},
+	}
+	readDashboards := influxdb.Permission{
+		Action:   influxdb.ReadAction,
+		Resource: influxdb.Resource{Type: influxdb.DashboardsResourceType, OrgID: &org2},
+	}
+	stored := []*influxdb.Authorization{
+		{ID: 3, OrgID: org1, UserID: userID, Status: influxdb.Active, Permissions: []influxdb.Permission{writeBucket}},
+		{ID: 2, OrgID: org2, UserID: userID, Status: influxdb.Active, Permissions: []influxdb.Permission{readDashboards}},
+		{ID: 1, OrgID: org1, UserID: userID, Status: influxdb.Active, Permissions: []influxdb.Permission{writeBucket, readDashboards}},
+	}
+	orgNames := map[influxdb.ID]string{org1: "org1", org2: "org2"}
+
+	backend := NewMockAuthorizationBackend(t)
+	backend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	backend.AuthorizationService = &mock.AuthorizationService{
+		FindAuthorizationsFn: func(context.Context, influxdb.AuthorizationFilter, ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+			return stored, len(stored), nil
+		},
+	}
+	backend.OrganizationService = &mock.OrganizationService{
+		FindOrganizationByIDF: func(_ context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: id, Name: orgNames[id]}, nil
+		},
+	}
+	backend.UserService = &mock.UserService{
+		FindUserByIDFn: func(_ context.Context, id influxdb.ID) (*influxdb.User, error) {
+			return &influxdb.User{ID: id, Name: "auditor"}, nil
+		},
+	}
+	backend.LookupService = &mock.LookupService{
+		NameFn: func(_ context.Context, rt influxdb.ResourceType, id influxdb.ID) (string, error) {
+			if rt == influxdb.OrgsResourceType {
+				return orgNames[id], nil
+			}
+			return "telegraf", nil
+		},
+	}
+	server := httptest.NewServer(NewAuthorizationHandler(zaptest.NewLogger(t), backend))
+	defer server.Close()
+
+	svc := &AuthorizationService{Client: mustNewHTTPClient(t, server.URL, "")}
+	ctx := context.Background()
+
+	t.Run("expanded", func(t *testing.T) {
+		auths, err := svc.FindAuthorizationsWithOptions(ctx, influxdb.AuthorizationFilter{}, AuthorizationFindOptions{ExpandPermissions: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(auths) != 3 {
+			t.Fatalf("unexpected authorizations: %d", len(auths))
+		}
+
+		a := auths[2]
+		if a.Org != "org1" || a.User != "auditor" {
+			t.Errorf("unexpected org and user: %q %q", a.Org, a.User)
+		}
+		if len(a.Permissions) != 2 || a.Permissions[0].String() != writeBucket.String() {
+			t.Errorf("expected the raw permissions, got %v", a.Permissions)
+		}
+		var got []string
+		for _, p := range a.ExpandedPermissions {
+			got = append(got, p.String())
+		}
+		want := []string{"write buckets telegraf in org org1", "read all dashboards in org org2"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("unexpected expanded permissions: got %q want %q", got, want)
+		}
+
+		groups := GroupAuthorizationsByOrg(auths)
+		if len(groups) != 2 {
+			t.Fatalf("unexpected groups: %v", groups)
+		}
+		if g := groups[org1]; len(g) != 2 || g[0].ID != 1 || g[1].ID != 3 {
+			t.Errorf("unexpected group of org1: %v", g)
+		}
+		if g := groups[org2]; len(g) != 1 || g[0].ID != 2 {
+			t.Errorf("unexpected group of org2: %v", g)
+		}
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		auths, err := svc.FindAuthorizationsWithOptions(ctx, influxdb.AuthorizationFilter{}, AuthorizationFindOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(auths) != 3 {
+			t.Fatalf("unexpected authorizations: %d", len(auths))
+		}
+		if a := auths[0]; a.Org != "" || a.ExpandedPermissions != nil || len(a.Permissions) != 1 {
+			t.Errorf("expected only the raw authorization, got %+v", a)
+		}
+	})
+}
+
+func TestExpandedPermission_String(t *testing.T) {
+	orgID, id := influxdb.ID(1), influxdb.ID(2)
+	tests := []struct {
+		p    ExpandedPermission
+		want string
+	}{
+		{
+			p:    ExpandedPermission{Permission: influxdb.Permission{Action: influxdb.ReadAction, Resource: influxdb.Resource{Type: influxdb.UsersResourceType}}},
+			want: "read all users",
+		},
+		{
+			p:    ExpandedPermission{Permission: influxdb.Permission{Action: influxdb.WriteAction, Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &orgID, ID: &id}}},
+			want: "write buckets 0000000000000002 in org 0000000000000001",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("unexpected description: got %q want %q", got, tt.want)
+		}
+	}
+}
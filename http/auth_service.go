@@ -657,6 +657,20 @@ func (s *AuthorizationService) FindAuthorizationByToken(ctx context.Context, tok
 // FindAuthorizations returns a list of authorizations that match filter and the total count of matching authorizations.
 // Additional options provide pagination & sorting.
 func (s *AuthorizationService) FindAuthorizations(ctx context.Context, filter influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Authorization, int, error) {
+	as, err := s.findAuthorizations(ctx, filter, opt...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	auths := make([]*influxdb.Authorization, 0, len(as.Auths))
+	for _, a := range as.Auths {
+		auths = append(auths, a.toPlatform())
+	}
+
+	return auths, len(auths), nil
+}
+
+func (s *AuthorizationService) findAuthorizations(ctx context.Context, filter influxdb.AuthorizationFilter, opt ...influxdb.FindOptions) (*authsResponse, error) {
 	params := influxdb.FindOptionParams(opt...)
 	if filter.ID != nil {
 		params = append(params, [2]string{"id", filter.ID.String()})
@@ -681,15 +695,9 @@ func (s *AuthorizationService) FindAuthorizations(ctx context.Context, filter in
 		DecodeJSON(&as).
 		Do(ctx)
 	if err != nil {
-		return nil, 0, err
-	}
-
-	auths := make([]*influxdb.Authorization, 0, len(as.Auths))
-	for _, a := range as.Auths {
-		auths = append(auths, a.toPlatform())
+		return nil, err
 	}
-
-	return auths, len(auths), nil
+	return &as, nil
 }
 
 // CreateAuthorization creates a new authorization and sets b.ID with the new identifier.
@@ -26,6 +26,11 @@ type AuthenticationHandler struct {
 	TokenParser          *jsonweb.TokenParser
 	SessionRenewDisabled bool
 
+	// V1Authorizer authenticates the v1 writes carrying a username and
+	// password rather than a token or session. Without it those writes are
+	// unauthorized.
+	V1Authorizer V1Authorizer
+
 	// This is only really used for it's lookup method the specific http
 	// handler used to register routes does not matter.
 	noAuthRouter *httprouter.Router
@@ -53,6 +58,7 @@ func (h *AuthenticationHandler) RegisterNoAuthRoute(method, path string) {
 const (
 	tokenAuthScheme   = "token"
 	sessionAuthScheme = "session"
+	v1AuthScheme      = "v1"
 )
 
 // ProbeAuthScheme probes the http request for the requests for token or cookie session.
@@ -71,6 +77,17 @@ func ProbeAuthScheme(r *http.Request) (string, error) {
 	return sessionAuthScheme, nil
 }
 
+// probeAuthScheme probes r for its auth scheme. The credentials of v1 writes
+// take precedence over tokens and sessions, as the write handler has them.
+func (h *AuthenticationHandler) probeAuthScheme(r *http.Request) (string, error) {
+	if h.V1Authorizer != nil && isV1WriteRequest(r) {
+		if _, _, ok := v1Credentials(r); ok {
+			return v1AuthScheme, nil
+		}
+	}
+	return ProbeAuthScheme(r)
+}
+
 func (h *AuthenticationHandler) unauthorized(ctx context.Context, w http.ResponseWriter, err error) {
 	h.log.Info("Unauthorized", zap.Error(err))
 	UnauthorizedError(ctx, h, w)
@@ -84,7 +101,7 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 
 	ctx := r.Context()
-	scheme, err := ProbeAuthScheme(r)
+	scheme, err := h.probeAuthScheme(r)
 	if err != nil {
 		h.unauthorized(ctx, w, err)
 		return
@@ -96,6 +113,8 @@ func (h *AuthenticationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		auth, err = h.extractAuthorization(ctx, r)
 	case sessionAuthScheme:
 		auth, err = h.extractSession(ctx, r)
+	case v1AuthScheme:
+		auth, err = h.extractV1Credentials(ctx, r)
 	default:
 		// TODO: this error will be nil if it gets here, this should be remedied with some
 		//  sentinel error I'm thinking
@@ -160,6 +179,11 @@ func (h *AuthenticationHandler) extractAuthorization(ctx context.Context, r *htt
 	return h.AuthorizationService.FindAuthorizationByToken(ctx, t)
 }
 
+func (h *AuthenticationHandler) extractV1Credentials(ctx context.Context, r *http.Request) (platform.Authorizer, error) {
+	username, password, _ := v1Credentials(r)
+	return h.V1Authorizer.AuthorizeV1(ctx, username, password)
+}
+
 func (h *AuthenticationHandler) extractSession(ctx context.Context, r *http.Request) (*platform.Session, error) {
 	k, err := decodeCookieSession(ctx, r)
 	if err != nil {
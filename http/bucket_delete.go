@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+const opDeleteBucketIfEmpty = "http/DeleteBucketIfEmpty"
+
+// BucketDeleteGuard checks that a bucket may be deleted before
+// DeleteBucketIfEmpty deletes it, returning an error when it may not.
+type BucketDeleteGuard func(ctx context.Context, b *influxdb.Bucket) error
+
+// DeleteBucketIfEmpty deletes the bucket id, as DeleteBucket, once guard
+// allows it, such as NoRecentWrites, so that automation
+// going wrong does not delete buckets still in use. A refusal of guard fails
+// with EConflict unless it has an error code of its own, and the bucket is
+// left as is.
+func (s *BucketService) DeleteBucketIfEmpty(ctx context.Context, id influxdb.ID, guard BucketDeleteGuard) error {
+	if guard == nil {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opDeleteBucketIfEmpty,
+			Msg:  "a guard is required to delete a bucket if empty",
+		}
+	}
+
+	b, err := s.FindBucketByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := guard(ctx, b); err != nil {
+		if _, ok := err.(*influxdb.Error); ok {
+			return err
+		}
+		return &influxdb.Error{
+			Code: influxdb.EConflict,
+			Op:   opDeleteBucketIfEmpty,
+			Msg:  fmt.Sprintf("bucket %q was not deleted", b.Name),
+			Err:  err,
+		}
+	}
+	return s.DeleteBucket(ctx, id)
+}
+
+// NoRecentWrites returns a guard refusing to delete buckets that have points
+// written within window of now, as queried through qs.
+func NoRecentWrites(qs query.QueryService, window time.Duration) BucketDeleteGuard {
+	return func(ctx context.Context, b *influxdb.Bucket) error {
+		itr, err := qs.Query(ctx, &query.Request{
+			OrganizationID: b.OrgID,
+			Compiler: lang.FluxCompiler{
+				Query: fmt.Sprintf(`from(bucketID: %q) |> range(start: -%dns) |> limit(n: 1)`, b.ID, window.Nanoseconds()),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		defer itr.Release()
+
+		var rows int
+		for itr.More() {
+			if err := itr.Next().Tables().Do(func(tbl flux.Table) error {
+				return tbl.Do(func(cr flux.ColReader) error {
+					rows += cr.Len()
+					return nil
+				})
+			}); err != nil {
+				return err
+			}
+		}
+		if err := itr.Err(); err != nil {
+			return err
+		}
+		if rows > 0 {
+			return &influxdb.Error{
+				Code: influxdb.EConflict,
+				Op:   opDeleteBucketIfEmpty,
+				Msg:  fmt.Sprintf("bucket %q was written to within the last %s", b.Name, window),
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBucketService_DeleteBucketIfEmpty(t *testing.T) {
+	stored := influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello"}
+
+	var deleted []influxdb.ID
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = &mock.BucketService{
+		FindBucketByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+			if id != stored.ID {
+				return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+			}
+			b := stored
+			return &b, nil
+		},
+		DeleteBucketFn: func(ctx context.Context, id influxdb.ID) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+	server := httptest.NewServer(NewBucketHandler(zaptest.NewLogger(t), bucketBackend))
+	defer server.Close()
+
+	svc := &BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+	ctx := context.Background()
+
+	t.Run("refused", func(t *testing.T) {
+		deleted = nil
+		err := svc.DeleteBucketIfEmpty(ctx, 1, func(ctx context.Context, b *influxdb.Bucket) error {
+			if b.Name != "hello" {
+				t.Errorf("unexpected bucket: %+v", b)
+			}
+			return errors.New("still in use")
+		})
+		if got := influxdb.ErrorCode(err); got != influxdb.EConflict {
+			t.Errorf("unexpected error code: got %s want %s", got, influxdb.EConflict)
+		}
+		if len(deleted) != 0 {
+			t.Errorf("expected the bucket to be kept, deleted %v", deleted)
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		deleted = nil
+		err := svc.DeleteBucketIfEmpty(ctx, 1, func(context.Context, *influxdb.Bucket) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deleted) != 1 || deleted[0] != 1 {
+			t.Errorf("unexpected deletes: %v", deleted)
+		}
+	})
+
+	t.Run("unknown bucket", func(t *testing.T) {
+		deleted = nil
+		err := svc.DeleteBucketIfEmpty(ctx, 2, func(context.Context, *influxdb.Bucket) error { return nil })
+		if got := influxdb.ErrorCode(err); got != influxdb.ENotFound {
+			t.Errorf("unexpected error code: got %s want %s", got, influxdb.ENotFound)
+		}
+		if len(deleted) != 0 {
+			t.Errorf("unexpected deletes: %v", deleted)
+		}
+	})
+
+	t.Run("no guard", func(t *testing.T) {
+		deleted = nil
+		err := svc.DeleteBucketIfEmpty(ctx, 1, nil)
+		if got := influxdb.ErrorCode(err); got != influxdb.EInvalid {
+			t.Errorf("unexpected error code: got %s want %s", got, influxdb.EInvalid)
+		}
+		if len(deleted) != 0 {
+			t.Errorf("unexpected deletes: %v", deleted)
+		}
+	})
+}
+
+func TestNoRecentWrites(t *testing.T) {
+	bucket := &influxdb.Bucket{ID: 2, OrgID: 1, Name: "hello"}
+
+	guardWith := func(rows int) (BucketDeleteGuard, *string) {
+		var got string
+		qs := &querymock.QueryService{
+			QueryF: func(ctx context.Context, req *query.Request) (flux.ResultIterator, error) {
+				got = req.Compiler.(lang.FluxCompiler).Query
+				tbl := &executetest.Table{
+					ColMeta: []flux.ColMeta{{Label: "_value", Type: flux.TFloat}},
+				}
+				for i := 0; i < rows; i++ {
+					tbl.Data = append(tbl.Data, []interface{}{1.0})
+				}
+				return flux.NewSliceResultIterator([]flux.Result{executetest.NewResult([]*executetest.Table{tbl})}), nil
+			},
+		}
+		return NoRecentWrites(qs, time.Hour), &got
+	}
+
+	guard, q := guardWith(0)
+	if err := guard(context.Background(), bucket); err != nil {
+		t.Errorf("unexpected error for a bucket without writes: %v", err)
+	}
+	if !strings.Contains(*q, `from(bucketID: "0000000000000002")`) || !strings.Contains(*q, "range(start: -3600000000000ns)") {
+		t.Errorf("unexpected query: %s", *q)
+	}
+
+	guard, _ = guardWith(1)
+	if err := guard(context.Background(), bucket); influxdb.ErrorCode(err) != influxdb.EConflict {
+		t.Errorf("expected a conflict for a bucket with recent writes, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/csv"
+	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	opListMeasurements = "http/ListMeasurements"
+
+	// DefaultMeasurementsPageSize is the number of measurements
+	// ListMeasurements reads per query when the BucketService does not set a
+	// MeasurementsPageSize.
+	DefaultMeasurementsPageSize = 1000
+)
+
+// fluxStringEscaper escapes a string to be quoted in a Flux string literal,
+// where "${" starts an interpolation.
+var fluxStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`)
+
+// ListMeasurements returns the names of the measurements of the bucket of the
+// org orgID, sorted, for browsing the schema of the bucket. The measurements
+// are read through Flux queries of the tag values of the _measurement tag over
+// all time, a page of MeasurementsPageSize measurements at a time.
+func (s *BucketService) ListMeasurements(ctx context.Context, orgID, bucketID influxdb.ID) ([]string, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	pageSize := s.MeasurementsPageSize
+	if pageSize <= 0 {
+		pageSize = DefaultMeasurementsPageSize
+	}
+
+	var measurements []string
+	for {
+		page, err := s.listMeasurementsPage(ctx, orgID, bucketID, measurements, pageSize)
+		if err != nil {
+			return nil, &influxdb.Error{
+				Op:  opListMeasurements,
+				Err: tracing.LogError(span, err),
+			}
+		}
+		measurements = append(measurements, page...)
+		if len(page) < pageSize {
+			return measurements, nil
+		}
+	}
+}
+
+// listMeasurementsPage queries the page of measurements sorted after the last
+// of the measurements already listed.
+func (s *BucketService) listMeasurementsPage(ctx context.Context, orgID, bucketID influxdb.ID, listed []string, pageSize int) ([]string, error) {
+	var after string
+	if len(listed) > 0 {
+		after = fmt.Sprintf(`
+	|> filter(fn: (r) => r._value > "%s")`, fluxStringEscaper.Replace(listed[len(listed)-1]))
+	}
+	q := fmt.Sprintf(`from(bucketID: "%s")
+	|> range(start: %s)
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")%s
+	|> sort()
+	|> limit(n: %d)`,
+		bucketID, time.Unix(0, models.MinNanoTime).UTC().Format(time.RFC3339Nano), after, pageSize)
+
+	req := QueryRequest{
+		Query: q,
+		Dialect: QueryDialect{
+			Annotations: []string{"datatype", "group", "default"},
+		},
+	}.WithDefaults()
+
+	var page []string
+	err := s.Client.
+		PostJSON(req, prefixQuery).
+		QueryParams([2]string{OrgID, orgID.String()}).
+		Accept("text/csv").
+		DecodeReader(func(r io.Reader) error {
+			itr, err := csv.NewMultiResultDecoder(csv.ResultDecoderConfig{}).Decode(ioutil.NopCloser(r))
+			if err != nil {
+				return err
+			}
+			defer itr.Release()
+			for itr.More() {
+				if err := itr.Next().Tables().Do(func(tbl flux.Table) error {
+					return tbl.Do(func(cr flux.ColReader) error {
+						j := execute.ColIdx("_value", cr.Cols())
+						if j < 0 {
+							return fmt.Errorf("measurements query returned no _value column")
+						}
+						vs := cr.Strings(j)
+						for i := 0; i < cr.Len(); i++ {
+							page = append(page, vs.ValueString(i))
+						}
+						return nil
+					})
+				}); err != nil {
+					return err
+				}
+			}
+			return itr.Err()
+		}).
+		Do(ctx)
+	return page, err
+}
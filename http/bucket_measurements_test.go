@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+func TestBucketService_ListMeasurements(t *testing.T) {
+	measurements := []string{"cpu", "disk", "mem", "net", "swap"}
+	afterRE := regexp.MustCompile(`r\._value > "([^"]*)"`)
+	limitRE := regexp.MustCompile(`limit\(n: (\d+)\)`)
+
+	// newServer serves the measurements of bucket 2 of org 1 after the one
+	// filtered on by the query, counting the queries.
+	newServer := func(t *testing.T, queries *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*queries++
+			if r.URL.Path != prefixQuery || r.URL.Query().Get(OrgID) != influxdb.ID(1).String() {
+				t.Errorf("unexpected query request: %s", r.URL)
+			}
+			var req QueryRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(req.Query, `from(bucketID: "`+influxdb.ID(2).String()+`")`) {
+				t.Errorf("unexpected query: %s", req.Query)
+			}
+			var after string
+			if m := afterRE.FindStringSubmatch(req.Query); m != nil {
+				after = m[1]
+			}
+			n, _ := strconv.Atoi(limitRE.FindStringSubmatch(req.Query)[1])
+
+			var rows strings.Builder
+			for _, m := range measurements {
+				if m > after && n > 0 {
+					fmt.Fprintf(&rows, ",,0,%s\r\n", m)
+					n--
+				}
+			}
+			// an empty result has no table at all.
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			if rows.Len() > 0 {
+				fmt.Fprint(w, "#datatype,string,long,string\r\n#group,false,false,false\r\n#default,_result,,\r\n,result,table,_value\r\n")
+				fmt.Fprint(w, rows.String(), "\r\n")
+			}
+		}))
+	}
+
+	tests := []struct {
+		name     string
+		pageSize int
+		queries  int
+	}{
+		{name: "single page", pageSize: 10, queries: 1},
+		{name: "pages", pageSize: 2, queries: 3},
+		{name: "last page full", pageSize: 5, queries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries int
+			server := newServer(t, &queries)
+			defer server.Close()
+
+			s := &BucketService{Client: mustNewHTTPClient(t, server.URL, ""), MeasurementsPageSize: tt.pageSize}
+			got, err := s.ListMeasurements(context.Background(), 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, measurements) {
+				t.Errorf("unexpected measurements: got %v want %v", got, measurements)
+			}
+			if queries != tt.queries {
+				t.Errorf("unexpected number of queries: got %d want %d", queries, tt.queries)
+			}
+		})
+	}
+
+	t.Run("query error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":"not found","message":"bucket not found"}`)
+		}))
+		defer server.Close()
+
+		s := &BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+		_, err := s.ListMeasurements(context.Background(), 1, 2)
+		if code := influxdb.ErrorCode(err); code != influxdb.ENotFound {
+			t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.ENotFound, err)
+		}
+	})
+
+	t.Run("measurement names are escaped", func(t *testing.T) {
+		if got, want := fluxStringEscaper.Replace(`a"b\c${d}`), `a\"b\\c\${d}`; got != want {
+			t.Errorf("unexpected escaped string: got %s want %s", got, want)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+const (
+	bucketSchemaMeasurementsPath = "schema/measurements"
+
+	opCreateMeasurementSchema = "CreateMeasurementSchema"
+	opListMeasurementSchemas  = "ListMeasurementSchemas"
+	opUpdateMeasurementSchema = "UpdateMeasurementSchema"
+)
+
+// The types of the columns of a measurement schema.
+const (
+	SchemaColumnTimestamp = "timestamp"
+	SchemaColumnTag       = "tag"
+	SchemaColumnField     = "field"
+)
+
+// The data types of the field columns of a measurement schema.
+const (
+	SchemaDataTypeInteger  = "integer"
+	SchemaDataTypeFloat    = "float"
+	SchemaDataTypeBoolean  = "boolean"
+	SchemaDataTypeString   = "string"
+	SchemaDataTypeUnsigned = "unsigned"
+)
+
+// MeasurementSchema is the explicit schema of a measurement of a bucket with
+// the explicit schema type, as served by InfluxDB Cloud.
+type MeasurementSchema struct {
+	ID        influxdb.ID               `json:"id,omitempty"`
+	OrgID     influxdb.ID               `json:"orgID,omitempty"`
+	BucketID  influxdb.ID               `json:"bucketID,omitempty"`
+	Name      string                    `json:"name"`
+	Columns   []MeasurementSchemaColumn `json:"columns"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	UpdatedAt time.Time                 `json:"updatedAt"`
+}
+
+// MeasurementSchemaColumn is a column of a MeasurementSchema. Type is one of
+// the SchemaColumn types and DataType, one of the SchemaDataTypes, is only set
+// for fields.
+type MeasurementSchemaColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	DataType string `json:"dataType,omitempty"`
+}
+
+// BucketSchemaService manages the measurement schemas of buckets over HTTP.
+type BucketSchemaService struct {
+	Client *httpc.Client
+}
+
+func bucketSchemaPath(bucketID influxdb.ID, measurementID ...string) []string {
+	return append([]string{prefixBuckets, bucketID.String(), bucketSchemaMeasurementsPath}, measurementID...)
+}
+
+// CreateMeasurementSchema creates the schema of the measurement schema.Name in
+// the bucket of the org orgID and returns it as stored.
+func (s *BucketSchemaService) CreateMeasurementSchema(ctx context.Context, orgID, bucketID influxdb.ID, schema MeasurementSchema) (*MeasurementSchema, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if schema.Name == "" {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opCreateMeasurementSchema,
+			Msg:  "measurement name is required",
+		}
+	}
+
+	body := struct {
+		Name    string                    `json:"name"`
+		Columns []MeasurementSchemaColumn `json:"columns"`
+	}{Name: schema.Name, Columns: schema.Columns}
+
+	var created MeasurementSchema
+	err := s.Client.
+		PostJSON(body, bucketSchemaPath(bucketID)...).
+		QueryParams([2]string{"orgID", orgID.String()}).
+		DecodeJSON(&created).
+		Do(ctx)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Op:  opCreateMeasurementSchema,
+			Err: tracing.LogError(span, err),
+		}
+	}
+	return &created, nil
+}
+
+// ListMeasurementSchemas returns the measurement schemas of the bucket of the
+// org orgID.
+func (s *BucketSchemaService) ListMeasurementSchemas(ctx context.Context, orgID, bucketID influxdb.ID) ([]*MeasurementSchema, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	var resp struct {
+		MeasurementSchemas []*MeasurementSchema `json:"measurementSchemas"`
+	}
+	err := s.Client.
+		Get(bucketSchemaPath(bucketID)...).
+		QueryParams([2]string{"orgID", orgID.String()}).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Op:  opListMeasurementSchemas,
+			Err: tracing.LogError(span, err),
+		}
+	}
+	return resp.MeasurementSchemas, nil
+}
+
+// UpdateMeasurementSchema replaces the columns of the measurement schema
+// measurementID of the bucket of the org orgID and returns it as stored. The
+// columns of a schema can only be added to: InfluxDB rejects updates that
+// remove or change a column.
+func (s *BucketSchemaService) UpdateMeasurementSchema(ctx context.Context, orgID, bucketID, measurementID influxdb.ID, columns []MeasurementSchemaColumn) (*MeasurementSchema, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	body := struct {
+		Columns []MeasurementSchemaColumn `json:"columns"`
+	}{Columns: columns}
+
+	var updated MeasurementSchema
+	err := s.Client.
+		PatchJSON(body, bucketSchemaPath(bucketID, measurementID.String())...).
+		QueryParams([2]string{"orgID", orgID.String()}).
+		DecodeJSON(&updated).
+		Do(ctx)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Op:  opUpdateMeasurementSchema,
+			Err: tracing.LogError(span, err),
+		}
+	}
+	return &updated, nil
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+func TestBucketSchemaService(t *testing.T) {
+	const (
+		orgID         = "043e0780ee2b1000"
+		bucketID      = "04504b356e23b000"
+		measurementID = "04504b356e23b001"
+	)
+	schemasPath := prefixBuckets + "/" + bucketID + "/" + bucketSchemaMeasurementsPath
+
+	var bodies []string
+	stored := fmtSchema(measurementID, orgID, bucketID, `[{"name":"time","type":"timestamp"},{"name":"host","type":"tag"},{"name":"usage","type":"field","dataType":"float"}]`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("orgID"); got != orgID {
+			t.Errorf("unexpected orgID: %q", got)
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == schemasPath:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(stored))
+		case r.Method == http.MethodGet && r.URL.Path == schemasPath:
+			w.Write([]byte(`{"measurementSchemas":[` + stored + `]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == schemasPath+"/"+measurementID:
+			w.Write([]byte(fmtSchema(measurementID, orgID, bucketID, `[{"name":"time","type":"timestamp"},{"name":"host","type":"tag"},{"name":"usage","type":"field","dataType":"float"},{"name":"idle","type":"field","dataType":"integer"}]`)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not found","message":"path not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(server.URL, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewService(client, server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	oid, bid, mid := influxtesting.MustIDBase16(orgID), influxtesting.MustIDBase16(bucketID), influxtesting.MustIDBase16(measurementID)
+	columns := []MeasurementSchemaColumn{
+		{Name: "time", Type: SchemaColumnTimestamp},
+		{Name: "host", Type: SchemaColumnTag},
+		{Name: "usage", Type: SchemaColumnField, DataType: SchemaDataTypeFloat},
+	}
+
+	created, err := svc.CreateMeasurementSchema(ctx, oid, bid, MeasurementSchema{Name: "cpu", Columns: columns})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"cpu","columns":[{"name":"time","type":"timestamp"},{"name":"host","type":"tag"},{"name":"usage","type":"field","dataType":"float"}]}`; bodies[0] != want+"\n" {
+		t.Errorf("unexpected create body:\ngot  %s\nwant %s", bodies[0], want)
+	}
+	if created.ID != mid || created.BucketID != bid || created.Name != "cpu" || !reflect.DeepEqual(created.Columns, columns) {
+		t.Errorf("unexpected created schema: %+v", created)
+	}
+
+	schemas, err := svc.ListMeasurementSchemas(ctx, oid, bid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 1 || !reflect.DeepEqual(schemas[0], created) {
+		t.Errorf("unexpected schemas: %+v", schemas)
+	}
+
+	columns = append(columns, MeasurementSchemaColumn{Name: "idle", Type: SchemaColumnField, DataType: SchemaDataTypeInteger})
+	updated, err := svc.UpdateMeasurementSchema(ctx, oid, bid, mid, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated.Columns, columns) {
+		t.Errorf("unexpected updated columns: %+v", updated.Columns)
+	}
+
+	t.Run("unknown bucket", func(t *testing.T) {
+		_, err := svc.ListMeasurementSchemas(ctx, oid, mid)
+		if code := influxdb.ErrorCode(err); code != influxdb.ENotFound {
+			t.Errorf("unexpected error code: got %q want %q: %v", code, influxdb.ENotFound, err)
+		}
+	})
+
+	t.Run("name required", func(t *testing.T) {
+		_, err := svc.CreateMeasurementSchema(ctx, oid, bid, MeasurementSchema{Columns: columns})
+		if code := influxdb.ErrorCode(err); code != influxdb.EInvalid {
+			t.Errorf("unexpected error code: got %q want %q: %v", code, influxdb.EInvalid, err)
+		}
+	})
+}
+
+func fmtSchema(id, orgID, bucketID, columns string) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"id":        id,
+		"orgID":     orgID,
+		"bucketID":  bucketID,
+		"name":      "cpu",
+		"columns":   json.RawMessage(columns),
+		"createdAt": "2021-01-01T00:00:00Z",
+		"updatedAt": "2021-01-01T00:00:00Z",
+	})
+	return string(b)
+}
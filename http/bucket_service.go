@@ -2,7 +2,9 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"strings"
@@ -59,6 +61,8 @@ type BucketHandler struct {
 	OrganizationService        influxdb.OrganizationService
 }
 
+const opUpsertBucket = "UpsertBucket"
+
 const (
 	prefixBuckets          = "/api/v2/buckets"
 	bucketsIDPath          = "/api/v2/buckets/:id"
@@ -215,6 +219,10 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 }
 
 // bucketUpdate is used for serialization/deserialization with retention rules.
+// A nil RetentionRules leaves the retention period unchanged, while an empty
+// one sets it to infinite. Plain JSON updates decoded by handlePatchBucket
+// never leave it nil, as their retention has always been infinite without
+// retention rules.
 type bucketUpdate struct {
 	Name           *string         `json:"name,omitempty"`
 	Description    *string         `json:"description,omitempty"`
@@ -236,17 +244,20 @@ func (b *bucketUpdate) toInfluxDB() *influxdb.BucketUpdate {
 		return nil
 	}
 
-	// For now, only use a single retention rule.
-	var d time.Duration
-	if len(b.RetentionRules) > 0 {
-		d, _ = b.RetentionRules[0].RetentionPeriod()
+	upd := &influxdb.BucketUpdate{
+		Name:        b.Name,
+		Description: b.Description,
 	}
 
-	return &influxdb.BucketUpdate{
-		Name:            b.Name,
-		Description:     b.Description,
-		RetentionPeriod: &d,
+	// For now, only use a single retention rule.
+	if b.RetentionRules != nil {
+		var d time.Duration
+		if len(b.RetentionRules) > 0 {
+			d, _ = b.RetentionRules[0].RetentionPeriod()
+		}
+		upd.RetentionPeriod = &d
 	}
+	return upd
 }
 
 func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
@@ -255,17 +266,19 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 	}
 
 	up := &bucketUpdate{
-		Name:           pb.Name,
-		Description:    pb.Description,
-		RetentionRules: []retentionRule{},
+		Name:        pb.Name,
+		Description: pb.Description,
 	}
 
 	if pb.RetentionPeriod != nil {
-		d := int64((*pb.RetentionPeriod).Round(time.Second) / time.Second)
-		up.RetentionRules = append(up.RetentionRules, retentionRule{
-			Type:         "expire",
-			EverySeconds: d,
-		})
+		up.RetentionRules = []retentionRule{}
+		if *pb.RetentionPeriod != 0 {
+			d := int64((*pb.RetentionPeriod).Round(time.Second) / time.Second)
+			up.RetentionRules = append(up.RetentionRules, retentionRule{
+				Type:         "expire",
+				EverySeconds: d,
+			})
+		}
 	}
 	return up
 }
@@ -530,9 +543,23 @@ func (h *BucketHandler) handlePatchBucket(w http.ResponseWriter, r *http.Request
 	}
 
 	var reqBody bucketUpdate
-	if err := h.api.DecodeJSON(r.Body, &reqBody); err != nil {
-		h.api.Err(w, r, err)
-		return
+	if isMergePatch(r) {
+		upd, err := decodeBucketMergePatch(r.Body)
+		if err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		reqBody = *upd
+	} else {
+		if err := h.api.DecodeJSON(r.Body, &reqBody); err != nil {
+			h.api.Err(w, r, err)
+			return
+		}
+		// only merge patches leave the retention of the bucket unchanged
+		// when they have no retention rules.
+		if reqBody.RetentionRules == nil {
+			reqBody.RetentionRules = []retentionRule{}
+		}
 	}
 
 	if reqBody.Name != nil {
@@ -575,6 +602,14 @@ type BucketService struct {
 	// OpPrefix is an additional property for error
 	// find bucket service, when finds nothing.
 	OpPrefix string
+	// CreateOrGet makes CreateBucket succeed when the org already has a
+	// bucket of the name, setting the bucket to it, as CreateOrGetBucket.
+	CreateOrGet bool
+	// MeasurementsPageSize is the number of measurements ListMeasurements
+	// reads per query, DefaultMeasurementsPageSize when unset.
+	MeasurementsPageSize int
+
+	names *nameCache
 }
 
 // FindBucketByName returns a single bucket by name
@@ -590,10 +625,13 @@ func (s *BucketService) FindBucketByName(ctx context.Context, orgID influxdb.ID,
 		}
 	}
 
-	bkts, n, err := s.FindBuckets(ctx, influxdb.BucketFilter{
-		Name:           &name,
-		OrganizationID: &orgID,
-	})
+	filter := influxdb.NewBucketFilter().WithOrgID(orgID).WithName(name)
+	key, _ := bucketKey(filter)
+	if b, ok := s.names.findBucket(key); ok {
+		return b, nil
+	}
+
+	bkts, n, err := s.FindBuckets(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -605,6 +643,7 @@ func (s *BucketService) FindBucketByName(ctx context.Context, orgID influxdb.ID,
 		}
 	}
 
+	s.names.addBucket(key, bkts[0])
 	return bkts[0], nil
 }
 
@@ -630,6 +669,13 @@ func (s *BucketService) FindBucket(ctx context.Context, filter influxdb.BucketFi
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	key, byName := bucketKey(filter)
+	if byName {
+		if b, ok := s.names.findBucket(key); ok {
+			return b, nil
+		}
+	}
+
 	bs, n, err := s.FindBuckets(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -649,6 +695,9 @@ func (s *BucketService) FindBucket(ctx context.Context, filter influxdb.BucketFi
 		}
 	}
 
+	if byName {
+		s.names.addBucket(key, bs[0])
+	}
 	return bs[0], nil
 }
 
@@ -658,24 +707,10 @@ func (s *BucketService) FindBuckets(ctx context.Context, filter influxdb.BucketF
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
-	params := influxdb.FindOptionParams(opt...)
-	if filter.OrganizationID != nil {
-		params = append(params, [2]string{"orgID", filter.OrganizationID.String()})
-	}
-	if filter.Org != nil {
-		params = append(params, [2]string{"org", *filter.Org})
-	}
-	if filter.ID != nil {
-		params = append(params, [2]string{"id", filter.ID.String()})
-	}
-	if filter.Name != nil {
-		params = append(params, [2]string{"name", (*filter.Name)})
-	}
-
 	var bs bucketsResponse
 	err := s.Client.
 		Get(prefixBuckets).
-		QueryParams(params...).
+		QueryParams(findBucketsParams(filter, opt...)...).
 		DecodeJSON(&bs).
 		Do(ctx)
 	if err != nil {
@@ -695,8 +730,237 @@ func (s *BucketService) FindBuckets(ctx context.Context, filter influxdb.BucketF
 	return buckets, len(buckets), nil
 }
 
+// FindBucketsStream calls fn with each bucket matching filter as the response
+// is decoded, rather than holding all of them in memory as FindBuckets does.
+// It stops at the first error returned by fn, which it returns.
+func (s *BucketService) FindBucketsStream(ctx context.Context, filter influxdb.BucketFilter, fn func(*influxdb.Bucket) error, opt ...influxdb.FindOptions) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	// errors of fn are returned as is rather than as decoding errors.
+	var fnErr error
+	err := s.Client.
+		Get(prefixBuckets).
+		QueryParams(findBucketsParams(filter, opt...)...).
+		DecodeReader(func(r io.Reader) error {
+			return decodeBucketsStream(r, func(b *influxdb.Bucket) error {
+				if err := fn(b); err != nil {
+					fnErr = err
+					return err
+				}
+				return nil
+			})
+		}).
+		Do(ctx)
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// decodeBucketsStream decodes the buckets of a bucketsResponse one at a time,
+// calling fn with each of them.
+func decodeBucketsStream(r io.Reader, fn func(*influxdb.Bucket) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key, _ := tok.(string); key != "buckets" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			var b bucketResponse
+			if err := dec.Decode(&b); err != nil {
+				return err
+			}
+			pb, err := b.bucket.toInfluxDB()
+			if err != nil {
+				return err
+			}
+			if err := fn(pb); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q but got %v", want, tok)
+	}
+	return nil
+}
+
+func findBucketsParams(filter influxdb.BucketFilter, opt ...influxdb.FindOptions) [][2]string {
+	params := influxdb.FindOptionParams(opt...)
+	if filter.OrganizationID != nil {
+		params = append(params, [2]string{"orgID", filter.OrganizationID.String()})
+	}
+	if filter.Org != nil {
+		params = append(params, [2]string{"org", *filter.Org})
+	}
+	if filter.ID != nil {
+		params = append(params, [2]string{"id", filter.ID.String()})
+	}
+	if filter.Name != nil {
+		params = append(params, [2]string{"name", (*filter.Name)})
+	}
+	return params
+}
+
+// BucketIterator pages through all the buckets matching a filter. A new
+// page is requested from the server whenever the current one is exhausted.
+//
+//	it := s.NewBucketIterator(filter, influxdb.FindOptions{Limit: 100})
+//	for it.Next(ctx) {
+//		b := it.Bucket()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type BucketIterator struct {
+	svc    *BucketService
+	filter influxdb.BucketFilter
+	opts   influxdb.FindOptions
+
+	// seen tracks the buckets returned so far. The server may append
+	// system buckets to a page, which must not be returned twice.
+	seen map[influxdb.ID]bool
+	page []*influxdb.Bucket
+	cur  *influxdb.Bucket
+	done bool
+	err  error
+}
+
+// NewBucketIterator returns an iterator over all the buckets that match filter.
+// The limit of opts sets the page size and defaults to influxdb.MaxPageSize.
+// The offset of opts is the position of the first bucket returned.
+func (s *BucketService) NewBucketIterator(filter influxdb.BucketFilter, opts influxdb.FindOptions) *BucketIterator {
+	if opts.Limit <= 0 {
+		opts.Limit = influxdb.MaxPageSize
+	}
+	return &BucketIterator{
+		svc:    s,
+		filter: filter,
+		opts:   opts,
+		seen:   make(map[influxdb.ID]bool),
+	}
+}
+
+// Next advances the iterator to the next bucket, fetching the next page of
+// buckets when needed. It returns false when there are no more buckets or
+// an error occurred.
+func (it *BucketIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch(ctx)
+	}
+
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+func (it *BucketIterator) fetch(ctx context.Context) {
+	bs, _, err := it.svc.FindBuckets(ctx, it.filter, it.opts)
+	if err != nil {
+		it.err = err
+		return
+	}
+	// the system buckets filled in by the server are not part of its paging,
+	// so only the other buckets of the page move the offset.
+	n := 0
+	for _, b := range bs {
+		if !isFilledSystemBucket(b) {
+			n++
+		}
+	}
+	it.opts.Offset += n
+	it.done = n < it.opts.Limit
+
+	it.page = it.page[:0]
+	for _, b := range bs {
+		if it.seen[b.ID] {
+			continue
+		}
+		it.seen[b.ID] = true
+		it.page = append(it.page, b)
+	}
+	if len(it.page) == 0 {
+		it.done = true
+	}
+}
+
+// isFilledSystemBucket reports whether b is one of the system buckets the
+// server fills in for orgs that do not have them stored, which have fixed IDs.
+func isFilledSystemBucket(b *influxdb.Bucket) bool {
+	return b.ID == influxdb.TasksSystemBucketID || b.ID == influxdb.MonitoringSystemBucketID
+}
+
+// Bucket returns the current bucket of the iterator.
+func (it *BucketIterator) Bucket() *influxdb.Bucket {
+	return it.cur
+}
+
+// Err returns the first error encountered while paging through buckets.
+func (it *BucketIterator) Err() error {
+	return it.err
+}
+
 // CreateBucket creates a new bucket and sets b.ID with the new identifier.
 func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	if s.CreateOrGet {
+		_, err := s.CreateOrGetBucket(ctx, b)
+		return err
+	}
+	return s.createBucket(ctx, b)
+}
+
+// CreateOrGetBucket creates the bucket b, as CreateBucket, unless the org
+// b.OrgID already has a bucket named b.Name, in which case b is set to that
+// bucket as it is stored. It returns whether the bucket was created. Unlike
+// a lookup before the create, a bucket created by another client in the
+// meantime is returned rather than failing the create with a conflict.
+func (s *BucketService) CreateOrGetBucket(ctx context.Context, b *influxdb.Bucket) (bool, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	err := s.createBucket(ctx, b)
+	if influxdb.ErrorCode(err) != influxdb.EConflict {
+		return err == nil, err
+	}
+	existing, ferr := s.FindBucketByName(ctx, b.OrgID, b.Name)
+	if ferr != nil {
+		// the conflict was not on the name of the bucket.
+		return false, err
+	}
+	*b = *existing
+	return false, nil
+}
+
+func (s *BucketService) createBucket(ctx context.Context, b *influxdb.Bucket) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
@@ -706,7 +970,10 @@ func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) er
 		DecodeJSON(&br).
 		Do(ctx)
 	if err != nil {
-		return err
+		return &influxdb.Error{
+			Op:  s.OpPrefix + influxdb.OpCreateBucket,
+			Err: err,
+		}
 	}
 
 	pb, err := br.toInfluxDB()
@@ -717,25 +984,78 @@ func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) er
 	return nil
 }
 
-// UpdateBucket updates a single bucket with changeset.
+// UpdateBucket updates a single bucket with changeset. Only the fields set on
+// upd are changed, a zero retention period sets the retention to infinite.
+// The changeset is sent as a JSON Merge Patch of the fields set, so that a
+// description set to the empty string clears it.
 // Returns the new bucket state after update.
 func (s *BucketService) UpdateBucket(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
 	var br bucketResponse
 	err := s.Client.
 		PatchJSON(newBucketUpdate(&upd), bucketIDPath(id)).
+		ContentType(mergePatchContentType).
 		DecodeJSON(&br).
 		Do(ctx)
 	if err != nil {
-		return nil, err
+		return nil, &influxdb.Error{
+			Op:  s.OpPrefix + influxdb.OpUpdateBucket,
+			Err: err,
+		}
 	}
+	s.names.invalidateID(nameCacheBucket, id)
 	return br.toInfluxDB()
 }
 
+// UpsertBucket creates the bucket named b.Name in the organization b.OrgID,
+// or updates the description and retention period of the bucket of that name
+// when it exists, and returns the bucket as it is stored. A bucket created by
+// another client between the lookup and the create is updated instead.
+func (s *BucketService) UpsertBucket(ctx context.Context, b *influxdb.Bucket) (*influxdb.Bucket, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	for attempt := 1; ; attempt++ {
+		existing, err := s.FindBucketByName(ctx, b.OrgID, b.Name)
+		if err == nil {
+			if existing.Description == b.Description && existing.RetentionPeriod == b.RetentionPeriod {
+				return existing, nil
+			}
+			return s.UpdateBucket(ctx, existing.ID, influxdb.BucketUpdate{
+				Description:     &b.Description,
+				RetentionPeriod: &b.RetentionPeriod,
+			})
+		}
+		if influxdb.ErrorCode(err) != influxdb.ENotFound {
+			return nil, &influxdb.Error{
+				Err: err,
+				Op:  s.OpPrefix + opUpsertBucket,
+			}
+		}
+
+		created := *b
+		err = s.CreateBucket(ctx, &created)
+		if err == nil {
+			return &created, nil
+		}
+		if influxdb.ErrorCode(err) != influxdb.EConflict || attempt == maxUpsertAttempts {
+			return nil, &influxdb.Error{
+				Err: err,
+				Op:  s.OpPrefix + opUpsertBucket,
+			}
+		}
+	}
+}
+
 // DeleteBucket removes a bucket by ID.
 func (s *BucketService) DeleteBucket(ctx context.Context, id influxdb.ID) error {
-	return s.Client.
-		Delete(bucketIDPath(id)).
-		Do(ctx)
+	if err := s.Client.Delete(bucketIDPath(id)).Do(ctx); err != nil {
+		return err
+	}
+	s.names.invalidateID(nameCacheBucket, id)
+	return nil
 }
 
 // validBucketName reports any errors with bucket names
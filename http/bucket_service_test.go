@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -1249,6 +1252,214 @@ func TestBucketService(t *testing.T) {
 	platformtesting.BucketService(initBucketService, t)
 }
 
+func TestBucketService_NewBucketIterator(t *testing.T) {
+	var stored []*influxdb.Bucket
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		stored = append(stored, &influxdb.Bucket{ID: influxdb.ID(i + 1), OrgID: 1, Name: name})
+	}
+	system := []*influxdb.Bucket{
+		{ID: influxdb.TasksSystemBucketID, OrgID: 1, Name: influxdb.TasksSystemBucketName, Type: influxdb.BucketTypeSystem},
+		{ID: influxdb.MonitoringSystemBucketID, OrgID: 1, Name: influxdb.MonitoringSystemBucketName, Type: influxdb.BucketTypeSystem},
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		// everyPage fills in the system buckets on every page rather than
+		// only on a partial one.
+		everyPage bool
+	}{
+		{name: "pages smaller than the total", limit: 2},
+		{name: "page boundary at the last bucket", limit: 5},
+		{name: "page size that fills with system buckets", limit: 6},
+		{name: "default page size", limit: 0},
+		{name: "system buckets on every page", limit: 2, everyPage: true},
+		{name: "system buckets on every page at the page boundary", limit: 5, everyPage: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucketBackend := NewMockBucketBackend(t)
+			bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+			bucketBackend.BucketService = &mock.BucketService{
+				FindBucketsFn: func(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+					offset, limit := opts[0].Offset, opts[0].Limit
+					if offset > len(stored) {
+						offset = len(stored)
+					}
+					end := offset + limit
+					if end > len(stored) {
+						end = len(stored)
+					}
+					bs := append([]*influxdb.Bucket{}, stored[offset:end]...)
+					if len(bs) < limit || tt.everyPage {
+						// mimic the server filling in system buckets
+						bs = append(bs, system...)
+					}
+					return bs, len(bs), nil
+				},
+			}
+			server := httptest.NewServer(NewBucketHandler(zaptest.NewLogger(t), bucketBackend))
+			defer server.Close()
+
+			svc := &BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+			orgID := influxdb.ID(1)
+			it := svc.NewBucketIterator(influxdb.BucketFilter{OrganizationID: &orgID}, influxdb.FindOptions{Limit: tt.limit})
+
+			var names []string
+			for it.Next(context.Background()) {
+				names = append(names, it.Bucket().Name)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sort.Strings(names)
+			want := []string{influxdb.MonitoringSystemBucketName, influxdb.TasksSystemBucketName, "a", "b", "c", "d", "e"}
+			if got, want := strings.Join(names, ","), strings.Join(want, ","); got != want {
+				t.Errorf("unexpected buckets: got %s want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestBucketService_FindBucketsStream(t *testing.T) {
+	var stored []*influxdb.Bucket
+	for i, name := range []string{"a", "b", "c"} {
+		stored = append(stored, &influxdb.Bucket{ID: influxdb.ID(i + 1), OrgID: 1, Name: name})
+	}
+
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = &mock.BucketService{
+		FindBucketsFn: func(ctx context.Context, filter influxdb.BucketFilter, opts ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+			return stored, len(stored), nil
+		},
+	}
+	server := httptest.NewServer(NewBucketHandler(zaptest.NewLogger(t), bucketBackend))
+	defer server.Close()
+
+	svc := &BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+	orgID := influxdb.ID(1)
+	filter := influxdb.BucketFilter{OrganizationID: &orgID}
+
+	t.Run("yields all buckets", func(t *testing.T) {
+		var names []string
+		err := svc.FindBucketsStream(context.Background(), filter, func(b *influxdb.Bucket) error {
+			names = append(names, b.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, want := strings.Join(names, ","), "a,b,c"; got != want {
+			t.Errorf("unexpected buckets: got %s want %s", got, want)
+		}
+	})
+
+	t.Run("stops at the first callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		var names []string
+		err := svc.FindBucketsStream(context.Background(), filter, func(b *influxdb.Bucket) error {
+			names = append(names, b.Name)
+			if b.Name == "b" {
+				return stop
+			}
+			return nil
+		})
+		if err != stop {
+			t.Fatalf("unexpected error: got %v want %v", err, stop)
+		}
+		if got, want := strings.Join(names, ","), "a,b"; got != want {
+			t.Errorf("unexpected buckets: got %s want %s", got, want)
+		}
+	})
+}
+
+func TestBucketService_UpdateBucket(t *testing.T) {
+	stored := influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello", Description: "greetings", RetentionPeriod: time.Hour}
+
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = &mock.BucketService{
+		FindBucketByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+			if id != stored.ID {
+				return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+			}
+			b := stored
+			return &b, nil
+		},
+		UpdateBucketFn: func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+			if id != stored.ID {
+				return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+			}
+			b := stored
+			if upd.Name != nil {
+				b.Name = *upd.Name
+			}
+			if upd.Description != nil {
+				b.Description = *upd.Description
+			}
+			if upd.RetentionPeriod != nil {
+				b.RetentionPeriod = *upd.RetentionPeriod
+			}
+			return &b, nil
+		},
+	}
+	server := httptest.NewServer(NewBucketHandler(zaptest.NewLogger(t), bucketBackend))
+	defer server.Close()
+
+	svc := &BucketService{Client: mustNewHTTPClient(t, server.URL, ""), OpPrefix: "client/"}
+
+	name, desc := "example", "updated"
+	infinite, day := time.Duration(0), 24*time.Hour
+	tests := []struct {
+		name string
+		upd  influxdb.BucketUpdate
+		want influxdb.Bucket
+	}{
+		{
+			name: "name only",
+			upd:  influxdb.BucketUpdate{Name: &name},
+			want: influxdb.Bucket{ID: 1, OrgID: 1, Name: name, Description: "greetings", RetentionPeriod: time.Hour},
+		},
+		{
+			name: "description only",
+			upd:  influxdb.BucketUpdate{Description: &desc},
+			want: influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello", Description: desc, RetentionPeriod: time.Hour},
+		},
+		{
+			name: "retention only",
+			upd:  influxdb.BucketUpdate{RetentionPeriod: &day},
+			want: influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello", Description: "greetings", RetentionPeriod: day},
+		},
+		{
+			name: "infinite retention",
+			upd:  influxdb.BucketUpdate{RetentionPeriod: &infinite},
+			want: influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello", Description: "greetings"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.UpdateBucket(context.Background(), 1, tt.upd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Name != tt.want.Name || got.Description != tt.want.Description || got.RetentionPeriod != tt.want.RetentionPeriod {
+				t.Errorf("unexpected bucket: got %+v want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("errors carry the op prefix", func(t *testing.T) {
+		_, err := svc.UpdateBucket(context.Background(), 2, influxdb.BucketUpdate{Name: &name})
+		if got := influxdb.ErrorCode(err); got != influxdb.ENotFound {
+			t.Errorf("unexpected error code: got %s want %s", got, influxdb.ENotFound)
+		}
+		if got := influxdb.ErrorOp(err); got != "client/"+influxdb.OpUpdateBucket {
+			t.Errorf("unexpected error op: got %s", got)
+		}
+	})
+}
+
 func mustNewHTTPClient(t *testing.T, addr, token string) *httpc.Client {
 	t.Helper()
 
@@ -1258,3 +1469,135 @@ func mustNewHTTPClient(t *testing.T, addr, token string) *httpc.Client {
 	}
 	return httpClient
 }
+
+// racingBucketService creates the bucket of the first create on behalf of
+// another client just before the create.
+type racingBucketService struct {
+	influxdb.BucketService
+	raced bool
+}
+
+func (s *racingBucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	if !s.raced {
+		s.raced = true
+		other := *b
+		if err := s.BucketService.CreateBucket(ctx, &other); err != nil {
+			return err
+		}
+	}
+	return s.BucketService.CreateBucket(ctx, b)
+}
+
+func TestBucketService_UpsertBucket(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	svc := kv.NewService(logger, NewTestInmemStore(t))
+	org := &influxdb.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	racing := &racingBucketService{BucketService: svc, raced: true}
+
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = racing
+	bucketBackend.OrganizationService = svc
+	server := httptest.NewServer(NewBucketHandler(logger, bucketBackend))
+	defer server.Close()
+
+	client := BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	created, err := client.UpsertBucket(ctx, &influxdb.Bucket{OrgID: org.ID, Name: "bucket", RetentionPeriod: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.ID.Valid() || created.RetentionPeriod != time.Hour {
+		t.Fatalf("unexpected created bucket: %+v", created)
+	}
+
+	updated, err := client.UpsertBucket(ctx, &influxdb.Bucket{OrgID: org.ID, Name: "bucket", Description: "metrics"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != created.ID || updated.Description != "metrics" || updated.RetentionPeriod != 0 {
+		t.Errorf("unexpected updated bucket: %+v", updated)
+	}
+
+	t.Run("created by another client", func(t *testing.T) {
+		racing.raced = false
+		got, err := client.UpsertBucket(ctx, &influxdb.Bucket{OrgID: org.ID, Name: "raced", RetentionPeriod: 2 * time.Hour})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.RetentionPeriod != 2*time.Hour {
+			t.Errorf("unexpected bucket: %+v", got)
+		}
+	})
+}
+
+func TestBucketService_CreateOrGetBucket(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	svc := kv.NewService(logger, NewTestInmemStore(t))
+	org := &influxdb.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	racing := &racingBucketService{BucketService: svc, raced: true}
+
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = racing
+	bucketBackend.OrganizationService = svc
+	server := httptest.NewServer(NewBucketHandler(logger, bucketBackend))
+	defer server.Close()
+
+	client := BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	b := &influxdb.Bucket{OrgID: org.ID, Name: "bucket", RetentionPeriod: time.Hour}
+	created, err := client.CreateOrGetBucket(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || !b.ID.Valid() {
+		t.Fatalf("expected the bucket to be created, got %v %+v", created, b)
+	}
+
+	existing := &influxdb.Bucket{OrgID: org.ID, Name: "bucket", RetentionPeriod: 2 * time.Hour}
+	created, err = client.CreateOrGetBucket(ctx, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || existing.ID != b.ID || existing.RetentionPeriod != time.Hour {
+		t.Errorf("expected the existing bucket, got %v %+v", created, existing)
+	}
+
+	t.Run("created by another client", func(t *testing.T) {
+		racing.raced = false
+		b := &influxdb.Bucket{OrgID: org.ID, Name: "raced"}
+		created, err := client.CreateOrGetBucket(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created || !b.ID.Valid() {
+			t.Errorf("expected the bucket of the other client, got %v %+v", created, b)
+		}
+	})
+
+	t.Run("CreateOrGet", func(t *testing.T) {
+		client := BucketService{Client: mustNewHTTPClient(t, server.URL, ""), CreateOrGet: true}
+		existing := &influxdb.Bucket{OrgID: org.ID, Name: "bucket"}
+		if err := client.CreateBucket(ctx, existing); err != nil {
+			t.Fatal(err)
+		}
+		if existing.ID != b.ID {
+			t.Errorf("expected the existing bucket, got %+v", existing)
+		}
+
+		plain := BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+		err := plain.CreateBucket(ctx, &influxdb.Bucket{OrgID: org.ID, Name: "bucket"})
+		if got := influxdb.ErrorCode(err); got != influxdb.EConflict {
+			t.Errorf("expected a conflict without CreateOrGet, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// mergePatchContentType is the content type of a JSON Merge Patch, RFC 7386.
+const mergePatchContentType = "application/merge-patch+json"
+
+// MarshalJSON encodes the fields of the update that are set, so that the
+// fields it leaves unset are left unchanged by a merge patch.
+func (b bucketUpdate) MarshalJSON() ([]byte, error) {
+	patch := make(map[string]interface{}, 3)
+	if b.Name != nil {
+		patch["name"] = *b.Name
+	}
+	if b.Description != nil {
+		patch["description"] = *b.Description
+	}
+	if b.RetentionRules != nil {
+		patch["retentionRules"] = b.RetentionRules
+	}
+	return json.Marshal(patch)
+}
+
+// isMergePatch returns whether the body of r is a JSON Merge Patch.
+func isMergePatch(r *http.Request) bool {
+	ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && ct == mergePatchContentType
+}
+
+// decodeBucketMergePatch decodes the JSON Merge Patch of a bucket update
+// from r. Fields absent from the patch are left unchanged while a null
+// clears them: a null description empties it and null retention rules make
+// the retention infinite. The name of a bucket cannot be cleared.
+func decodeBucketMergePatch(r io.Reader) (*bucketUpdate, error) {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&patch); err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "unable to decode bucket merge patch",
+			Err:  err,
+		}
+	}
+
+	upd := &bucketUpdate{}
+	for field, raw := range patch {
+		null := string(raw) == "null"
+		var err error
+		switch field {
+		case "name":
+			if null {
+				return nil, &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Msg:  "bucket name cannot be cleared",
+				}
+			}
+			err = json.Unmarshal(raw, &upd.Name)
+		case "description":
+			upd.Description = new(string)
+			if !null {
+				err = json.Unmarshal(raw, upd.Description)
+			}
+		case "retentionRules":
+			upd.RetentionRules = []retentionRule{}
+			if !null {
+				err = json.Unmarshal(raw, &upd.RetentionRules)
+			}
+		}
+		if err != nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  "invalid " + field + " in bucket merge patch",
+				Err:  err,
+			}
+		}
+	}
+	if err := upd.OK(); err != nil {
+		return nil, err
+	}
+	return upd, nil
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestBucketService_UpdateBucket_MergePatch(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(b)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"0000000000000001","orgID":"0000000000000001","name":"hello"}`))
+	}))
+	defer server.Close()
+
+	svc := &BucketService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	name, desc, empty := "example", "updated", ""
+	day := 24 * time.Hour
+	tests := []struct {
+		name string
+		upd  influxdb.BucketUpdate
+		want string
+	}{
+		{
+			name: "unset fields are not sent",
+			upd:  influxdb.BucketUpdate{Description: &desc},
+			want: `{"description":"updated"}`,
+		},
+		{
+			name: "empty description is sent to clear it",
+			upd:  influxdb.BucketUpdate{Description: &empty},
+			want: `{"description":""}`,
+		},
+		{
+			name: "all fields",
+			upd:  influxdb.BucketUpdate{Name: &name, Description: &desc, RetentionPeriod: &day},
+			want: `{"description":"updated","name":"example","retentionRules":[{"type":"expire","everySeconds":86400}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.UpdateBucket(context.Background(), 1, tt.upd); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if contentType != mergePatchContentType {
+				t.Errorf("unexpected content type: got %q want %q", contentType, mergePatchContentType)
+			}
+			if got := strings.TrimSpace(body); got != tt.want {
+				t.Errorf("unexpected patch: got %s want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_handlePatchBucket_MergePatch(t *testing.T) {
+	stored := influxdb.Bucket{ID: 1, OrgID: 1, Name: "hello", Description: "greetings", RetentionPeriod: time.Hour}
+
+	bucketBackend := NewMockBucketBackend(t)
+	bucketBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	bucketBackend.BucketService = &mock.BucketService{
+		FindBucketByIDFn: func(ctx context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+			b := stored
+			return &b, nil
+		},
+		UpdateBucketFn: func(ctx context.Context, id influxdb.ID, upd influxdb.BucketUpdate) (*influxdb.Bucket, error) {
+			b := stored
+			if upd.Name != nil {
+				b.Name = *upd.Name
+			}
+			if upd.Description != nil {
+				b.Description = *upd.Description
+			}
+			if upd.RetentionPeriod != nil {
+				b.RetentionPeriod = *upd.RetentionPeriod
+			}
+			return &b, nil
+		},
+	}
+	handler := NewBucketHandler(zaptest.NewLogger(t), bucketBackend)
+
+	tests := []struct {
+		name       string
+		patch      string
+		wantStatus int
+		want       influxdb.Bucket
+	}{
+		{
+			name:       "absent fields are unchanged",
+			patch:      `{"name": "example"}`,
+			wantStatus: http.StatusOK,
+			want:       influxdb.Bucket{Name: "example", Description: "greetings", RetentionPeriod: time.Hour},
+		},
+		{
+			name:       "null description clears it",
+			patch:      `{"description": null}`,
+			wantStatus: http.StatusOK,
+			want:       influxdb.Bucket{Name: "hello", RetentionPeriod: time.Hour},
+		},
+		{
+			name:       "null retention rules make the retention infinite",
+			patch:      `{"retentionRules": null}`,
+			wantStatus: http.StatusOK,
+			want:       influxdb.Bucket{Name: "hello", Description: "greetings"},
+		},
+		{
+			name:       "null name is invalid",
+			patch:      `{"name": null}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/api/v2/buckets/0000000000000001", strings.NewReader(tt.patch))
+			r.Header.Set("Content-Type", mergePatchContentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("unexpected status: got %d want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			br := bucketResponse{}
+			if err := json.NewDecoder(w.Body).Decode(&br); err != nil {
+				t.Fatal(err)
+			}
+			got, err := br.toInfluxDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != tt.want.Name || got.Description != tt.want.Description || got.RetentionPeriod != tt.want.RetentionPeriod {
+				t.Errorf("unexpected bucket: got %+v want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("plain json patch without retention rules makes the retention infinite", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPatch, "/api/v2/buckets/0000000000000001", strings.NewReader(`{"name": "example"}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: got %d: %s", w.Code, w.Body.String())
+		}
+		br := bucketResponse{}
+		if err := json.NewDecoder(w.Body).Decode(&br); err != nil {
+			t.Fatal(err)
+		}
+		got, err := br.toInfluxDB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "example" || got.RetentionPeriod != 0 {
+			t.Errorf("unexpected bucket: got %+v want name example and infinite retention", got)
+		}
+	})
+
+	t.Run("null description of a plain json patch is unchanged", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPatch, "/api/v2/buckets/0000000000000001", strings.NewReader(`{"description": null}`))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: got %d: %s", w.Code, w.Body.String())
+		}
+		br := bucketResponse{}
+		if err := json.NewDecoder(w.Body).Decode(&br); err != nil {
+			t.Fatal(err)
+		}
+		if br.Description != stored.Description {
+			t.Errorf("expected the description to be unchanged: got %q", br.Description)
+		}
+	})
+}
@@ -2,32 +2,59 @@ package http
 
 import (
 	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/dbrp"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
 )
 
+// DefaultUserAgent is the user agent set on requests made by clients from
+// NewHTTPClient unless overridden with httpc.WithUserAgent.
+func DefaultUserAgent() string {
+	version := influxdb.GetBuildInfo().Version
+	if version == "" {
+		version = "dev"
+	}
+	return "influxdb-go-client/" + version
+}
+
 // NewHTTPClient creates a new httpc.Client type. This call sets all
 // the options that are important to the http pkg on the httpc client.
 // The default status fn and so forth will all be set for the caller.
 // In addition, some options can be specified. Those will be added to the defaults.
+// The client gets a connection pool of its own, with the settings of
+// DefaultTransport, whose idle connections are closed by its Close.
 func NewHTTPClient(addr, token string, insecureSkipVerify bool, opts ...httpc.ClientOptFn) (*httpc.Client, error) {
-	u, err := url.Parse(addr)
+	return NewHTTPClientWithAddrs([]string{addr}, token, insecureSkipVerify, opts...)
+}
+
+// NewHTTPClientWithAddrs creates a new httpc.Client like NewHTTPClient, for
+// several hosts such as the replicas of a replica set. Reads are spread
+// round-robin across the hosts, skipping hosts that recently failed to
+// connect, and other requests are sent to the first host. See
+// httpc.WithAddrs.
+func NewHTTPClientWithAddrs(addrs []string, token string, insecureSkipVerify bool, opts ...httpc.ClientOptFn) (*httpc.Client, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("must provide a non empty host address")
+	}
+	u, err := url.Parse(addrs[0])
 	if err != nil {
 		return nil, err
 	}
 
 	defaultOpts := []httpc.ClientOptFn{
-		httpc.WithAddr(addr),
+		httpc.WithAddrs(addrs...),
 		httpc.WithContentType("application/json"),
-		httpc.WithHTTPClient(NewClient(u.Scheme, insecureSkipVerify)),
+		httpc.WithOwnedHTTPClient(newOwnedClient(u.Scheme, insecureSkipVerify)),
 		httpc.WithInsecureSkipVerify(insecureSkipVerify),
 		httpc.WithStatusFn(CheckError),
+		httpc.WithUserAgent(DefaultUserAgent()),
 	}
 	if token != "" {
 		defaultOpts = append(defaultOpts, httpc.WithAuthToken(token))
@@ -36,6 +63,14 @@ func NewHTTPClient(addr, token string, insecureSkipVerify bool, opts ...httpc.Cl
 	return httpc.New(opts...)
 }
 
+// WithRoundTripper sends the requests of a client from NewHTTPClient through
+// rt rather than the default transport, for instance to record and replay
+// requests in tests or to send them through a proxy. The requests are still
+// traced, and rt is responsible for TLS verification.
+func WithRoundTripper(rt http.RoundTripper) httpc.ClientOptFn {
+	return httpc.WithHTTPClient(&http.Client{Transport: &SpanTransport{base: rt}})
+}
+
 // Service connects to an InfluxDB via HTTP.
 type Service struct {
 	Addr               string
@@ -45,6 +80,7 @@ type Service struct {
 	*AuthorizationService
 	*BackupService
 	*BucketService
+	*BucketSchemaService
 	*TaskService
 	*DashboardService
 	*OrganizationService
@@ -52,6 +88,7 @@ type Service struct {
 	*UserService
 	*VariableService
 	*WriteService
+	*DeleteService
 	DocumentService
 	*CheckService
 	*NotificationEndpointService
@@ -60,6 +97,10 @@ type Service struct {
 	*LabelService
 	*SecretService
 	DBRPMappingServiceV2 *dbrp.Client
+
+	client *httpc.Client
+	lazy   lazyServices
+	names  *nameCache
 }
 
 // NewService returns a service that is an HTTP client to a remote.
@@ -74,35 +115,17 @@ type Service struct {
 //
 // So one should provide the same `addr` and `token` to both calls to ensure consistency
 // in the behavior of the returned service.
-func NewService(httpClient *httpc.Client, addr, token string) (*Service, error) {
-	return &Service{
-		Addr:                 addr,
-		Token:                token,
-		AuthorizationService: &AuthorizationService{Client: httpClient},
-		BackupService: &BackupService{
-			Addr:  addr,
-			Token: token,
-		},
-		BucketService:           &BucketService{Client: httpClient},
-		TaskService:             &TaskService{Client: httpClient},
-		DashboardService:        &DashboardService{Client: httpClient},
-		OrganizationService:     &OrganizationService{Client: httpClient},
-		NotificationRuleService: &NotificationRuleService{Client: httpClient},
-		UserService:             &UserService{Client: httpClient},
-		VariableService:         &VariableService{Client: httpClient},
-		WriteService: &WriteService{
-			Addr:  addr,
-			Token: token,
-		},
-		DocumentService:             NewDocumentService(httpClient),
-		CheckService:                &CheckService{Client: httpClient},
-		NotificationEndpointService: &NotificationEndpointService{Client: httpClient},
-		UserResourceMappingService:  &UserResourceMappingService{Client: httpClient},
-		TelegrafService:             NewTelegrafService(httpClient),
-		LabelService:                &LabelService{Client: httpClient},
-		SecretService:               &SecretService{Client: httpClient},
-		DBRPMappingServiceV2:        dbrp.NewClient(httpClient),
-	}, nil
+func NewService(httpClient *httpc.Client, addr, token string, opts ...ServiceOption) (*Service, error) {
+	s := &Service{
+		Addr:   addr,
+		Token:  token,
+		client: httpClient,
+	}
+	s.buildAll()
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 // NewURL concats addr and path.
@@ -115,9 +138,55 @@ func NewURL(addr, path string) (*url.URL, error) {
 	return u, nil
 }
 
+const (
+	// DefaultMaxIdleConnsPerHost is the number of idle connections to a host
+	// kept by clients from NewClient. It matches the total number of idle
+	// connections, so that deployments with a single host reuse connections.
+	DefaultMaxIdleConnsPerHost = 100
+	// DefaultMaxConnsPerHost is the number of connections to a host allowed
+	// for clients from NewClient. Zero means no limit.
+	DefaultMaxConnsPerHost = 0
+)
+
+// ClientOption configures the transport of a client from NewClient.
+type ClientOption func(*transportConfig)
+
+// WithMaxIdleConnsPerHost sets the number of idle connections kept per host.
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(c *transportConfig) {
+		c.maxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost limits the number of connections per host, including
+// those in use. Zero means no limit.
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(c *transportConfig) {
+		c.maxConnsPerHost = n
+	}
+}
+
+// WithHTTP2Disabled restricts the client to HTTP/1.1, for proxies that break
+// HTTP/2 streams.
+func WithHTTP2Disabled() ClientOption {
+	return func(c *transportConfig) {
+		c.disableHTTP2 = true
+	}
+}
+
 // NewClient returns an http.Client that pools connections and injects a span.
-func NewClient(scheme string, insecure bool) *http.Client {
-	return httpClient(scheme, insecure)
+// Clients without options share the pool of DefaultTransport or
+// DefaultTransportInsecure, clients with options get a pool of their own.
+func NewClient(scheme string, insecure bool, opts ...ClientOption) *http.Client {
+	if len(opts) == 0 {
+		return httpClient(scheme, insecure)
+	}
+
+	c := newTransportConfig(scheme == "https" && insecure)
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &http.Client{Transport: &SpanTransport{base: newTransport(c)}}
 }
 
 // SpanTransport injects the http.RoundTripper.RoundTrip() request
@@ -135,15 +204,52 @@ func (s *SpanTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return s.base.RoundTrip(r)
 }
 
-// DefaultTransport wraps http.DefaultTransport in SpanTransport to inject
-// tracing headers into all outgoing requests.
-var DefaultTransport http.RoundTripper = &SpanTransport{base: http.DefaultTransport}
+// CloseIdleConnections closes the idle connections of the base transport, for
+// the clients owning s.
+func (s *SpanTransport) CloseIdleConnections() {
+	if ci, ok := s.base.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// WithConnectTimeout returns a copy of s whose base transport dials with the
+// timeout d, for httpc.WithConnectTimeout.
+func (s *SpanTransport) WithConnectTimeout(d time.Duration) (http.RoundTripper, error) {
+	base, err := httpc.ConnectTimeout(s.base, d)
+	if err != nil {
+		return nil, err
+	}
+	return &SpanTransport{base: base}, nil
+}
+
+// DefaultTransport is a transport with the settings of http.DefaultTransport
+// and DefaultMaxIdleConnsPerHost, wrapped in SpanTransport to inject tracing
+// headers into all outgoing requests.
+var DefaultTransport http.RoundTripper = &SpanTransport{base: newTransport(newTransportConfig(false))}
 
 // DefaultTransportInsecure is identical to DefaultTransport, with
 // the exception that tls.Config is configured with InsecureSkipVerify
 // set to true.
-var DefaultTransportInsecure http.RoundTripper = &SpanTransport{
-	base: &http.Transport{
+var DefaultTransportInsecure http.RoundTripper = &SpanTransport{base: newTransport(newTransportConfig(true))}
+
+// transportConfig holds the settings of a transport from newTransport.
+type transportConfig struct {
+	insecure            bool
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	disableHTTP2        bool
+}
+
+func newTransportConfig(insecure bool) transportConfig {
+	return transportConfig{
+		insecure:            insecure,
+		maxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		maxConnsPerHost:     DefaultMaxConnsPerHost,
+	}
+}
+
+func newTransport(c transportConfig) *http.Transport {
+	t := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
@@ -152,13 +258,30 @@ var DefaultTransportInsecure http.RoundTripper = &SpanTransport{
 		}).DialContext,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   c.maxIdleConnsPerHost,
+		MaxConnsPerHost:       c.maxConnsPerHost,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig: &tls.Config{
+	}
+	if c.disableHTTP2 {
+		// a non-nil empty map keeps the transport from negotiating h2
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if c.insecure {
+		t.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: true,
-		},
-	},
+		}
+	}
+	return t
+}
+
+// newOwnedClient returns an http.Client like httpClient, whose transport is a
+// copy of DefaultTransport or DefaultTransportInsecure rather than shared.
+func newOwnedClient(scheme string, insecure bool) *http.Client {
+	c := newTransportConfig(scheme == "https" && insecure)
+	return &http.Client{Transport: &SpanTransport{base: newTransport(c)}}
 }
 
 func httpClient(scheme string, insecure bool) *http.Client {
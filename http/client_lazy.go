@@ -0,0 +1,274 @@
+package http
+
+import (
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+// NewLazyService returns a Service like NewService whose services are only
+// built when first returned by their accessor, such as Org or Bucket, for
+// tools that use few of them. The embedded services of the Service are nil
+// until then. The accessors are safe for concurrent use, reading the
+// embedded services directly while they are being built is not.
+func NewLazyService(httpClient *httpc.Client, addr, token string, opts ...ServiceOption) (*Service, error) {
+	s := &Service{
+		Addr:   addr,
+		Token:  token,
+		client: httpClient,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// lazyServices guards the building of each service of a Service by its
+// accessor.
+type lazyServices struct {
+	authorization        sync.Once
+	backup               sync.Once
+	bucket               sync.Once
+	bucketSchema         sync.Once
+	task                 sync.Once
+	dashboard            sync.Once
+	org                  sync.Once
+	notificationRule     sync.Once
+	user                 sync.Once
+	variable             sync.Once
+	writer               sync.Once
+	deleter              sync.Once
+	document             sync.Once
+	check                sync.Once
+	notificationEndpoint sync.Once
+	userResourceMapping  sync.Once
+	telegraf             sync.Once
+	label                sync.Once
+	secret               sync.Once
+	dbrpMapping          sync.Once
+}
+
+// buildAll builds every service of s.
+func (s *Service) buildAll() {
+	s.Authorization()
+	s.Backup()
+	s.Bucket()
+	s.BucketSchema()
+	s.Task()
+	s.Dashboard()
+	s.Org()
+	s.NotificationRule()
+	s.User()
+	s.Variable()
+	s.Writer()
+	s.Deleter()
+	s.Document()
+	s.Check()
+	s.NotificationEndpoint()
+	s.UserResourceMapping()
+	s.Telegraf()
+	s.Label()
+	s.Secret()
+	s.DBRPMapping()
+}
+
+// Authorization returns the authorization service of s, building it on first use.
+func (s *Service) Authorization() *AuthorizationService {
+	s.lazy.authorization.Do(func() {
+		if s.AuthorizationService == nil {
+			s.AuthorizationService = &AuthorizationService{Client: s.client}
+		}
+	})
+	return s.AuthorizationService
+}
+
+// Backup returns the backup service of s, building it on first use.
+func (s *Service) Backup() *BackupService {
+	s.lazy.backup.Do(func() {
+		if s.BackupService == nil {
+			s.BackupService = &BackupService{Addr: s.Addr, Token: s.Token}
+		}
+	})
+	return s.BackupService
+}
+
+// Bucket returns the bucket service of s, building it on first use.
+func (s *Service) Bucket() *BucketService {
+	s.lazy.bucket.Do(func() {
+		if s.BucketService == nil {
+			s.BucketService = &BucketService{Client: s.client, names: s.names}
+		}
+	})
+	return s.BucketService
+}
+
+// BucketSchema returns the bucket schema service of s, building it on first use.
+func (s *Service) BucketSchema() *BucketSchemaService {
+	s.lazy.bucketSchema.Do(func() {
+		if s.BucketSchemaService == nil {
+			s.BucketSchemaService = &BucketSchemaService{Client: s.client}
+		}
+	})
+	return s.BucketSchemaService
+}
+
+// Task returns the task service of s, building it on first use.
+func (s *Service) Task() *TaskService {
+	s.lazy.task.Do(func() {
+		if s.TaskService == nil {
+			s.TaskService = &TaskService{Client: s.client}
+		}
+	})
+	return s.TaskService
+}
+
+// Dashboard returns the dashboard service of s, building it on first use.
+func (s *Service) Dashboard() *DashboardService {
+	s.lazy.dashboard.Do(func() {
+		if s.DashboardService == nil {
+			s.DashboardService = &DashboardService{Client: s.client}
+		}
+	})
+	return s.DashboardService
+}
+
+// Org returns the organization service of s, building it on first use.
+func (s *Service) Org() *OrganizationService {
+	s.lazy.org.Do(func() {
+		if s.OrganizationService == nil {
+			s.OrganizationService = &OrganizationService{Client: s.client, names: s.names}
+		}
+	})
+	return s.OrganizationService
+}
+
+// NotificationRule returns the notification rule service of s, building it on first use.
+func (s *Service) NotificationRule() *NotificationRuleService {
+	s.lazy.notificationRule.Do(func() {
+		if s.NotificationRuleService == nil {
+			s.NotificationRuleService = &NotificationRuleService{Client: s.client}
+		}
+	})
+	return s.NotificationRuleService
+}
+
+// User returns the user service of s, building it on first use.
+func (s *Service) User() *UserService {
+	s.lazy.user.Do(func() {
+		if s.UserService == nil {
+			s.UserService = &UserService{Client: s.client}
+		}
+	})
+	return s.UserService
+}
+
+// Variable returns the variable service of s, building it on first use.
+func (s *Service) Variable() *VariableService {
+	s.lazy.variable.Do(func() {
+		if s.VariableService == nil {
+			s.VariableService = &VariableService{Client: s.client}
+		}
+	})
+	return s.VariableService
+}
+
+// Writer returns the write service of s, building it on first use.
+func (s *Service) Writer() *WriteService {
+	s.lazy.writer.Do(func() {
+		if s.WriteService == nil {
+			s.WriteService = &WriteService{Addr: s.Addr, Token: s.Token}
+		}
+	})
+	return s.WriteService
+}
+
+// Deleter returns the delete service of s, building it on first use.
+func (s *Service) Deleter() *DeleteService {
+	s.lazy.deleter.Do(func() {
+		if s.DeleteService == nil {
+			s.DeleteService = &DeleteService{Addr: s.Addr, Token: s.Token, Client: s.client}
+		}
+	})
+	return s.DeleteService
+}
+
+// Document returns the document service of s, building it on first use.
+func (s *Service) Document() DocumentService {
+	s.lazy.document.Do(func() {
+		if s.DocumentService == nil {
+			s.DocumentService = NewDocumentService(s.client)
+		}
+	})
+	return s.DocumentService
+}
+
+// Check returns the check service of s, building it on first use.
+func (s *Service) Check() *CheckService {
+	s.lazy.check.Do(func() {
+		if s.CheckService == nil {
+			s.CheckService = &CheckService{Client: s.client}
+		}
+	})
+	return s.CheckService
+}
+
+// NotificationEndpoint returns the notification endpoint service of s, building it on first use.
+func (s *Service) NotificationEndpoint() *NotificationEndpointService {
+	s.lazy.notificationEndpoint.Do(func() {
+		if s.NotificationEndpointService == nil {
+			s.NotificationEndpointService = &NotificationEndpointService{Client: s.client}
+		}
+	})
+	return s.NotificationEndpointService
+}
+
+// UserResourceMapping returns the user resource mapping service of s, building it on first use.
+func (s *Service) UserResourceMapping() *UserResourceMappingService {
+	s.lazy.userResourceMapping.Do(func() {
+		if s.UserResourceMappingService == nil {
+			s.UserResourceMappingService = &UserResourceMappingService{Client: s.client}
+		}
+	})
+	return s.UserResourceMappingService
+}
+
+// Telegraf returns the telegraf service of s, building it on first use.
+func (s *Service) Telegraf() *TelegrafService {
+	s.lazy.telegraf.Do(func() {
+		if s.TelegrafService == nil {
+			s.TelegrafService = NewTelegrafService(s.client)
+		}
+	})
+	return s.TelegrafService
+}
+
+// Label returns the label service of s, building it on first use.
+func (s *Service) Label() *LabelService {
+	s.lazy.label.Do(func() {
+		if s.LabelService == nil {
+			s.LabelService = &LabelService{Client: s.client}
+		}
+	})
+	return s.LabelService
+}
+
+// Secret returns the secret service of s, building it on first use.
+func (s *Service) Secret() *SecretService {
+	s.lazy.secret.Do(func() {
+		if s.SecretService == nil {
+			s.SecretService = &SecretService{Client: s.client}
+		}
+	})
+	return s.SecretService
+}
+
+// DBRPMapping returns the dbrp mapping service of s, building it on first use.
+func (s *Service) DBRPMapping() *dbrp.Client {
+	s.lazy.dbrpMapping.Do(func() {
+		if s.DBRPMappingServiceV2 == nil {
+			s.DBRPMappingServiceV2 = dbrp.NewClient(s.client)
+		}
+	})
+	return s.DBRPMappingServiceV2
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+const (
+	opSelfTest = "http/SelfTest"
+
+	// SelfTestMeasurement is the measurement of the canary points of
+	// SelfTest, tagged with a selfTestTag unique to each test.
+	SelfTestMeasurement = "influxdb_selftest"
+	selfTestTag         = "canary"
+)
+
+// SelfTestResult reports the steps of a SelfTest that succeeded.
+type SelfTestResult struct {
+	OrgID    influxdb.ID
+	BucketID influxdb.ID
+	// Point is the canary point, as line protocol.
+	Point string
+	// Written reports whether the canary point was written.
+	Written bool
+	// Found reports whether the canary point was read back from the bucket.
+	Found bool
+	// Deleted reports whether the canary point was deleted afterwards.
+	Deleted bool
+	// Took is how long the test took.
+	Took time.Duration
+}
+
+// SelfTest is a canary of the ingest pipeline. It writes a point of the
+// SelfTestMeasurement with a tag unique to the test through the write
+// service of s, reads it back from the bucket through qs, and deletes it
+// through the delete service of s, so that writes going to another bucket
+// than the one they were meant for, or not being stored at all, are noticed.
+// A step failing fails the test with the error of that step, reported along
+// with the steps that succeeded. The canary is deleted whether it was found
+// or not, once written.
+func (s *Service) SelfTest(ctx context.Context, orgID, bucketID influxdb.ID, qs query.QueryService) (res SelfTestResult, err error) {
+	start := time.Now()
+	res = SelfTestResult{OrgID: orgID, BucketID: bucketID}
+	defer func() { res.Took = time.Since(start) }()
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return res, &influxdb.Error{Code: influxdb.EInternal, Op: opSelfTest, Msg: "unable to generate canary", Err: err}
+	}
+	canary := hex.EncodeToString(nonce)
+	ts := start.UTC()
+	res.Point = fmt.Sprintf("%s,%s=%s value=1i %d", SelfTestMeasurement, selfTestTag, canary, ts.UnixNano())
+
+	w := *s.Writer()
+	w.Precision = "ns"
+	if err := w.Write(ctx, orgID, bucketID, strings.NewReader(res.Point)); err != nil {
+		return res, selfTestError("write", err)
+	}
+	res.Written = true
+
+	found, queryErr := selfTestFind(ctx, qs, orgID, bucketID, canary, ts)
+	res.Found = found
+
+	predicate := fmt.Sprintf(`_measurement="%s" AND %s="%s"`, SelfTestMeasurement, selfTestTag, canary)
+	deleteErr := s.Deleter().Delete(ctx, orgID, bucketID, ts, ts.Add(time.Nanosecond), predicate)
+	res.Deleted = deleteErr == nil
+
+	switch {
+	case queryErr != nil:
+		return res, selfTestError("read", queryErr)
+	case !found:
+		return res, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Op:   opSelfTest,
+			Msg:  fmt.Sprintf("canary point %s was written but not found in bucket %s", canary, bucketID),
+		}
+	case deleteErr != nil:
+		return res, selfTestError("delete", deleteErr)
+	}
+	return res, nil
+}
+
+// selfTestFind returns whether the canary point written at ts is in the
+// bucket.
+func selfTestFind(ctx context.Context, qs query.QueryService, orgID, bucketID influxdb.ID, canary string, ts time.Time) (bool, error) {
+	itr, err := qs.Query(ctx, &query.Request{
+		OrganizationID: orgID,
+		Compiler: lang.FluxCompiler{
+			Query: fmt.Sprintf(`from(bucketID: %q) |> range(start: %s, stop: %s) |> filter(fn: (r) => r._measurement == %q and r.%s == %q)`,
+				bucketID, ts.Format(time.RFC3339Nano), ts.Add(time.Nanosecond).Format(time.RFC3339Nano), SelfTestMeasurement, selfTestTag, canary),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer itr.Release()
+
+	var rows int
+	for itr.More() {
+		if err := itr.Next().Tables().Do(func(tbl flux.Table) error {
+			return tbl.Do(func(cr flux.ColReader) error {
+				rows += cr.Len()
+				return nil
+			})
+		}); err != nil {
+			return false, err
+		}
+	}
+	if err := itr.Err(); err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func selfTestError(step string, err error) error {
+	return &influxdb.Error{
+		Code: influxdb.ErrorCode(err),
+		Op:   opSelfTest,
+		Msg:  fmt.Sprintf("canary %s failed", step),
+		Err:  err,
+	}
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/flux/execute/executetest"
+	"github.com/influxdata/flux/lang"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/query"
+	querymock "github.com/influxdata/influxdb/v2/query/mock"
+)
+
+func TestService_SelfTest(t *testing.T) {
+	// canaryServer stores the lines written to a bucket by its id, or to
+	// misroute when it is set.
+	type canaryServer struct {
+		mu       sync.Mutex
+		lines    map[string][]string
+		deletes  []DeleteRequest
+		misroute string
+	}
+	newServer := func(t *testing.T, cs *canaryServer) *httptest.Server {
+		mux := http.NewServeMux()
+		mux.HandleFunc(prefixWrite, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			cs.mu.Lock()
+			defer cs.mu.Unlock()
+			bucket := r.URL.Query().Get("bucket")
+			if cs.misroute != "" {
+				bucket = cs.misroute
+			}
+			cs.lines[bucket] = append(cs.lines[bucket], string(body))
+			w.WriteHeader(http.StatusNoContent)
+		})
+		mux.HandleFunc(prefixDelete, func(w http.ResponseWriter, r *http.Request) {
+			var dr DeleteRequest
+			if err := json.NewDecoder(r.Body).Decode(&dr); err != nil {
+				t.Error(err)
+			}
+			cs.mu.Lock()
+			cs.deletes = append(cs.deletes, dr)
+			cs.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		})
+		return httptest.NewServer(mux)
+	}
+	// queryService finds the lines of cs holding the canary of the query in
+	// the bucket it queries.
+	queryService := func(cs *canaryServer) query.QueryService {
+		return &querymock.QueryService{
+			QueryF: func(ctx context.Context, req *query.Request) (flux.ResultIterator, error) {
+				q := req.Compiler.(lang.FluxCompiler).Query
+				tbl := &executetest.Table{
+					ColMeta: []flux.ColMeta{{Label: "_value", Type: flux.TInt}},
+				}
+				cs.mu.Lock()
+				for bucket, lines := range cs.lines {
+					for _, line := range lines {
+						canary := strings.Split(strings.Split(line, selfTestTag+"=")[1], " ")[0]
+						if strings.Contains(q, `from(bucketID: "`+bucket+`")`) && strings.Contains(q, `"`+canary+`"`) {
+							tbl.Data = append(tbl.Data, []interface{}{int64(1)})
+						}
+					}
+				}
+				cs.mu.Unlock()
+				return flux.NewSliceResultIterator([]flux.Result{executetest.NewResult([]*executetest.Table{tbl})}), nil
+			},
+		}
+	}
+
+	t.Run("canary found", func(t *testing.T) {
+		cs := &canaryServer{lines: make(map[string][]string)}
+		server := newServer(t, cs)
+		defer server.Close()
+		svc, err := NewService(mustNewHTTPClient(t, server.URL, ""), server.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.Writer().WithCompression(0)
+
+		res, err := svc.SelfTest(context.Background(), 1, 2, queryService(cs))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !res.Written || !res.Found || !res.Deleted || res.Took <= 0 {
+			t.Errorf("unexpected result: %+v", res)
+		}
+		if res.OrgID != 1 || res.BucketID != 2 || !strings.HasPrefix(res.Point, SelfTestMeasurement+",") {
+			t.Errorf("unexpected result: %+v", res)
+		}
+		if len(cs.deletes) != 1 || !strings.Contains(cs.deletes[0].Predicate, `_measurement="`+SelfTestMeasurement+`"`) {
+			t.Errorf("expected the canary to be deleted: %+v", cs.deletes)
+		}
+	})
+
+	t.Run("canary misrouted", func(t *testing.T) {
+		cs := &canaryServer{lines: make(map[string][]string), misroute: influxdb.ID(3).String()}
+		server := newServer(t, cs)
+		defer server.Close()
+		svc, err := NewService(mustNewHTTPClient(t, server.URL, ""), server.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.Writer().WithCompression(0)
+
+		res, err := svc.SelfTest(context.Background(), 1, 2, queryService(cs))
+		if code := influxdb.ErrorCode(err); code != influxdb.ENotFound {
+			t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.ENotFound, err)
+		}
+		if !res.Written || res.Found || !res.Deleted {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	})
+
+	t.Run("query failure", func(t *testing.T) {
+		cs := &canaryServer{lines: make(map[string][]string)}
+		server := newServer(t, cs)
+		defer server.Close()
+		svc, err := NewService(mustNewHTTPClient(t, server.URL, ""), server.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.Writer().WithCompression(0)
+
+		qs := &querymock.QueryService{
+			QueryF: func(ctx context.Context, req *query.Request) (flux.ResultIterator, error) {
+				return nil, &influxdb.Error{Code: influxdb.EUnavailable, Msg: "query service down"}
+			},
+		}
+		res, err := svc.SelfTest(context.Background(), 1, 2, qs)
+		if code := influxdb.ErrorCode(err); code != influxdb.EUnavailable {
+			t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.EUnavailable, err)
+		}
+		if !res.Written || res.Found || !res.Deleted {
+			t.Errorf("expected the canary to be deleted after the failed read: %+v", res)
+		}
+	})
+}
@@ -0,0 +1,459 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+func TestNewHTTPClient_UserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []httpc.ClientOptFn
+		want string
+	}{
+		{
+			name: "defaults to the client user agent",
+			want: DefaultUserAgent(),
+		},
+		{
+			name: "user agent can be overridden",
+			opts: []httpc.ClientOptFn{httpc.WithUserAgent("my-tool/1.0")},
+			want: "my-tool/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			client, err := NewHTTPClient(ts.URL, "", false, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := client.Get("/").Do(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected user agent: got %q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClient_DefaultHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client, err := NewHTTPClient(ts.URL, "mytoken", false, httpc.WithDefaultHeaders(http.Header{
+		"X-Tenant":      []string{"acme"},
+		"X-Api-Key":     []string{"k1", "k2"},
+		"Authorization": []string{"Basic Zm9vOmJhcg=="},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get("/").Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := got.Get("X-Tenant"); v != "acme" {
+		t.Errorf("unexpected X-Tenant header: %q", v)
+	}
+	if v := got.Values("X-Api-Key"); len(v) != 2 || v[0] != "k1" || v[1] != "k2" {
+		t.Errorf("unexpected X-Api-Key header: %q", v)
+	}
+	if v := got.Values("Authorization"); len(v) != 1 || v[0] != "Token mytoken" {
+		t.Errorf("expected the token to take precedence, got Authorization %q", v)
+	}
+}
+
+func TestNewHTTPClientWithAddrs(t *testing.T) {
+	var hits []string
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name+" "+r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	primary, replica := newServer("primary"), newServer("replica")
+	defer primary.Close()
+	defer replica.Close()
+
+	client, err := NewHTTPClientWithAddrs([]string{primary.URL, replica.URL}, "mytoken", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.Get("/").Do(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := strings.Join(hits, ","), "primary Token mytoken,replica Token mytoken"; got != want {
+		t.Errorf("unexpected requests: got %s want %s", got, want)
+	}
+
+	if _, err := NewHTTPClientWithAddrs(nil, "", false); err == nil {
+		t.Error("expected an error without addresses")
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper calling the func.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+func TestNewHTTPClient_roundTripper(t *testing.T) {
+	var requests []string
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, r.Method+" "+r.URL.String()+" "+r.Header.Get("Authorization"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"orgs":[{"id":"020f755c3c082000","name":"org"}]}`)),
+			Request:    r,
+		}, nil
+	})
+
+	// no server listens on the address, every request goes to rt.
+	client, err := NewHTTPClient("http://influxdb.invalid:8086", "mytoken", false, WithRoundTripper(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgs := &OrganizationService{Client: client}
+	got, _, err := orgs.FindOrganizations(context.Background(), influxdb.OrganizationFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "org" {
+		t.Errorf("unexpected orgs: %v", got)
+	}
+	if got, want := strings.Join(requests, ","), "GET http://influxdb.invalid:8086/api/v2/orgs Token mytoken"; got != want {
+		t.Errorf("unexpected requests: got %s want %s", got, want)
+	}
+}
+
+func TestNewHTTPClient_requestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client, err := NewHTTPClient(ts.URL, "", false, httpc.WithRequestTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get("/").Do(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: got %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNewHTTPClient_connectTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client, err := NewHTTPClient(ts.URL, "", false, httpc.WithConnectTimeout(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get("/").Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the dials of the client are bounded by a traced transport of its own.
+	st, ok := client.Config().Transport.(*SpanTransport)
+	if !ok || st == DefaultTransport {
+		t.Fatalf("unexpected transport: %#v", client.Config().Transport)
+	}
+	if _, ok := st.base.(*http.Transport); !ok {
+		t.Errorf("unexpected base transport: %#v", st.base)
+	}
+}
+
+func TestNewHTTPClient_Close(t *testing.T) {
+	closed := make(chan struct{}, 10)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed <- struct{}{}
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	client, err := NewHTTPClient(ts.URL, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := client.Config().Transport.(*SpanTransport); !ok || st == DefaultTransport {
+		t.Fatalf("unexpected transport: %#v", client.Config().Transport)
+	}
+	if err := client.Get("/").Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the idle connections of the client to be closed")
+	}
+}
+
+func TestNewClient_transport(t *testing.T) {
+	tests := []struct {
+		name         string
+		scheme       string
+		insecure     bool
+		opts         []ClientOption
+		idlePerHost  int
+		connsPerHost int
+	}{
+		{
+			name:        "defaults",
+			scheme:      "http",
+			idlePerHost: DefaultMaxIdleConnsPerHost,
+		},
+		{
+			name:        "insecure defaults",
+			scheme:      "https",
+			insecure:    true,
+			idlePerHost: DefaultMaxIdleConnsPerHost,
+		},
+		{
+			name:         "pool tuning",
+			scheme:       "https",
+			insecure:     true,
+			opts:         []ClientOption{WithMaxIdleConnsPerHost(10), WithMaxConnsPerHost(20)},
+			idlePerHost:  10,
+			connsPerHost: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(tt.scheme, tt.insecure, tt.opts...)
+			tr := c.Transport.(*SpanTransport).base.(*http.Transport)
+			if tr.MaxIdleConnsPerHost != tt.idlePerHost {
+				t.Errorf("unexpected MaxIdleConnsPerHost: got %d want %d", tr.MaxIdleConnsPerHost, tt.idlePerHost)
+			}
+			if tr.MaxConnsPerHost != tt.connsPerHost {
+				t.Errorf("unexpected MaxConnsPerHost: got %d want %d", tr.MaxConnsPerHost, tt.connsPerHost)
+			}
+			if insecure := tr.TLSClientConfig != nil && tr.TLSClientConfig.InsecureSkipVerify; insecure != tt.insecure {
+				t.Errorf("unexpected InsecureSkipVerify: got %v want %v", insecure, tt.insecure)
+			}
+		})
+	}
+}
+
+func TestNewClient_http2(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{name: "http2 by default", want: "HTTP/2.0"},
+		{name: "http2 disabled", opts: []ClientOption{WithHTTP2Disabled()}, want: "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("https", true, tt.opts...)
+			resp, err := c.Get(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(body); got != tt.want {
+				t.Errorf("unexpected protocol: got %s want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServices_contextCanceled(t *testing.T) {
+	// the server only notices a client going away once it has read the
+	// request body, so handlers are also released when the test ends.
+	stop := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stop:
+		}
+	}))
+	defer ts.Close()
+	defer close(stop)
+
+	client, err := NewHTTPClient(ts.URL, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{
+			name: "buckets",
+			call: func(ctx context.Context) error {
+				_, _, err := (&BucketService{Client: client}).FindBuckets(ctx, influxdb.BucketFilter{})
+				return err
+			},
+		},
+		{
+			name: "organizations",
+			call: func(ctx context.Context) error {
+				_, err := (&OrganizationService{Client: client}).FindOrganizationByID(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "users",
+			call: func(ctx context.Context) error {
+				_, err := (&UserService{Client: client}).FindUser(ctx, influxdb.UserFilter{ID: new(influxdb.ID)})
+				return err
+			},
+		},
+		{
+			name: "user resource mappings",
+			call: func(ctx context.Context) error {
+				_, _, err := (&UserResourceMappingService{Client: client}).FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+					ResourceType: influxdb.OrgsResourceType,
+					ResourceID:   1,
+					UserType:     influxdb.Member,
+				})
+				return err
+			},
+		},
+		{
+			name: "write",
+			call: func(ctx context.Context) error {
+				return (&WriteService{Addr: ts.URL}).Write(ctx, 1, 2, strings.NewReader("m f=1"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("call did not return after the context was canceled")
+			}
+		})
+	}
+}
+
+func TestNewLazyService(t *testing.T) {
+	const orgID = "043e0780ee2b1000"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != prefixOrganizations {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"orgs":[{"id":"` + orgID + `","name":"org"}]}`))
+	}))
+	defer ts.Close()
+
+	client := mustNewHTTPClient(t, ts.URL, "")
+	svc, err := NewLazyService(client, ts.URL, "token", WithNameCache(10, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.OrganizationService != nil || svc.BucketService != nil || svc.WriteService != nil {
+		t.Fatal("expected the services to be built on first use")
+	}
+
+	orgs := make(chan *OrganizationService, 10)
+	for i := 0; i < cap(orgs); i++ {
+		go func() { orgs <- svc.Org() }()
+	}
+	org := <-orgs
+	for i := 1; i < cap(orgs); i++ {
+		if o := <-orgs; o != org {
+			t.Fatal("expected every call to return the same service")
+		}
+	}
+	if svc.OrganizationService != org || org.names == nil {
+		t.Errorf("unexpected organization service: %+v", svc.OrganizationService)
+	}
+	if svc.BucketService != nil {
+		t.Error("expected the bucket service to be left unbuilt")
+	}
+
+	o, err := svc.Org().FindOrganizationByName(context.Background(), "org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.ID.String() != orgID {
+		t.Errorf("unexpected org: %+v", o)
+	}
+
+	if w := svc.Writer(); w.Addr != ts.URL || w.Token != "token" {
+		t.Errorf("unexpected write service: %+v", w)
+	}
+}
+
+func TestNewService_accessors(t *testing.T) {
+	svc, err := NewService(mustNewHTTPClient(t, "http://localhost:9999", ""), "http://localhost:9999", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Org() != svc.OrganizationService || svc.Bucket() != svc.BucketService || svc.DBRPMapping() != svc.DBRPMappingServiceV2 {
+		t.Error("expected the accessors to return the embedded services")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	"github.com/influxdata/influxdb/v2/predicate"
 	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
 	"go.uber.org/zap"
@@ -239,6 +240,52 @@ type DeleteService struct {
 	Addr               string
 	Token              string
 	InsecureSkipVerify bool
+
+	// Client sends the requests of Delete.
+	Client *httpc.Client
+}
+
+// DeletePredicateError is returned by Delete when the server rejects the
+// predicate of a delete.
+type DeletePredicateError struct {
+	Predicate string
+	Err       error
+}
+
+// Error implements the error interface.
+func (e *DeletePredicateError) Error() string {
+	return fmt.Sprintf("invalid delete predicate %q: %v", e.Predicate, e.Err)
+}
+
+// Unwrap returns the error of the server.
+func (e *DeletePredicateError) Unwrap() error {
+	return e.Err
+}
+
+// Delete deletes the points of the bucket between start and stop matching
+// predicate. An empty predicate deletes every point in the range. A predicate
+// the server cannot parse fails with a *DeletePredicateError.
+func (s *DeleteService) Delete(ctx context.Context, orgID, bucketID influxdb.ID, start, stop time.Time, predicate string) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	dr := DeleteRequest{
+		Start:     start.UTC().Format(time.RFC3339Nano),
+		Stop:      stop.UTC().Format(time.RFC3339Nano),
+		Predicate: predicate,
+	}
+	err := s.Client.
+		PostJSON(dr, prefixDelete).
+		QueryParams(
+			[2]string{"orgID", orgID.String()},
+			[2]string{"bucketID", bucketID.String()},
+		).
+		Do(ctx)
+	if err != nil && predicate != "" && influxdb.ErrorCode(err) == influxdb.EInvalid {
+		// the times and body are valid, so the server rejected the predicate.
+		return &DeletePredicateError{Predicate: predicate, Err: err}
+	}
+	return err
 }
 
 // DeleteBucketRangePredicate send delete request over http to delete points.
@@ -3,13 +3,16 @@ package http
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
@@ -380,3 +383,99 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteService_Delete(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	stop := start.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		predicate string
+		predErr   bool
+		pred      bool
+	}{
+		{
+			name:      "predicate",
+			predicate: `_measurement="cpu" and host="a"`,
+			pred:      true,
+		},
+		{
+			name: "empty predicate deletes the range",
+		},
+		{
+			name:      "malformed predicate",
+			predicate: `host=`,
+			predErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			deleteBackend := NewMockDeleteBackend(t)
+			deleteBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+			deleteBackend.DeleteService = &mock.DeleteService{
+				DeleteBucketRangePredicateF: func(_ context.Context, oid, bid influxdb.ID, min, max int64, pred influxdb.Predicate, _ influxdb.DeletePrefixRangeOptions) error {
+					called = true
+					if oid.String() != orgID || bid.String() != bucketID {
+						t.Errorf("unexpected org and bucket: got %s and %s", oid, bid)
+					}
+					if min != start.UnixNano() || max != stop.UnixNano() {
+						t.Errorf("unexpected range: got %d to %d", min, max)
+					}
+					if got := pred != nil; got != tt.pred {
+						t.Errorf("unexpected predicate: got %v", pred)
+					}
+					return nil
+				},
+			}
+			deleteBackend.OrganizationService = &mock.OrganizationService{
+				FindOrganizationF: func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+					return testOrg(orgID), nil
+				},
+			}
+			deleteBackend.BucketService = &mock.BucketService{
+				FindBucketFn: func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+					return testBucket(orgID, bucketID), nil
+				},
+			}
+			h := NewDeleteHandler(zaptest.NewLogger(t), deleteBackend)
+			server := httptest.NewServer(httpmock.NewAuthMiddlewareHandler(h, bucketWritePermission(orgID, bucketID)))
+			defer server.Close()
+
+			client, err := NewHTTPClient(server.URL, "", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc, err := NewService(client, server.URL, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = svc.Delete(context.Background(), influxtesting.MustIDBase16(orgID), influxtesting.MustIDBase16(bucketID), start, stop, tt.predicate)
+			if tt.predErr {
+				var perr *DeletePredicateError
+				if !errors.As(err, &perr) {
+					t.Fatalf("expected a DeletePredicateError, got %v", err)
+				}
+				if perr.Predicate != tt.predicate {
+					t.Errorf("unexpected predicate: got %q want %q", perr.Predicate, tt.predicate)
+				}
+				if called {
+					t.Error("unexpected delete of a malformed predicate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !called {
+				t.Error("expected a delete")
+			}
+		})
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"io"
 	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	platform "github.com/influxdata/influxdb/v2"
 	khttp "github.com/influxdata/influxdb/v2/kit/transport/http"
@@ -96,9 +98,110 @@ func CheckError(resp *http.Response) (err error) {
 		perr.Code = khttp.StatusCodeToErrorCode(resp.StatusCode)
 	}
 
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			perr.Err = &RetryAfterError{Err: perr.Err, After: d}
+		}
+	}
+
 	return perr
 }
 
+// RetryAfterError is the cause of an error response that carried a
+// Retry-After header, as sent by the server when rate limiting (429) or
+// temporarily unavailable (503).
+type RetryAfterError struct {
+	// Err is the error decoded from the response body, if any.
+	Err error
+	// After is how long the server asked the client to wait.
+	After time.Duration
+}
+
+// Error returns the error of the response body, or the recommended wait if
+// the body had none.
+func (e *RetryAfterError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("retry after %s", e.After)
+}
+
+// Unwrap returns the error decoded from the response body.
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns how long the server asked the client to wait before
+// retrying.
+func (e *RetryAfterError) RetryAfter() time.Duration {
+	return e.After
+}
+
+// RetryAfter returns the wait recommended by the server for an error returned
+// by CheckError. The bool is false if the response carried no Retry-After.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rerr *RetryAfterError
+	if stderrors.As(err, &rerr) {
+		return rerr.After, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header which is either a number of
+// seconds or an HTTP date. Dates in the past result in no wait.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// ErrorCode returns the influxdb error code of an error returned by
+// CheckError, such as influxdb.ENotFound or influxdb.EConflict. Unlike
+// influxdb.ErrorCode, the error may have been wrapped with fmt.Errorf.
+func ErrorCode(err error) string {
+	return platform.ErrorCode(findPlatformError(err))
+}
+
+// ErrorMessage returns the message sent by the server for an error returned
+// by CheckError. The error may have been wrapped with fmt.Errorf.
+func ErrorMessage(err error) string {
+	return platform.ErrorMessage(findPlatformError(err))
+}
+
+// StatusCode returns the HTTP status code that corresponds to the code of an
+// error returned by CheckError, as mapped by the server when responding.
+// Zero is returned for a nil error.
+func StatusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return khttp.ErrorCodeToStatusCode(context.Background(), ErrorCode(err))
+}
+
+func findPlatformError(err error) error {
+	var perr *platform.Error
+	if stderrors.As(err, &perr) {
+		return perr
+	}
+	return err
+}
+
 func firstLineAsError(buf bytes.Buffer) error {
 	line, _ := buf.ReadString('\n')
 	return stderrors.New(strings.TrimSuffix(line, "\n"))
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	stderrors "errors"
+	"fmt"
 	"io"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb/v2"
@@ -112,3 +114,114 @@ func TestCheckError(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckError_retryAfter(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+		wantOK     bool
+	}{
+		{
+			name:       "too many requests with seconds",
+			status:     429,
+			retryAfter: "3",
+			want:       3 * time.Second,
+			wantOK:     true,
+		},
+		{
+			name:       "unavailable with a date in the past",
+			status:     503,
+			retryAfter: "Wed, 21 Oct 2015 07:28:00 GMT",
+			wantOK:     true,
+		},
+		{
+			name:   "too many requests without header",
+			status: 429,
+		},
+		{
+			name:       "invalid header",
+			status:     429,
+			retryAfter: "soon",
+		},
+		{
+			name:       "other status codes ignore the header",
+			status:     500,
+			retryAfter: "3",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			kithttp.ErrorHandler(0).HandleHTTPError(context.Background(), &influxdb.Error{
+				Code: kithttp.StatusCodeToErrorCode(tt.status),
+				Msg:  "slow down",
+			}, w)
+
+			err := http.CheckError(w.Result())
+			if got := influxdb.ErrorCode(err); got != kithttp.StatusCodeToErrorCode(tt.status) {
+				t.Errorf("unexpected error code: got %q", got)
+			}
+			if got := influxdb.ErrorMessage(err); got != "slow down" {
+				t.Errorf("unexpected error message: got %q", got)
+			}
+
+			got, ok := http.RetryAfter(err)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("unexpected retry after: got %v, %v want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+
+			var rerr *http.RetryAfterError
+			if stderrors.As(err.(*influxdb.Error).Err, &rerr) != tt.wantOK {
+				t.Fatalf("unexpected error type: %#v", err)
+			}
+			if tt.wantOK && rerr.RetryAfter() != tt.want {
+				t.Errorf("unexpected RetryAfter(): got %v want %v", rerr.RetryAfter(), tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		status  int
+		code    string
+		message string
+	}{
+		{name: "not found", status: 404, code: influxdb.ENotFound, message: "bucket not found"},
+		{name: "conflict", status: 422, code: influxdb.EConflict, message: "bucket already exists"},
+		{name: "unauthorized", status: 401, code: influxdb.EUnauthorized, message: "unauthorized access"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			kithttp.ErrorHandler(0).HandleHTTPError(context.Background(), &influxdb.Error{
+				Code: tt.code,
+				Msg:  tt.message,
+			}, w)
+
+			err := http.CheckError(w.Result())
+			for _, err := range []error{err, fmt.Errorf("finding bucket: %w", err)} {
+				if got := http.ErrorCode(err); got != tt.code {
+					t.Errorf("unexpected error code: got %q want %q", got, tt.code)
+				}
+				if got := http.ErrorMessage(err); got != tt.message {
+					t.Errorf("unexpected error message: got %q want %q", got, tt.message)
+				}
+				if got := http.StatusCode(err); got != tt.status {
+					t.Errorf("unexpected status code: got %d want %d", got, tt.status)
+				}
+			}
+			if got := err.Error(); got != tt.message {
+				t.Errorf("unexpected error string: got %q want %q", got, tt.message)
+			}
+		})
+	}
+
+	if got := http.StatusCode(nil); got != 0 {
+		t.Errorf("unexpected status code for nil error: got %d", got)
+	}
+}
@@ -11,6 +11,8 @@ import (
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
 	msg := fmt.Sprintf(`{"name":"influxdb", "message":"ready for queries and writes", "status":"pass", "checks":[], "version": %q, "commit": %q}`, platform.GetBuildInfo().Version, platform.GetBuildInfo().Commit)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(headerInfluxDBVersion, platform.GetBuildInfo().Version)
+	w.Header().Set(headerInfluxDBBuild, "OSS")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, msg)
 }
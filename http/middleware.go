@@ -134,6 +134,8 @@ var blacklistEndpoints = map[string]isValidMethodFn{
 	usersPasswordPath:                ignoreMethod(),
 	"/api/v2/packages/apply":         ignoreMethod(),
 	prefixWrite:                      ignoreMethod("POST"),
+	prefixWriteBatch:                 ignoreMethod("POST"),
+	prefixPromWrite:                  ignoreMethod("POST"),
 	organizationsIDSecretsPath:       ignoreMethod("PATCH"),
 	organizationsIDSecretsDeletePath: ignoreMethod("POST"),
 	prefixSetup:                      ignoreMethod("POST"),
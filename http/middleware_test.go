@@ -232,6 +232,11 @@ func TestLoggingMW(t *testing.T) {
 				method: "POST",
 				path:   "/api/v2/write",
 			},
+			{
+				name:   "write batch path",
+				method: "POST",
+				path:   "/api/v2/write/batch",
+			},
 			{
 				name:   "orgs id secrets path",
 				method: "PATCH",
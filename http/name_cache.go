@@ -0,0 +1,206 @@
+package http
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// ServiceOption configures a Service from NewService or NewLazyService.
+type ServiceOption func(*Service)
+
+// WithNameCache caches the organizations and buckets the Service finds by
+// name, so that resolving the same names again does not call the server.
+// At most size results are kept for up to ttl, the least recently used being
+// evicted first. Updates and deletes made through the Service evict the
+// results they change, others are only seen once ttl expires or the name is
+// passed to Invalidate.
+func WithNameCache(size int, ttl time.Duration) ServiceOption {
+	return func(s *Service) {
+		if size <= 0 || ttl <= 0 {
+			return
+		}
+		c := newNameCache(size, ttl)
+		s.names = c
+		// the services of a lazy Service get the cache when they are built.
+		if s.OrganizationService != nil {
+			s.OrganizationService.names = c
+		}
+		if s.BucketService != nil {
+			s.BucketService.names = c
+		}
+	}
+}
+
+// Invalidate evicts the cached organizations and buckets named name. It does
+// nothing unless the Service was created WithNameCache.
+func (s *Service) Invalidate(name string) {
+	s.names.invalidate(name)
+}
+
+const (
+	nameCacheOrg    = "org"
+	nameCacheBucket = "bucket"
+)
+
+// nameCacheKey identifies a result by its kind and name. The scope of a
+// bucket is the org ID or org name it was found with.
+type nameCacheKey struct {
+	kind  string
+	scope string
+	name  string
+}
+
+type nameCacheEntry struct {
+	key     nameCacheKey
+	id      influxdb.ID
+	value   interface{}
+	expires time.Time
+}
+
+// nameCache is an LRU cache of find results by name. Entries expire once
+// they are older than the ttl. A nil *nameCache caches nothing.
+type nameCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	entries map[nameCacheKey]*list.Element
+	evictor *list.List
+}
+
+func newNameCache(capacity int, ttl time.Duration) *nameCache {
+	return &nameCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[nameCacheKey]*list.Element),
+		evictor:  list.New(),
+	}
+}
+
+func (c *nameCache) findOrganization(name string) (*influxdb.Organization, bool) {
+	v, ok := c.get(nameCacheKey{kind: nameCacheOrg, name: name})
+	if !ok {
+		return nil, false
+	}
+	o := *v.(*influxdb.Organization)
+	return &o, true
+}
+
+func (c *nameCache) addOrganization(o *influxdb.Organization) {
+	cp := *o
+	c.add(nameCacheKey{kind: nameCacheOrg, name: o.Name}, o.ID, &cp)
+}
+
+// bucketKey returns the key of a filter for a bucket by name in an org, false
+// if filter is not one.
+func bucketKey(filter influxdb.BucketFilter) (nameCacheKey, bool) {
+	if filter.Name == nil || filter.ID != nil {
+		return nameCacheKey{}, false
+	}
+	switch {
+	case filter.OrganizationID != nil:
+		return nameCacheKey{kind: nameCacheBucket, scope: "id:" + filter.OrganizationID.String(), name: *filter.Name}, true
+	case filter.Org != nil:
+		return nameCacheKey{kind: nameCacheBucket, scope: "name:" + *filter.Org, name: *filter.Name}, true
+	}
+	return nameCacheKey{}, false
+}
+
+func (c *nameCache) findBucket(k nameCacheKey) (*influxdb.Bucket, bool) {
+	v, ok := c.get(k)
+	if !ok {
+		return nil, false
+	}
+	b := *v.(*influxdb.Bucket)
+	return &b, true
+}
+
+func (c *nameCache) addBucket(k nameCacheKey, b *influxdb.Bucket) {
+	cp := *b
+	c.add(k, b.ID, &cp)
+}
+
+func (c *nameCache) get(k nameCacheKey) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ele, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	entry := ele.Value.(*nameCacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(ele)
+		return nil, false
+	}
+	c.evictor.MoveToFront(ele)
+	return entry.value, true
+}
+
+// add caches v, evicting the least recently used entry if the cache is full.
+func (c *nameCache) add(k nameCacheKey, id influxdb.ID, v interface{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &nameCacheEntry{key: k, id: id, value: v, expires: c.now().Add(c.ttl)}
+	if ele, ok := c.entries[k]; ok {
+		ele.Value = entry
+		c.evictor.MoveToFront(ele)
+		return
+	}
+
+	c.entries[k] = c.evictor.PushFront(entry)
+	for c.evictor.Len() > c.capacity {
+		c.remove(c.evictor.Back())
+	}
+}
+
+// invalidate evicts the entries named name, of any kind.
+func (c *nameCache) invalidate(name string) {
+	c.evict(func(e *nameCacheEntry) bool { return e.key.name == name })
+}
+
+// invalidateID evicts the entries of the given kind for the resource id.
+func (c *nameCache) invalidateID(kind string, id influxdb.ID) {
+	c.evict(func(e *nameCacheEntry) bool { return e.key.kind == kind && e.id == id })
+}
+
+// invalidateOrgBuckets evicts the buckets of the org orgID.
+func (c *nameCache) invalidateOrgBuckets(orgID influxdb.ID) {
+	c.evict(func(e *nameCacheEntry) bool {
+		b, ok := e.value.(*influxdb.Bucket)
+		return ok && b.OrgID == orgID
+	})
+}
+
+func (c *nameCache) evict(match func(*nameCacheEntry) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ele := c.evictor.Front(); ele != nil; {
+		next := ele.Next()
+		if match(ele.Value.(*nameCacheEntry)) {
+			c.remove(ele)
+		}
+		ele = next
+	}
+}
+
+func (c *nameCache) remove(ele *list.Element) {
+	c.evictor.Remove(ele)
+	delete(c.entries, ele.Value.(*nameCacheEntry).key)
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+func TestNameCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newNameCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.addOrganization(&influxdb.Organization{ID: 1, Name: "a"})
+	c.addOrganization(&influxdb.Organization{ID: 2, Name: "b"})
+	if _, ok := c.findOrganization("a"); !ok {
+		t.Fatal("expected org a to be cached")
+	}
+
+	// b is the least recently used, so it is evicted.
+	c.addOrganization(&influxdb.Organization{ID: 3, Name: "c"})
+	if _, ok := c.findOrganization("b"); ok {
+		t.Error("expected org b to be evicted")
+	}
+
+	c.invalidate("a")
+	if _, ok := c.findOrganization("a"); ok {
+		t.Error("expected org a to be invalidated")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.findOrganization("c"); ok {
+		t.Error("expected org c to expire")
+	}
+
+	var nilCache *nameCache
+	nilCache.addOrganization(&influxdb.Organization{ID: 1, Name: "a"})
+	if _, ok := nilCache.findOrganization("a"); ok {
+		t.Error("expected nil cache to cache nothing")
+	}
+}
+
+func TestService_WithNameCache(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	var orgCalls, bucketCalls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case prefixOrganizations:
+			atomic.AddInt32(&orgCalls, 1)
+			fmt.Fprintf(w, `{"orgs":[{"id":%q,"name":"org"}]}`, orgID)
+		case prefixBuckets:
+			atomic.AddInt32(&bucketCalls, 1)
+			fmt.Fprintf(w, `{"buckets":[{"id":%q,"orgID":%q,"name":"bucket","retentionRules":[]}]}`, bucketID, orgID)
+		case prefixBuckets + "/" + bucketID:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := mustNewHTTPClient(t, ts.URL, "")
+	svc, err := NewService(client, ts.URL, "", WithNameCache(10, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	orgName, bucketName := "org", "bucket"
+	findOrg := func() {
+		t.Helper()
+		o, err := svc.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &orgName})
+		if err != nil {
+			t.Fatalf("unexpected error finding org: %v", err)
+		}
+		if o.ID.String() != orgID {
+			t.Fatalf("unexpected org: %v", o.ID)
+		}
+	}
+	findBucket := func() *influxdb.Bucket {
+		t.Helper()
+		b, err := svc.FindBucket(ctx, influxdb.BucketFilter{Org: &orgName, Name: &bucketName})
+		if err != nil {
+			t.Fatalf("unexpected error finding bucket: %v", err)
+		}
+		return b
+	}
+
+	findOrg()
+	findOrg()
+	if got := atomic.LoadInt32(&orgCalls); got != 1 {
+		t.Errorf("unexpected org lookups: got %d want 1", got)
+	}
+
+	b := findBucket()
+	b.Name = "changed by the caller"
+	findBucket()
+	if got := atomic.LoadInt32(&bucketCalls); got != 1 {
+		t.Errorf("unexpected bucket lookups: got %d want 1", got)
+	}
+	if got := findBucket().Name; got != bucketName {
+		t.Errorf("cached bucket was changed by the caller: got name %q", got)
+	}
+
+	svc.Invalidate(orgName)
+	findOrg()
+	if got := atomic.LoadInt32(&orgCalls); got != 2 {
+		t.Errorf("unexpected org lookups after invalidate: got %d want 2", got)
+	}
+
+	if err := svc.DeleteBucket(ctx, b.ID); err != nil {
+		t.Fatalf("unexpected error deleting bucket: %v", err)
+	}
+	findBucket()
+	if got := atomic.LoadInt32(&bucketCalls); got != 2 {
+		t.Errorf("unexpected bucket lookups after delete: got %d want 2", got)
+	}
+}
+
+func TestService_withoutNameCache(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"orgs":[{"id":"043e0780ee2b1000","name":"org"}]}`)
+	}))
+	defer ts.Close()
+
+	svc, err := NewService(mustNewHTTPClient(t, ts.URL, ""), ts.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "org"
+	for i := 0; i < 2; i++ {
+		if _, err := svc.FindOrganization(context.Background(), influxdb.OrganizationFilter{Name: &name}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	svc.Invalidate(name)
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("unexpected org lookups: got %d want 2", got)
+	}
+}
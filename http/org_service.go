@@ -70,6 +70,10 @@ const (
 	organizationsIDSecretsDeletePath = "/api/v2/orgs/:id/secrets/delete"
 	organizationsIDLabelsPath        = "/api/v2/orgs/:id/labels"
 	organizationsIDLabelsIDPath      = "/api/v2/orgs/:id/labels/:lid"
+
+	// paramNamePrefix is the query parameter restricting the orgs listed to
+	// those whose name starts with its value.
+	paramNamePrefix = "namePrefix"
 )
 
 func checkOrganizationExists(orgHandler *OrgHandler) kithttp.Middleware {
@@ -261,6 +265,9 @@ func (h *OrgHandler) handleGetOrgs(w http.ResponseWriter, r *http.Request) {
 		}
 		filter.ID = id
 	}
+	if prefix := qp.Get(paramNamePrefix); prefix != "" {
+		filter.NamePrefix = &prefix
+	}
 
 	if userID := qp.Get("userID"); userID != "" {
 		id, err := influxdb.IDFromString(userID)
@@ -491,6 +498,8 @@ type OrganizationService struct {
 	Client *httpc.Client
 	// OpPrefix is for not found errors.
 	OpPrefix string
+
+	names *nameCache
 }
 
 // FindOrganizationByID gets a single organization with a given id using HTTP.
@@ -506,11 +515,44 @@ func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id influ
 	return o, nil
 }
 
+// FindOrganizationByName gets a single organization by name using HTTP.
+func (s *OrganizationService) FindOrganizationByName(ctx context.Context, name string) (*influxdb.Organization, error) {
+	if name == "" {
+		return nil, &influxdb.Error{
+			Code: influxdb.EUnprocessableEntity,
+			Op:   s.OpPrefix + opFindOrganizationByName,
+			Msg:  "organization name is required",
+		}
+	}
+
+	o, err := s.FindOrganization(ctx, influxdb.OrganizationFilter{Name: &name})
+	if influxdb.ErrorCode(err) == influxdb.ENotFound {
+		return nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Op:   s.OpPrefix + opFindOrganizationByName,
+			Msg:  fmt.Sprintf("organization %q not found", name),
+		}
+	} else if err != nil {
+		return nil, &influxdb.Error{
+			Err: err,
+			Op:  s.OpPrefix + opFindOrganizationByName,
+		}
+	}
+	return o, nil
+}
+
 // FindOrganization gets a single organization matching the filter using HTTP.
 func (s *OrganizationService) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
 	if filter.ID == nil && filter.Name == nil {
 		return nil, influxdb.ErrInvalidOrgFilter
 	}
+	byName := filter.ID == nil
+	if byName {
+		if o, ok := s.names.findOrganization(*filter.Name); ok {
+			return o, nil
+		}
+	}
+
 	os, n, err := s.FindOrganizations(ctx, filter)
 	if err != nil {
 		return nil, &influxdb.Error{
@@ -527,11 +569,69 @@ func (s *OrganizationService) FindOrganization(ctx context.Context, filter influ
 		}
 	}
 
+	if byName {
+		s.names.addOrganization(os[0])
+	}
 	return os[0], nil
 }
 
 // FindOrganizations returns all organizations that match the filter via HTTP.
+// A NamePrefix filter is applied by the client, see findOrganizationsByPrefix.
 func (s *OrganizationService) FindOrganizations(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if filter.NamePrefix != nil && filter.Name == nil && filter.ID == nil {
+		span.LogKV("name-prefix", *filter.NamePrefix)
+		return s.findOrganizationsByPrefix(ctx, filter, opt...)
+	}
+	return s.findOrganizations(ctx, filter, opt...)
+}
+
+// findOrganizationsByPrefix returns the orgs of filter whose name starts with
+// filter.NamePrefix. The prefix is sent to the server, which returns only the
+// matching orgs, but servers that predate it ignore it and return every org.
+// Either way the client pages through every org the server returns and
+// filters them itself, applying the offset and limit of opt to the matching
+// orgs.
+func (s *OrganizationService) findOrganizationsByPrefix(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	page := influxdb.FindOptions{Limit: influxdb.MaxPageSize}
+	if len(opt) > 0 {
+		page.SortBy = opt[0].SortBy
+		page.Descending = opt[0].Descending
+	}
+
+	var orgs []*influxdb.Organization
+	for {
+		os, n, err := s.findOrganizations(ctx, filter, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, o := range os {
+			if strings.HasPrefix(o.Name, *filter.NamePrefix) {
+				orgs = append(orgs, o)
+			}
+		}
+		if n < page.Limit {
+			break
+		}
+		page.Offset += n
+	}
+
+	if len(opt) > 0 {
+		if opt[0].Offset >= len(orgs) {
+			orgs = nil
+		} else {
+			orgs = orgs[opt[0].Offset:]
+		}
+		if opt[0].Limit > 0 && len(orgs) > opt[0].Limit {
+			orgs = orgs[:opt[0].Limit]
+		}
+	}
+	return orgs, len(orgs), nil
+}
+
+func (s *OrganizationService) findOrganizations(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
@@ -544,6 +644,9 @@ func (s *OrganizationService) FindOrganizations(ctx context.Context, filter infl
 		span.LogKV("org-id", *filter.ID)
 		params = append(params, [2]string{"orgID", filter.ID.String()})
 	}
+	if filter.NamePrefix != nil {
+		params = append(params, [2]string{paramNamePrefix, *filter.NamePrefix})
+	}
 	for _, o := range opt {
 		if o.Offset != 0 {
 			span.LogKV("offset", o.Offset)
@@ -583,10 +686,17 @@ func (s *OrganizationService) CreateOrganization(ctx context.Context, o *influxd
 		span.LogKV("org-id", o.ID)
 	}
 
-	return s.Client.
+	err := s.Client.
 		PostJSON(o, prefixOrganizations).
 		DecodeJSON(o).
 		Do(ctx)
+	if err != nil {
+		return &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + influxdb.OpCreateOrganization,
+		}
+	}
+	return nil
 }
 
 // UpdateOrganization updates the organization over HTTP.
@@ -603,20 +713,154 @@ func (s *OrganizationService) UpdateOrganization(ctx context.Context, id influxd
 		DecodeJSON(&o).
 		Do(ctx)
 	if err != nil {
-		return nil, tracing.LogError(span, err)
+		return nil, &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + influxdb.OpUpdateOrganization,
+		}
 	}
 
+	s.names.invalidateID(nameCacheOrg, id)
 	return &o, nil
 }
 
+// maxUpsertAttempts is the number of times an upsert finds and creates its
+// resource before giving up, when another client keeps creating the resource
+// between the find and the create.
+const maxUpsertAttempts = 3
+
+// UpsertOrganization creates the organization named o.Name, or updates the
+// description of the organization of that name when it exists, and returns
+// the organization as it is stored. An organization created by another client
+// between the lookup and the create is updated instead.
+func (s *OrganizationService) UpsertOrganization(ctx context.Context, o *influxdb.Organization) (*influxdb.Organization, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	for attempt := 1; ; attempt++ {
+		existing, err := s.FindOrganizationByName(ctx, o.Name)
+		if err == nil {
+			if existing.Description == o.Description {
+				return existing, nil
+			}
+			return s.UpdateOrganization(ctx, existing.ID, influxdb.OrganizationUpdate{
+				Description: &o.Description,
+			})
+		}
+		if influxdb.ErrorCode(err) != influxdb.ENotFound {
+			return nil, &influxdb.Error{
+				Err: err,
+				Op:  s.OpPrefix + opUpsertOrganization,
+			}
+		}
+
+		created := *o
+		err = s.CreateOrganization(ctx, &created)
+		if err == nil {
+			return &created, nil
+		}
+		if influxdb.ErrorCode(err) != influxdb.EConflict || attempt == maxUpsertAttempts {
+			return nil, &influxdb.Error{
+				Err: err,
+				Op:  s.OpPrefix + opUpsertOrganization,
+			}
+		}
+	}
+}
+
 // DeleteOrganization removes organization id over HTTP.
 func (s *OrganizationService) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
-	return s.Client.
+	err := s.Client.
 		Delete(prefixOrganizations, id.String()).
 		Do(ctx)
+	if err != nil {
+		return &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + influxdb.OpDeleteOrganization,
+		}
+	}
+	s.names.invalidateID(nameCacheOrg, id)
+	s.names.invalidateOrgBuckets(id)
+	return nil
+}
+
+const (
+	opFindOrganizationByName   = "FindOrganizationByName"
+	opUpsertOrganization       = "UpsertOrganization"
+	opAddOrganizationMember    = "AddOrganizationMember"
+	opRemoveOrganizationMember = "RemoveOrganizationMember"
+	opFindOrganizationMembers  = "FindOrganizationMembers"
+)
+
+// AddMember adds user userID as a member of organization orgID over HTTP.
+func (s *OrganizationService) AddMember(ctx context.Context, orgID, userID influxdb.ID) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("org-id", orgID, "user-id", userID)
+
+	err := s.members().CreateUserResourceMapping(ctx, &influxdb.UserResourceMapping{
+		ResourceID:   orgID,
+		ResourceType: influxdb.OrgsResourceType,
+		UserID:       userID,
+		UserType:     influxdb.Member,
+	})
+	if err != nil {
+		return &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + opAddOrganizationMember,
+		}
+	}
+	return nil
+}
+
+// RemoveMember removes user userID from the members of organization orgID over HTTP.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID, userID influxdb.ID) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("org-id", orgID, "user-id", userID)
+
+	if err := s.members().DeleteUserResourceMapping(ctx, orgID, userID); err != nil {
+		return &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + opRemoveOrganizationMember,
+		}
+	}
+	return nil
+}
+
+// FindMembers returns the users that are members of organization orgID over HTTP.
+func (s *OrganizationService) FindMembers(ctx context.Context, orgID influxdb.ID) ([]*influxdb.User, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("org-id", orgID)
+
+	var resp resourceUsersResponse
+	err := s.Client.
+		Get(resourceIDPath(influxdb.OrgsResourceType, orgID, string(influxdb.Member)+"s")).
+		DecodeJSON(&resp).
+		Do(ctx)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Err: tracing.LogError(span, err),
+			Op:  s.OpPrefix + opFindOrganizationMembers,
+		}
+	}
+
+	users := make([]*influxdb.User, 0, len(resp.Users))
+	for _, u := range resp.Users {
+		user := u.User
+		users = append(users, &user)
+	}
+	return users, nil
+}
+
+func (s *OrganizationService) members() *SpecificURMSvc {
+	return (&UserResourceMappingService{Client: s.Client}).SpecificURMSvc(influxdb.OrgsResourceType, influxdb.Member)
 }
 
 func organizationIDPath(id influxdb.ID) string {
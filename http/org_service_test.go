@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
@@ -109,6 +111,220 @@ func TestOrganizationService(t *testing.T) {
 	influxdbtesting.OrganizationService(initOrganizationService, t)
 }
 
+func TestOrganizationService_Members(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	svc := kv.NewService(logger, NewTestInmemStore(t))
+
+	org := &influxdb.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	user := &influxdb.User{Name: "user"}
+	if err := svc.CreateUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	orgBackend := NewMockOrgBackend(t)
+	orgBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	orgBackend.OrganizationService = svc
+	orgBackend.UserResourceMappingService = svc
+	orgBackend.UserService = svc
+	server := httptest.NewServer(NewOrgHandler(logger, orgBackend))
+	defer server.Close()
+
+	client := OrganizationService{
+		Client:   mustNewHTTPClient(t, server.URL, ""),
+		OpPrefix: "client/",
+	}
+
+	findMembers := func(t *testing.T) []string {
+		t.Helper()
+		users, err := client.FindMembers(ctx, org.ID)
+		if err != nil {
+			t.Fatalf("unexpected error finding members: %v", err)
+		}
+		var names []string
+		for _, u := range users {
+			names = append(names, u.Name)
+		}
+		return names
+	}
+
+	if err := client.AddMember(ctx, org.ID, user.ID); err != nil {
+		t.Fatalf("unexpected error adding member: %v", err)
+	}
+	if got := findMembers(t); len(got) != 1 || got[0] != "user" {
+		t.Errorf("unexpected members after add: %v", got)
+	}
+
+	if err := client.RemoveMember(ctx, org.ID, user.ID); err != nil {
+		t.Fatalf("unexpected error removing member: %v", err)
+	}
+	if got := findMembers(t); len(got) != 0 {
+		t.Errorf("unexpected members after remove: %v", got)
+	}
+
+	err := client.AddMember(ctx, org.ID, influxdb.ID(1))
+	if got := influxdb.ErrorCode(err); got != influxdb.ENotFound {
+		t.Errorf("unexpected error code adding unknown user: got %q want %q", got, influxdb.ENotFound)
+	}
+	if got := influxdb.ErrorOp(err); got != "client/"+opAddOrganizationMember {
+		t.Errorf("unexpected error op adding unknown user: got %q", got)
+	}
+}
+
+func TestOrganizationService_FindOrganizationByName(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	svc := kv.NewService(logger, NewTestInmemStore(t))
+
+	org := &influxdb.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+
+	orgBackend := NewMockOrgBackend(t)
+	orgBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	orgBackend.OrganizationService = svc
+	server := httptest.NewServer(NewOrgHandler(logger, orgBackend))
+	defer server.Close()
+
+	client := OrganizationService{
+		Client:   mustNewHTTPClient(t, server.URL, ""),
+		OpPrefix: "client/",
+	}
+
+	got, err := client.FindOrganizationByName(ctx, "org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != org.ID {
+		t.Errorf("unexpected org: got %v want %v", got.ID, org.ID)
+	}
+
+	tests := []struct {
+		name string
+		org  string
+		code string
+		msg  string
+	}{
+		{name: "unknown org", org: "other", code: influxdb.ENotFound, msg: `organization "other" not found`},
+		{name: "empty name", org: "", code: influxdb.EUnprocessableEntity, msg: "organization name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.FindOrganizationByName(ctx, tt.org)
+			if got := influxdb.ErrorCode(err); got != tt.code {
+				t.Errorf("unexpected error code: got %q want %q", got, tt.code)
+			}
+			if got := influxdb.ErrorMessage(err); got != tt.msg {
+				t.Errorf("unexpected error message: got %q want %q", got, tt.msg)
+			}
+			if got := influxdb.ErrorOp(err); got != "client/"+opFindOrganizationByName {
+				t.Errorf("unexpected error op: got %q", got)
+			}
+		})
+	}
+}
+
+func TestOrganizationService_FindOrganizations_namePrefix(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	prefix := "team-"
+
+	names := func(orgs []*influxdb.Organization) string {
+		var ns []string
+		for _, o := range orgs {
+			ns = append(ns, o.Name)
+		}
+		return strings.Join(ns, ",")
+	}
+
+	t.Run("server filtering by prefix", func(t *testing.T) {
+		svc := kv.NewService(logger, NewTestInmemStore(t))
+		for _, name := range []string{"team-a", "other", "team-b", "teamc"} {
+			if err := svc.CreateOrganization(ctx, &influxdb.Organization{Name: name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var queries []string
+		orgBackend := NewMockOrgBackend(t)
+		orgBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+		orgBackend.OrganizationService = svc
+		orgHandler := NewOrgHandler(logger, orgBackend)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query().Get(paramNamePrefix))
+			orgHandler.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		client := OrganizationService{Client: mustNewHTTPClient(t, server.URL, "")}
+		orgs, n, err := client.FindOrganizations(ctx, influxdb.OrganizationFilter{NamePrefix: &prefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// orgs are listed in the order of their random IDs.
+		sort.Slice(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
+		if got, want := names(orgs), "team-a,team-b"; got != want || n != 2 {
+			t.Errorf("unexpected orgs: got %s (%d) want %s", got, n, want)
+		}
+		if got := strings.Join(queries, ","); got != prefix {
+			t.Errorf("unexpected name prefixes sent: got %q want %q", got, prefix)
+		}
+	})
+
+	t.Run("server ignoring the prefix", func(t *testing.T) {
+		// 150 orgs, every third matching the prefix, served by a server
+		// that only pages.
+		var all []*influxdb.Organization
+		for i := 0; i < 150; i++ {
+			name := fmt.Sprintf("org-%03d", i)
+			if i%3 == 0 {
+				name = fmt.Sprintf("team-%03d", i)
+			}
+			all = append(all, &influxdb.Organization{ID: influxdb.ID(i + 1), Name: name})
+		}
+		var pages int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pages++
+			opts, err := influxdb.DecodeFindOptions(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end := opts.Offset + opts.Limit
+			if end > len(all) {
+				end = len(all)
+			}
+			if err := json.NewEncoder(w).Encode(newOrgsResponse(all[opts.Offset:end])); err != nil {
+				t.Fatal(err)
+			}
+		}))
+		defer server.Close()
+
+		client := OrganizationService{Client: mustNewHTTPClient(t, server.URL, "")}
+		orgs, n, err := client.FindOrganizations(ctx, influxdb.OrganizationFilter{NamePrefix: &prefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 50 || orgs[0].Name != "team-000" || orgs[49].Name != "team-147" {
+			t.Errorf("unexpected orgs: %s", names(orgs))
+		}
+		if pages != 2 {
+			t.Errorf("unexpected pages requested: got %d want 2", pages)
+		}
+
+		orgs, _, err = client.FindOrganizations(ctx, influxdb.OrganizationFilter{NamePrefix: &prefix}, influxdb.FindOptions{Offset: 10, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := names(orgs), "team-030,team-033"; got != want {
+			t.Errorf("unexpected page of orgs: got %s want %s", got, want)
+		}
+	})
+}
+
 func TestSecretService(t *testing.T) {
 	t.Parallel()
 	influxdbtesting.DeleteSecrets(initSecretService, t)
@@ -424,3 +640,74 @@ func TestSecretService_handleDeleteSecrets(t *testing.T) {
 		})
 	}
 }
+
+// racingOrganizationService creates the organization of the first create on
+// behalf of another client just before the create.
+type racingOrganizationService struct {
+	influxdb.OrganizationService
+	raced bool
+}
+
+func (s *racingOrganizationService) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	if !s.raced {
+		s.raced = true
+		other := *o
+		if err := s.OrganizationService.CreateOrganization(ctx, &other); err != nil {
+			return err
+		}
+	}
+	return s.OrganizationService.CreateOrganization(ctx, o)
+}
+
+func TestOrganizationService_UpsertOrganization(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+	svc := kv.NewService(logger, NewTestInmemStore(t))
+	racing := &racingOrganizationService{OrganizationService: svc, raced: true}
+
+	orgBackend := NewMockOrgBackend(t)
+	orgBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	orgBackend.OrganizationService = racing
+	server := httptest.NewServer(NewOrgHandler(logger, orgBackend))
+	defer server.Close()
+
+	client := OrganizationService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	created, err := client.UpsertOrganization(ctx, &influxdb.Organization{Name: "org", Description: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.ID.Valid() || created.Description != "first" {
+		t.Fatalf("unexpected created org: %+v", created)
+	}
+
+	updated, err := client.UpsertOrganization(ctx, &influxdb.Organization{Name: "org", Description: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.ID != created.ID || updated.Description != "second" {
+		t.Errorf("unexpected updated org: %+v", updated)
+	}
+
+	unchanged, err := client.UpsertOrganization(ctx, &influxdb.Organization{Name: "org", Description: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.ID != created.ID || unchanged.Description != "second" {
+		t.Errorf("unexpected org: %+v", unchanged)
+	}
+
+	t.Run("created by another client", func(t *testing.T) {
+		racing.raced = false
+		got, err := client.UpsertOrganization(ctx, &influxdb.Organization{Name: "raced", Description: "mine"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Description != "mine" {
+			t.Errorf("unexpected org: %+v", got)
+		}
+		if _, n, err := svc.FindOrganizations(ctx, influxdb.OrganizationFilter{Name: &got.Name}); err != nil || n != 1 {
+			t.Errorf("unexpected orgs named %q: %d, %v", got.Name, n, err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
+)
+
+const (
+	// PingPath is the v1 endpoint reporting that the server is up.
+	PingPath = "/ping"
+
+	headerInfluxDBVersion = "X-Influxdb-Version"
+	headerInfluxDBBuild   = "X-Influxdb-Build"
+
+	opPing = "http/Ping"
+)
+
+// PingResult is the version of the server answering a Ping.
+type PingResult struct {
+	Version string
+	Build   string
+}
+
+// Ping checks that the server at s.Addr is up and, when s has a token, that
+// it accepts the token. It hits /health, falling back to the v1 /ping of
+// servers without it, whose token is not checked, and returns the version and build of the server from
+// the X-Influxdb-Version and X-Influxdb-Build headers, or the body of
+// /health. A server that cannot be reached fails with EUnavailable and a
+// rejected token with EUnauthorized or EForbidden.
+func (s *Service) Ping(ctx context.Context) (*PingResult, error) {
+	client := s.Org().Client
+	if client == nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opPing,
+			Msg:  "service has no client",
+		}
+	}
+
+	res, err := s.ping(ctx, client, HealthPath)
+	if influxdb.ErrorCode(err) == influxdb.ENotFound {
+		// v1 servers have no orgs to check the token against.
+		return s.ping(ctx, client, PingPath)
+	}
+	if err != nil || s.Token == "" {
+		return res, err
+	}
+
+	// /health and /ping do not check the token, so list an org with it.
+	err = client.
+		Get(prefixOrganizations).
+		QueryParams([2]string{"limit", "1"}).
+		Do(ctx)
+	if err != nil {
+		return nil, s.pingError(ctx, err)
+	}
+	return res, nil
+}
+
+func (s *Service) ping(ctx context.Context, client *httpc.Client, path string) (*PingResult, error) {
+	var res PingResult
+	err := client.
+		Get(path).
+		Decode(func(resp *http.Response) error {
+			res.Version = resp.Header.Get(headerInfluxDBVersion)
+			res.Build = resp.Header.Get(headerInfluxDBBuild)
+			if res.Version != "" || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+
+			var health struct {
+				Version string `json:"version"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+				return err
+			}
+			res.Version = health.Version
+			return nil
+		}).
+		Do(ctx)
+	if err != nil {
+		return nil, s.pingError(ctx, err)
+	}
+	return &res, nil
+}
+
+// pingError describes err, telling apart a server that cannot be reached
+// from one that rejects the token.
+func (s *Service) pingError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var ierr *influxdb.Error
+	if !errors.As(err, &ierr) {
+		return &influxdb.Error{
+			Code: influxdb.EUnavailable,
+			Op:   opPing,
+			Msg:  fmt.Sprintf("unable to reach InfluxDB at %s", s.Addr),
+			Err:  err,
+		}
+	}
+
+	switch code := influxdb.ErrorCode(err); code {
+	case influxdb.EUnauthorized, influxdb.EForbidden:
+		return &influxdb.Error{
+			Code: code,
+			Op:   opPing,
+			Msg:  fmt.Sprintf("InfluxDB at %s rejected the token", s.Addr),
+			Err:  err,
+		}
+	default:
+		return &influxdb.Error{
+			Code: code,
+			Op:   opPing,
+			Err:  err,
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+func TestService_Ping(t *testing.T) {
+	v2 := http.NewServeMux()
+	v2.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"name":"influxdb","status":"pass","version":"2.0.0","commit":"abc"}`))
+	})
+	v2.HandleFunc(prefixOrganizations, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token mytoken" {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"orgs":[]}`))
+	})
+	v2Server := httptest.NewServer(v2)
+	defer v2Server.Close()
+
+	v1 := http.NewServeMux()
+	v1.HandleFunc(PingPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerInfluxDBVersion, "1.8.3")
+		w.Header().Set(headerInfluxDBBuild, "OSS")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	v1Server := httptest.NewServer(v1)
+	defer v1Server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		token   string
+		want    PingResult
+		errCode string
+	}{
+		{
+			name:  "v2 health",
+			addr:  v2Server.URL,
+			token: "mytoken",
+			want:  PingResult{Version: "2.0.0"},
+		},
+		{
+			name: "v2 health without a token",
+			addr: v2Server.URL,
+			want: PingResult{Version: "2.0.0"},
+		},
+		{
+			name:  "v1 ping",
+			addr:  v1Server.URL,
+			token: "mytoken",
+			want:  PingResult{Version: "1.8.3", Build: "OSS"},
+		},
+		{
+			name:    "rejected token",
+			addr:    v2Server.URL,
+			token:   "badtoken",
+			errCode: influxdb.EUnauthorized,
+		},
+		{
+			name:    "unreachable server",
+			addr:    closed.URL,
+			errCode: influxdb.EUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.addr, tt.token, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			svc, err := NewService(client, tt.addr, tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := svc.Ping(context.Background())
+			if tt.errCode != "" {
+				if code := influxdb.ErrorCode(err); code != tt.errCode {
+					t.Fatalf("unexpected error code: got %q want %q: %v", code, tt.errCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("unexpected ping result: got %+v want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthHandler_versionHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+
+	if got, want := w.Header().Get(headerInfluxDBVersion), influxdb.GetBuildInfo().Version; got != want {
+		t.Errorf("unexpected version header: got %q want %q", got, want)
+	}
+	if got := w.Header().Get(headerInfluxDBBuild); got != "OSS" {
+		t.Errorf("unexpected build header: got %q want OSS", got)
+	}
+}
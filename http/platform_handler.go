@@ -23,6 +23,7 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 	h.SessionService = b.SessionService
 	h.SessionRenewDisabled = b.SessionRenewDisabled
 	h.UserService = b.UserService
+	h.V1Authorizer = b.V1Authorizer
 
 	h.RegisterNoAuthRoute("GET", "/api/v2")
 	h.RegisterNoAuthRoute("POST", "/api/v2/signin")
@@ -30,6 +31,8 @@ func NewPlatformHandler(b *APIBackend, opts ...APIHandlerOptFn) *PlatformHandler
 	h.RegisterNoAuthRoute("POST", "/api/v2/setup")
 	h.RegisterNoAuthRoute("GET", "/api/v2/setup")
 	h.RegisterNoAuthRoute("GET", "/api/v2/swagger.json")
+	h.RegisterNoAuthRoute("GET", prefixWriteHealth)
+	h.RegisterNoAuthRoute("GET", prefixWriteReady)
 
 	assetHandler := NewAssetHandler()
 	assetHandler.Path = b.AssetsPath
@@ -55,6 +58,7 @@ func (h *PlatformHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// of the platform API.
 	if !strings.HasPrefix(r.URL.Path, "/v1") &&
 		!strings.HasPrefix(r.URL.Path, "/api/v2") &&
+		!strings.HasPrefix(r.URL.Path, prefixPromWrite) &&
 		!strings.HasPrefix(r.URL.Path, "/chronograf/") {
 		h.AssetHandler.ServeHTTP(w, r)
 		return
@@ -20,28 +20,34 @@ const (
 
 // queryOrganization returns the organization for any http request.
 //
-// It checks the org= and then orgID= parameter of the request.
-//
-// This will try to find the organization using an ID string or
-// the name.  It interprets the &org= parameter as either the name
-// or the ID.
+// The orgID= parameter of the request is always an ID and, when present,
+// takes precedence over org=. The org= parameter is looked up as a name,
+// as org names may themselves look like IDs. Only when no organization has
+// that name and it parses as an ID is it looked up as the ID of the
+// organization instead.
 func queryOrganization(ctx context.Context, r *http.Request, svc platform.OrganizationService) (o *platform.Organization, err error) {
-	filter := platform.OrganizationFilter{}
-	if organization := r.URL.Query().Get(Org); organization != "" {
-		if id, err := platform.IDFromString(organization); err == nil {
-			filter.ID = id
-		} else {
-			filter.Name = &organization
-		}
-	}
-
-	if reqID := r.URL.Query().Get(OrgID); reqID != "" {
-		filter.ID, err = platform.IDFromString(reqID)
+	qp := r.URL.Query()
+	if reqID := qp.Get(OrgID); reqID != "" {
+		id, err := platform.IDFromString(reqID)
 		if err != nil {
 			return nil, err
 		}
+		return svc.FindOrganization(ctx, platform.OrganizationFilter{ID: id})
+	}
+
+	organization := qp.Get(Org)
+	if organization == "" {
+		return svc.FindOrganization(ctx, platform.OrganizationFilter{})
+	}
+	o, err = svc.FindOrganization(ctx, platform.OrganizationFilter{Name: &organization})
+	if platform.ErrorCode(err) != platform.ENotFound {
+		return o, err
+	}
+	id, idErr := platform.IDFromString(organization)
+	if idErr != nil {
+		return nil, err
 	}
-	return svc.FindOrganization(ctx, filter)
+	return svc.FindOrganization(ctx, platform.OrganizationFilter{ID: id})
 }
 
 // queryBucket returns the bucket for any http request.
@@ -52,19 +58,20 @@ func queryOrganization(ctx context.Context, r *http.Request, svc platform.Organi
 // the name.  It interprets the &bucket= parameter as either the name
 // or the ID.
 func queryBucket(ctx context.Context, orgID platform.ID, r *http.Request, svc platform.BucketService) (b *platform.Bucket, err error) {
-	filter := platform.BucketFilter{OrganizationID: &orgID}
+	filter := platform.NewBucketFilter().WithOrgID(orgID)
 	if bucket := r.URL.Query().Get(Bucket); bucket != "" {
 		if id, err := platform.IDFromString(bucket); err == nil {
-			filter.ID = id
+			filter = filter.WithID(*id)
 		} else {
-			filter.Name = &bucket
+			filter = filter.WithName(bucket)
 		}
 	}
 	if reqID := r.URL.Query().Get(BucketID); reqID != "" {
-		filter.ID, err = platform.IDFromString(reqID)
+		id, err := platform.IDFromString(reqID)
 		if err != nil {
 			return nil, err
 		}
+		filter = filter.WithID(*id)
 	}
 	if filter.ID == nil && filter.Name == nil {
 		return nil, &platform.Error{
@@ -89,6 +89,12 @@ func Test_queryOrganization(t *testing.T) {
 				r:   httptest.NewRequest(http.MethodPost, "/api/v2/query?org=0000000000000001", nil),
 				svc: &mock.OrganizationService{
 					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						if filter.Name != nil {
+							return nil, &platform.Error{
+								Code: platform.ENotFound,
+								Msg:  "organization not found",
+							}
+						}
 						if *filter.ID == platform.ID(1) {
 							return &platform.Organization{
 								ID: platform.ID(1),
@@ -102,6 +108,75 @@ func Test_queryOrganization(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "org named like an id finds organization by name",
+			want: &platform.Organization{
+				ID:   platform.ID(2),
+				Name: "020f755c3c083000",
+			},
+			args: args{
+				ctx: context.Background(),
+				r:   httptest.NewRequest(http.MethodPost, "/api/v2/query?org=020f755c3c083000", nil),
+				svc: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						if filter.Name != nil && *filter.Name == "020f755c3c083000" {
+							return &platform.Organization{
+								ID:   platform.ID(2),
+								Name: "020f755c3c083000",
+							}, nil
+						}
+						return nil, &platform.Error{
+							Code: platform.ENotFound,
+							Msg:  "organization not found",
+						}
+					},
+				},
+			},
+		},
+		{
+			name: "org id takes precedence over org",
+			want: &platform.Organization{
+				ID:   platform.ID(1),
+				Name: "org1",
+			},
+			args: args{
+				ctx: context.Background(),
+				r:   httptest.NewRequest(http.MethodPost, "/api/v2/query?org=020f755c3c083000&orgID=0000000000000001", nil),
+				svc: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						if filter.Name == nil && filter.ID != nil && *filter.ID == platform.ID(1) {
+							return &platform.Organization{
+								ID:   platform.ID(1),
+								Name: "org1",
+							}, nil
+						}
+						return nil, &platform.Error{
+							Code: platform.ENotFound,
+							Msg:  "organization not found",
+						}
+					},
+				},
+			},
+		},
+		{
+			name:    "unknown org name that is not an id returns error",
+			wantErr: true,
+			args: args{
+				ctx: context.Background(),
+				r:   httptest.NewRequest(http.MethodPost, "/api/v2/query?org=org2", nil),
+				svc: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						if filter.ID != nil {
+							t.Fatalf("unexpected lookup by ID %s", filter.ID)
+						}
+						return nil, &platform.Error{
+							Code: platform.ENotFound,
+							Msg:  "organization not found",
+						}
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
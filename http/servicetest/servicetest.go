@@ -0,0 +1,132 @@
+// Package servicetest serves the bucket, organization, user and user resource
+// mapping APIs from an in-memory store, so that clients of the http package
+// can be tested against a server without running InfluxDB.
+package servicetest
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/http"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/inmem"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/kv"
+	"github.com/influxdata/influxdb/v2/kv/migration/all"
+	"go.uber.org/zap/zaptest"
+)
+
+// The names of the fixtures the store of a Server is seeded with.
+const (
+	UserName   = "admin"
+	OrgName    = "myorg"
+	BucketName = "mybucket"
+)
+
+// Server is an httptest.Server serving the APIs of the services of KV. Its
+// requests are authorized as the operator User, whatever their token.
+type Server struct {
+	*httptest.Server
+
+	// Service is a client of the server.
+	Service *http.Service
+	// KV is the in-memory implementation of the services served.
+	KV *kv.Service
+
+	// User is the operator the requests are authorized as, the owner of Org.
+	User *influxdb.User
+	// Org is the seeded org, named OrgName.
+	Org *influxdb.Organization
+	// Bucket is the seeded bucket of Org, named BucketName.
+	Bucket *influxdb.Bucket
+}
+
+// NewServer starts a Server with a store seeded with the fixtures. It is to
+// be closed by the caller.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	log := zaptest.NewLogger(t)
+	store := inmem.NewKVStore()
+	if err := all.Up(context.Background(), log, store); err != nil {
+		t.Fatalf("failed to migrate the store: %v", err)
+	}
+	svc := kv.NewService(log, store)
+
+	s := &Server{KV: svc}
+	auth := s.seed(t)
+
+	b := &http.APIBackend{
+		HTTPErrorHandler:                kithttp.ErrorHandler(0),
+		BucketService:                   svc,
+		BucketOperationLogService:       svc,
+		OrganizationService:             svc,
+		OrganizationOperationLogService: svc,
+		UserService:                     svc,
+		UserOperationLogService:         svc,
+		PasswordsService:                svc,
+		UserResourceMappingService:      svc,
+		LabelService:                    svc,
+		SecretService:                   svc,
+	}
+	buckets := http.NewBucketHandler(log, http.NewBucketBackend(log, b))
+	orgs := http.NewOrgHandler(log, http.NewOrgBackend(log, b))
+	users := http.NewUserHandler(log, http.NewUserBackend(log, b))
+
+	mux := nethttp.NewServeMux()
+	for prefix, h := range map[string]nethttp.Handler{
+		"/api/v2/buckets": buckets,
+		"/api/v2/orgs":    orgs,
+		"/api/v2/users":   users,
+		"/api/v2/me":      users,
+	} {
+		mux.Handle(prefix, h)
+		mux.Handle(prefix+"/", h)
+	}
+	s.Server = httptest.NewServer(httpmock.NewAuthMiddlewareHandler(mux, auth))
+
+	client, err := http.NewHTTPClient(s.URL, "", false)
+	if err != nil {
+		s.Close()
+		t.Fatalf("failed to create the client: %v", err)
+	}
+	if s.Service, err = http.NewService(client, s.URL, ""); err != nil {
+		s.Close()
+		t.Fatalf("failed to create the service: %v", err)
+	}
+	return s
+}
+
+// seed creates the fixtures and returns the authorization of their user.
+func (s *Server) seed(t testing.TB) *influxdb.Authorization {
+	t.Helper()
+
+	ctx := context.Background()
+	s.User = &influxdb.User{Name: UserName, Status: influxdb.Active}
+	if err := s.KV.CreateUser(ctx, s.User); err != nil {
+		t.Fatalf("failed to create user %q: %v", UserName, err)
+	}
+
+	auth := &influxdb.Authorization{
+		Status:      influxdb.Active,
+		UserID:      s.User.ID,
+		Permissions: influxdb.OperPermissions(),
+	}
+	// creating the org as the user makes the user its owner.
+	ctx = pcontext.SetAuthorizer(ctx, auth)
+	s.Org = &influxdb.Organization{Name: OrgName}
+	if err := s.KV.CreateOrganization(ctx, s.Org); err != nil {
+		t.Fatalf("failed to create org %q: %v", OrgName, err)
+	}
+	auth.OrgID = s.Org.ID
+
+	s.Bucket = &influxdb.Bucket{OrgID: s.Org.ID, Name: BucketName}
+	if err := s.KV.CreateBucket(ctx, s.Bucket); err != nil {
+		t.Fatalf("failed to create bucket %q: %v", BucketName, err)
+	}
+	return auth
+}
@@ -0,0 +1,61 @@
+package servicetest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/servicetest"
+)
+
+func TestNewServer(t *testing.T) {
+	s := servicetest.NewServer(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	org, err := s.Service.FindOrganization(ctx, influxdb.OrganizationFilter{Name: strPtr(servicetest.OrgName)})
+	if err != nil {
+		t.Fatalf("failed to find the seeded org: %v", err)
+	}
+	if org.ID != s.Org.ID {
+		t.Errorf("unexpected org: got %s want %s", org.ID, s.Org.ID)
+	}
+
+	bucket, err := s.Service.FindBucketByName(ctx, org.ID, servicetest.BucketName)
+	if err != nil {
+		t.Fatalf("failed to find the seeded bucket: %v", err)
+	}
+	if bucket.ID != s.Bucket.ID {
+		t.Errorf("unexpected bucket: got %s want %s", bucket.ID, s.Bucket.ID)
+	}
+
+	user, err := s.Service.FindUserByID(ctx, s.User.ID)
+	if err != nil {
+		t.Fatalf("failed to find the seeded user: %v", err)
+	}
+	if user.Name != servicetest.UserName {
+		t.Errorf("unexpected user: %s", user.Name)
+	}
+
+	urms, _, err := s.Service.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		ResourceType: influxdb.OrgsResourceType,
+		ResourceID:   org.ID,
+		UserType:     influxdb.Owner,
+	})
+	if err != nil {
+		t.Fatalf("failed to find the owners of the seeded org: %v", err)
+	}
+	if len(urms) != 1 || urms[0].UserID != s.User.ID {
+		t.Errorf("unexpected owners: %+v", urms)
+	}
+
+	created := &influxdb.Bucket{OrgID: org.ID, Name: "other"}
+	if err := s.Service.CreateBucket(ctx, created); err != nil {
+		t.Fatalf("failed to create a bucket: %v", err)
+	}
+	if _, err := s.KV.FindBucketByID(ctx, created.ID); err != nil {
+		t.Errorf("expected the bucket to be stored: %v", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
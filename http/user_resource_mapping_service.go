@@ -311,6 +311,57 @@ func (s *UserResourceMappingService) DeleteUserResourceMapping(ctx context.Conte
 		Do(ctx)
 }
 
+// SyncMembers reconciles the users of userType on a resource with the wanted
+// set of user ids. Only the mappings that differ are created or deleted.
+func (s *UserResourceMappingService) SyncMembers(ctx context.Context, resourceType influxdb.ResourceType, resourceID influxdb.ID, userType influxdb.UserType, want []influxdb.ID) error {
+	svc := s.SpecificURMSvc(resourceType, userType)
+
+	current, _, err := svc.FindUserResourceMappings(ctx, influxdb.UserResourceMappingFilter{
+		ResourceID:   resourceID,
+		ResourceType: resourceType,
+		UserType:     userType,
+	})
+	if err != nil {
+		return err
+	}
+
+	have := make(map[influxdb.ID]bool, len(current))
+	for _, m := range current {
+		have[m.UserID] = true
+	}
+	wanted := make(map[influxdb.ID]bool, len(want))
+	for _, id := range want {
+		wanted[id] = true
+	}
+
+	for _, id := range want {
+		if have[id] {
+			continue
+		}
+		// guard against duplicates in want
+		have[id] = true
+		err := svc.CreateUserResourceMapping(ctx, &influxdb.UserResourceMapping{
+			ResourceID:   resourceID,
+			ResourceType: resourceType,
+			UserID:       id,
+			UserType:     userType,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range current {
+		if wanted[m.UserID] {
+			continue
+		}
+		if err := svc.DeleteUserResourceMapping(ctx, resourceID, m.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SpecificURMSvc returns a urm service with specific resource and user types.
 // this will help us stay compatible with the existing service contract but also allow for urm deletes to go through the correct
 // api
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/httprouter"
 	platform "github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
 	"go.uber.org/zap/zaptest"
 )
@@ -385,3 +386,93 @@ func TestUserResourceMappingService_PostMembersHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestUserResourceMappingService_SyncMembers(t *testing.T) {
+	ctx := context.Background()
+	svc := newInMemKVSVC(t)
+
+	org := &platform.Organization{Name: "org"}
+	if err := svc.CreateOrganization(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	users := make([]*platform.User, 3)
+	for i := range users {
+		users[i] = &platform.User{Name: fmt.Sprintf("user%d", i)}
+		if err := svc.CreateUser(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, u := range users[:2] {
+		err := svc.CreateUserResourceMapping(ctx, &platform.UserResourceMapping{
+			ResourceID:   org.ID,
+			ResourceType: platform.OrgsResourceType,
+			UserID:       u.ID,
+			UserType:     platform.Member,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var created, deleted []platform.ID
+	urmSvc := &mock.UserResourceMappingService{
+		FindMappingsFn: func(ctx context.Context, filter platform.UserResourceMappingFilter) ([]*platform.UserResourceMapping, int, error) {
+			return svc.FindUserResourceMappings(ctx, filter)
+		},
+		CreateMappingFn: func(ctx context.Context, m *platform.UserResourceMapping) error {
+			created = append(created, m.UserID)
+			return svc.CreateUserResourceMapping(ctx, m)
+		},
+		DeleteMappingFn: func(ctx context.Context, resourceID, userID platform.ID) error {
+			deleted = append(deleted, userID)
+			return svc.DeleteUserResourceMapping(ctx, resourceID, userID)
+		},
+	}
+
+	orgBackend := NewMockOrgBackend(t)
+	orgBackend.HTTPErrorHandler = kithttp.ErrorHandler(0)
+	orgBackend.OrganizationService = svc
+	orgBackend.UserResourceMappingService = urmSvc
+	orgBackend.UserService = svc
+	server := httptest.NewServer(NewOrgHandler(zaptest.NewLogger(t), orgBackend))
+	defer server.Close()
+
+	client := UserResourceMappingService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	want := []platform.ID{users[1].ID, users[2].ID}
+	if err := client.SyncMembers(ctx, platform.OrgsResourceType, org.ID, platform.Member, want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created) != 1 || created[0] != users[2].ID {
+		t.Errorf("unexpected members created: %v", created)
+	}
+	if len(deleted) != 1 || deleted[0] != users[0].ID {
+		t.Errorf("unexpected members deleted: %v", deleted)
+	}
+
+	ms, _, err := svc.FindUserResourceMappings(ctx, platform.UserResourceMappingFilter{
+		ResourceID:   org.ID,
+		ResourceType: platform.OrgsResourceType,
+		UserType:     platform.Member,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[platform.ID]bool)
+	for _, m := range ms {
+		got[m.UserID] = true
+	}
+	if len(got) != 2 || !got[users[1].ID] || !got[users[2].ID] {
+		t.Errorf("unexpected members after sync: %v", ms)
+	}
+
+	// a second sync is a no-op
+	created, deleted = nil, nil
+	if err := client.SyncMembers(ctx, platform.OrgsResourceType, org.ID, platform.Member, want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(created) != 0 || len(deleted) != 0 {
+		t.Errorf("expected no changes, created %v deleted %v", created, deleted)
+	}
+}
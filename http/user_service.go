@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	"go.uber.org/zap"
 )
@@ -588,12 +589,57 @@ func (s *UserService) UpdateUser(ctx context.Context, id influxdb.ID, upd influx
 
 // DeleteUser removes a user by ID.
 func (s *UserService) DeleteUser(ctx context.Context, id influxdb.ID) error {
-	return s.Client.
+	err := s.Client.
 		Delete(prefixUsers, id.String()).
 		StatusFn(func(resp *http.Response) error {
 			return CheckErrorStatus(http.StatusNoContent, resp)
 		}).
 		Do(ctx)
+	if err != nil {
+		return &influxdb.Error{
+			Op:  s.OpPrefix + influxdb.OpDeleteUser,
+			Err: err,
+		}
+	}
+	return nil
+}
+
+const opSetPassword = "SetPassword"
+
+// SetPassword sets the password of user userID. The password is only ever
+// sent in the request body, it is not included in any returned error.
+func (s *UserService) SetPassword(ctx context.Context, userID influxdb.ID, password string) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	span.LogKV("user-id", userID)
+
+	err := (&PasswordService{Client: s.Client}).SetPassword(ctx, userID, password)
+	if err != nil {
+		return &influxdb.Error{
+			Op:  s.OpPrefix + opSetPassword,
+			Err: tracing.LogError(span, err),
+		}
+	}
+	return nil
+}
+
+// UpdateUserStatus marks user userID as active or inactive.
+// Returns the new user state after update.
+func (s *UserService) UpdateUserStatus(ctx context.Context, userID influxdb.ID, active bool) (*influxdb.User, error) {
+	status := influxdb.Inactive
+	if active {
+		status = influxdb.Active
+	}
+
+	u, err := s.UpdateUser(ctx, userID, influxdb.UserUpdate{Status: &status})
+	if err != nil {
+		return nil, &influxdb.Error{
+			Op:  s.OpPrefix + influxdb.OpUpdateUser,
+			Err: err,
+		}
+	}
+	return u, nil
 }
 
 func (s *UserService) FindPermissionForUser(ctx context.Context, uid influxdb.ID) (influxdb.PermissionSet, error) {
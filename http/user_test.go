@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path"
+	"strings"
 	"testing"
 
 	platform "github.com/influxdata/influxdb/v2"
@@ -87,3 +88,81 @@ func TestUserHandler_SettingPassword(t *testing.T) {
 		Do(h).
 		ExpectStatus(http.StatusNoContent)
 }
+
+func TestUserService_SetPassword(t *testing.T) {
+	const password = "s3cr3t-rotated-password"
+
+	be := NewMockUserBackend(t)
+	fakePassSVC := mock.NewPasswordsService()
+	fakePassSVC.SetPasswordFn = func(_ context.Context, id platform.ID, newPass string) error {
+		if id == platform.ID(1) && newPass == password {
+			return nil
+		}
+		return &platform.Error{
+			Code: platform.EInvalid,
+			Msg:  "password rejected",
+		}
+	}
+	be.PasswordsService = fakePassSVC
+	server := httptest.NewServer(NewUserHandler(zaptest.NewLogger(t), be))
+
+	client := UserService{
+		Client:   mustNewHTTPClient(t, server.URL, ""),
+		OpPrefix: "client/",
+	}
+
+	if err := client.SetPassword(context.Background(), platform.ID(1), password); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := client.SetPassword(context.Background(), platform.ID(2), password)
+	if got := platform.ErrorCode(err); got != platform.EInvalid {
+		t.Errorf("unexpected error code: got %q want %q", got, platform.EInvalid)
+	}
+	if got := platform.ErrorOp(err); got != "client/"+opSetPassword {
+		t.Errorf("unexpected error op: got %q", got)
+	}
+	if strings.Contains(err.Error(), password) {
+		t.Errorf("error leaked the password: %v", err)
+	}
+
+	server.Close()
+	err = client.SetPassword(context.Background(), platform.ID(1), password)
+	if err == nil {
+		t.Fatal("expected an error when the server is unavailable")
+	}
+	if strings.Contains(err.Error(), password) {
+		t.Errorf("error leaked the password: %v", err)
+	}
+}
+
+func TestUserService_UpdateUserStatus(t *testing.T) {
+	svc := newInMemKVSVC(t)
+	user := &platform.User{Name: "service-account"}
+	if err := svc.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	be := NewMockUserBackend(t)
+	be.UserService = svc
+	server := httptest.NewServer(NewUserHandler(zaptest.NewLogger(t), be))
+	defer server.Close()
+
+	client := UserService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	for _, tt := range []struct {
+		active bool
+		want   platform.Status
+	}{
+		{active: false, want: platform.Inactive},
+		{active: true, want: platform.Active},
+	} {
+		u, err := client.UpdateUserStatus(context.Background(), user.ID, tt.active)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if u.Status != tt.want {
+			t.Errorf("unexpected status: got %q want %q", u.Status, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	platform "github.com/influxdata/influxdb/v2"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/pkg/httpc"
 	itesting "github.com/influxdata/influxdb/v2/testing"
 	"go.uber.org/zap/zaptest"
 )
@@ -917,3 +919,33 @@ func initVariableService(f itesting.VariableFields, t *testing.T) (platform.Vari
 func TestVariableService(t *testing.T) {
 	itesting.VariableService(initVariableService, t, itesting.WithHTTPValidation())
 }
+
+func TestVariableService_FindVariableByID_etag(t *testing.T) {
+	const etag = `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, `{"id":"020f755c3c082000","orgID":"020f755c3c082001","name":"v","arguments":{"type":"constant","values":["a"]}}`)
+	}))
+	defer server.Close()
+
+	svc := &VariableService{Client: mustNewHTTPClient(t, server.URL, "")}
+
+	var tag string
+	ctx := httpc.WithETag(context.Background(), &tag)
+	v, err := svc.FindVariableByID(ctx, itesting.MustIDBase16("020f755c3c082000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "v" || tag != etag {
+		t.Fatalf("unexpected variable %+v with etag %q", v, tag)
+	}
+
+	if _, err := svc.FindVariableByID(ctx, v.ID); !errors.Is(err, httpc.ErrNotModified) {
+		t.Errorf("expected ErrNotModified, got %v", err)
+	}
+}
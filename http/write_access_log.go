@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// WithAccessLog logs every write through the logger of the handler, with
+// the method, path, status, response bytes, duration and request id of the
+// write and, once resolved, its org and bucket ids.
+func WithAccessLog() WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.accessLog = true
+	}
+}
+
+type accessLogCtxKey struct{}
+
+// accessLogEntry collects the ids the handler resolves while serving a
+// write.
+type accessLogEntry struct {
+	orgID    influxdb.ID
+	bucketID influxdb.ID
+}
+
+// setAccessLogOrg records the org of the write in ctx for the access log.
+func setAccessLogOrg(ctx context.Context, id influxdb.ID) {
+	if e, ok := ctx.Value(accessLogCtxKey{}).(*accessLogEntry); ok {
+		e.orgID = id
+	}
+}
+
+// setAccessLogBucket records the bucket of the write in ctx for the access
+// log.
+func setAccessLogBucket(ctx context.Context, id influxdb.ID) {
+	if e, ok := ctx.Value(accessLogCtxKey{}).(*accessLogEntry); ok {
+		e.bucketID = id
+	}
+}
+
+// withAccessLog wraps next with the access log when it is enabled.
+func (h *WriteHandler) withAccessLog(next http.Handler) http.Handler {
+	if !h.accessLog {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &accessLogEntry{}
+		srw := kithttp.NewStatusResponseWriter(w)
+		r = r.WithContext(context.WithValue(r.Context(), accessLogCtxKey{}, entry))
+
+		defer func(start time.Time) {
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", srw.Code()),
+				zap.Int("bytes", srw.ResponseBytes()),
+				zap.Duration("duration", time.Since(start)),
+				zap.String("request_id", middleware.GetReqID(r.Context())),
+			}
+			if entry.orgID.Valid() {
+				fields = append(fields, zap.Stringer("org_id", entry.orgID))
+			}
+			if entry.bucketID.Valid() {
+				fields = append(fields, zap.Stringer("bucket_id", entry.bucketID))
+			}
+			h.log.Info("Write", fields...)
+		}(time.Now())
+
+		next.ServeHTTP(srw, r)
+	})
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteHandler_handleWrite_accessLog(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name   string
+		opts   []WriteHandlerOption
+		bucket string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "write",
+			opts:   []WriteHandlerOption{WithAccessLog()},
+			bucket: bucketID,
+			fields: map[string]interface{}{
+				"method":     http.MethodPost,
+				"path":       prefixWrite,
+				"status":     int64(http.StatusNoContent),
+				"bytes":      int64(0),
+				"request_id": "req-1",
+				"org_id":     orgID,
+				"bucket_id":  bucketID,
+			},
+		},
+		{
+			name:   "unknown bucket",
+			opts:   []WriteHandlerOption{WithAccessLog()},
+			bucket: "other",
+			fields: map[string]interface{}{
+				"status":     int64(http.StatusNotFound),
+				"request_id": "req-1",
+				"org_id":     orgID,
+			},
+		},
+		{
+			name:   "disabled by default",
+			bucket: bucketID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(_ context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				if f.ID == nil || f.ID.String() != bucketID {
+					return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+				}
+				return testBucket(orgID, bucketID), nil
+			}
+
+			core, logs := observer.New(zapcore.InfoLevel)
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        &mock.PointsWriter{},
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zap.New(core), NewWriteBackend(zap.NewNop(), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+tt.bucket, strings.NewReader("m1 f1=1"))
+			r.Header.Set("X-Request-Id", "req-1")
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			entries := logs.FilterMessage("Write").All()
+			if tt.fields == nil {
+				if len(entries) != 0 {
+					t.Fatalf("unexpected access log entries: %v", entries)
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("unexpected access log entries: got %d want 1", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+			if _, ok := fields["duration"]; !ok {
+				t.Error("expected a duration field")
+			}
+			if _, ok := fields["bucket_id"]; ok && tt.fields["bucket_id"] == nil {
+				t.Errorf("unexpected bucket_id field: %v", fields["bucket_id"])
+			}
+			for k, want := range tt.fields {
+				if got := fields[k]; got != want {
+					t.Errorf("unexpected %s field: got %v want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
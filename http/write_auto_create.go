@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap"
+)
+
+// WithAutoCreateBucket creates the bucket of a write when the org has no
+// bucket of that name, with the given retention period, zero meaning
+// infinite retention. The writer must be allowed to create buckets in the
+// org. The bucket is only created once the write is validated and within the
+// rate limit of its org: dry runs and writes rejected before they are written
+// create none, while a write that then fails to be written leaves its bucket
+// created. It is meant for development and ephemeral test environments, as
+// writes with a mistyped bucket name then create a bucket rather than fail.
+func WithAutoCreateBucket(retention time.Duration) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.autoCreateBucket = true
+		w.autoCreateRetention = retention
+	}
+}
+
+// findBucketToWrite finds the bucket named bucket in the org. When it does
+// not exist and the handler auto-creates buckets, it returns the bucket to
+// create, without an ID, which createBucketToWrite creates once the write is
+// validated and rate limited, so that dry runs and rejected writes create no
+// bucket. Points are parsed into the bucket to create as into the bucket of
+// an invalid ID.
+func (h *WriteHandler) findBucketToWrite(ctx context.Context, auth influxdb.Authorizer, orgID influxdb.ID, bucket string) (*influxdb.Bucket, error) {
+	b, err := h.findBucket(ctx, orgID, bucket)
+	if !h.autoCreateBucket || influxdb.ErrorCode(err) != influxdb.ENotFound || bucket == "" {
+		return b, err
+	}
+
+	p, err := influxdb.NewPermission(influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInternal,
+			Op:   opWriteHandler,
+			Msg:  "unable to create permission for buckets",
+			Err:  err,
+		}
+	}
+	if pset, err := auth.PermissionSet(); err != nil || !pset.Allowed(*p) {
+		return nil, &influxdb.Error{
+			Code: influxdb.EForbidden,
+			Op:   opWriteHandler,
+			Msg:  "insufficient permissions to create bucket",
+			Err:  err,
+		}
+	}
+
+	return &influxdb.Bucket{
+		OrgID:           orgID,
+		Name:            bucket,
+		RetentionPeriod: h.autoCreateRetention,
+	}, nil
+}
+
+// createBucketToWrite creates b, a bucket to create from findBucketToWrite,
+// and renames the points parsed into it after the created bucket. Buckets
+// that exist are returned as is.
+func (h *WriteHandler) createBucketToWrite(ctx context.Context, b *influxdb.Bucket, points []models.Point) (*influxdb.Bucket, error) {
+	if b.ID.Valid() {
+		return b, nil
+	}
+
+	if err := h.BucketService.CreateBucket(ctx, b); err != nil {
+		// a concurrent write may have created the bucket first.
+		if influxdb.ErrorCode(err) != influxdb.EConflict {
+			return nil, err
+		}
+		if b, err = h.findBucket(ctx, b.OrgID, b.Name); err != nil {
+			return nil, err
+		}
+	} else {
+		h.log.Info("Created bucket for write", zap.String("bucket", b.Name), zap.Stringer("org_id", b.OrgID))
+	}
+
+	encoded := tsdb.EncodeName(b.OrgID, b.ID)
+	for _, p := range points {
+		p.SetName(string(encoded[:]))
+	}
+	return b, nil
+}
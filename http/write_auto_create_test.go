@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_autoCreateBucket(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	oid := influxtesting.MustIDBase16(orgID)
+	orgWritePermission := &influxdb.Authorization{
+		OrgID:  oid,
+		Status: influxdb.Active,
+		Permissions: []influxdb.Permission{{
+			Action:   influxdb.WriteAction,
+			Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &oid},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		opts      []WriteHandlerOption
+		auth      influxdb.Authorizer
+		query     string
+		body      string
+		createErr error
+		code      int
+		created   bool
+		points    int
+	}{
+		{
+			name:    "missing bucket is created",
+			opts:    []WriteHandlerOption{WithAutoCreateBucket(time.Hour)},
+			auth:    orgWritePermission,
+			code:    http.StatusNoContent,
+			created: true,
+			points:  1,
+		},
+		{
+			name:  "dry run creates no bucket",
+			opts:  []WriteHandlerOption{WithAutoCreateBucket(time.Hour)},
+			auth:  orgWritePermission,
+			query: "&dry-run=true",
+			code:  http.StatusOK,
+		},
+		{
+			name: "malformed write creates no bucket",
+			opts: []WriteHandlerOption{WithAutoCreateBucket(time.Hour)},
+			auth: orgWritePermission,
+			body: "m1 f1=",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "rate limited write creates no bucket",
+			opts: []WriteHandlerOption{WithAutoCreateBucket(time.Hour), WithWriteRateLimiter(denyRateLimiter{})},
+			auth: orgWritePermission,
+			code: http.StatusTooManyRequests,
+		},
+		{
+			name: "disabled by default",
+			auth: orgWritePermission,
+			code: http.StatusNotFound,
+		},
+		{
+			name: "writer not allowed to create buckets",
+			opts: []WriteHandlerOption{WithAutoCreateBucket(time.Hour)},
+			auth: bucketWritePermission(orgID, bucketID),
+			code: http.StatusForbidden,
+		},
+		{
+			name:      "bucket created concurrently",
+			opts:      []WriteHandlerOption{WithAutoCreateBucket(time.Hour)},
+			auth:      orgWritePermission,
+			createErr: &influxdb.Error{Code: influxdb.EConflict, Msg: "bucket with name telegraf already exists"},
+			code:      http.StatusNoContent,
+			created:   true,
+			points:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+
+			var created *influxdb.Bucket
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(_ context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				if created == nil {
+					return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+				}
+				return created, nil
+			}
+			buckets.CreateBucketFn = func(_ context.Context, b *influxdb.Bucket) error {
+				b.ID = influxtesting.MustIDBase16(bucketID)
+				created = b
+				return tt.createErr
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, tt.auth)
+
+			body := tt.body
+			if body == "" {
+				body = "m1 f1=1"
+			}
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket=telegraf"+tt.query, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := created != nil; got != tt.created {
+				t.Fatalf("unexpected bucket creation: got %v want %v", got, tt.created)
+			}
+			if created != nil {
+				if created.Name != "telegraf" || created.OrgID != oid || created.RetentionPeriod != time.Hour {
+					t.Errorf("unexpected bucket created: %+v", created)
+				}
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+			// points parsed before the bucket was created are written to it.
+			encoded := tsdb.EncodeName(oid, influxtesting.MustIDBase16(bucketID))
+			for _, p := range pw.Points {
+				if got, want := string(p.Name()), string(encoded[:]); got != want {
+					t.Errorf("unexpected point name: got %q want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+// denyRateLimiter rate limits every write.
+type denyRateLimiter struct{}
+
+func (denyRateLimiter) ReserveWrite(context.Context, influxdb.ID, int, int) (time.Duration, bool) {
+	return time.Second, false
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// busyRetryAfter is how long clients are asked to wait before retrying a
+// write refused by a busy points writer.
+const busyRetryAfter = time.Second
+
+// writeToPointsWriter writes points to the PointsWriter of the handler. A
+// storage.TryPointsWriter is written to without blocking, so that a busy
+// writer fails the write with a 503 and a Retry-After header rather than
+// holding the request.
+func (h *WriteHandler) writeToPointsWriter(ctx context.Context, points []models.Point, opts storage.WriteOptions) error {
+	if tw, ok := h.PointsWriter.(storage.TryPointsWriter); ok {
+		return tw.TryWritePointsWithOptions(ctx, points, opts)
+	}
+	return storage.WritePointsWithOptions(ctx, h.PointsWriter, points, opts)
+}
+
+// setBusyRetryAfter sets the Retry-After header of w when err is that of a
+// busy points writer.
+func setBusyRetryAfter(w http.ResponseWriter, err error) {
+	if errors.Is(err, storage.ErrPointsWriterBusy) {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(busyRetryAfter)))
+	}
+}
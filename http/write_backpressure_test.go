@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"go.uber.org/zap/zaptest"
+)
+
+// queuePointsWriter queues the points written to it, refusing writes once
+// its queue is full. It records the options of the last write it accepted.
+type queuePointsWriter struct {
+	queue chan []models.Point
+	opts  storage.WriteOptions
+}
+
+func (w *queuePointsWriter) WritePoints(_ context.Context, points []models.Point) error {
+	w.queue <- points
+	return nil
+}
+
+func (w *queuePointsWriter) TryWritePoints(ctx context.Context, points []models.Point) error {
+	return w.TryWritePointsWithOptions(ctx, points, storage.WriteOptions{})
+}
+
+func (w *queuePointsWriter) TryWritePointsWithOptions(_ context.Context, points []models.Point, opts storage.WriteOptions) error {
+	select {
+	case w.queue <- points:
+		w.opts = opts
+		return nil
+	default:
+		return storage.ErrPointsWriterBusy
+	}
+}
+
+func TestWriteHandler_busyPointsWriter(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	pw := &queuePointsWriter{queue: make(chan []models.Point, 1)}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	write := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID+"&precision=s", strings.NewReader("m1 f1=1"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := write(); w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status code: got %d want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+	if want := (storage.WriteOptions{Precision: "s"}); pw.opts != want {
+		t.Errorf("unexpected write options: got %+v want %+v", pw.opts, want)
+	}
+
+	// the queue is full until it is drained.
+	w := write()
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status code: got %d want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("unexpected Retry-After: got %q want %q", got, "1")
+	}
+
+	<-pw.queue
+	if w := write(); w.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code once drained: got %d want %d: %s", w.Code, http.StatusNoContent, w.Body.String())
+	}
+}
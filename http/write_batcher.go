@@ -0,0 +1,218 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	// DefaultBatchSizeBytes is the size of the buffered line protocol at which
+	// a BatchWriter flushes a bucket.
+	DefaultBatchSizeBytes = 1 << 20
+	// DefaultFlushInterval is how often a BatchWriter flushes all buckets.
+	DefaultFlushInterval = time.Second
+	// defaultBatchErrorsBuffer is the number of background flush errors kept
+	// until they are read from the Errors channel.
+	defaultBatchErrorsBuffer = 16
+)
+
+// ErrBatchWriterClosed is returned when writing to a closed BatchWriter.
+var ErrBatchWriterClosed = errors.New("batch writer is closed")
+
+// BatchWriter buffers line protocol per org and bucket and writes it through
+// a WriteService once a size threshold or a flush interval is reached.
+type BatchWriter struct {
+	svc       influxdb.WriteService
+	maxBytes  int
+	interval  time.Duration
+	errBuffer int
+
+	mu      sync.Mutex
+	buffers map[batchDest]*bytes.Buffer
+	closed  bool
+
+	errs chan error
+	done chan struct{}
+	wg   sync.WaitGroup
+	// flushCtx is the context of the background flushes, canceled by Close
+	// once its own context is done.
+	flushCtx    context.Context
+	cancelFlush context.CancelFunc
+}
+
+type batchDest struct {
+	orgID, bucketID influxdb.ID
+}
+
+// BatchWriterOption is a functional option for a *BatchWriter.
+type BatchWriterOption func(*BatchWriter)
+
+// WithBatchSizeBytes sets the buffered size at which a bucket is flushed.
+func WithBatchSizeBytes(n int) BatchWriterOption {
+	return func(b *BatchWriter) {
+		b.maxBytes = n
+	}
+}
+
+// WithFlushInterval sets how often all buffered lines are flushed in the
+// background. A non-positive interval disables background flushes.
+func WithFlushInterval(d time.Duration) BatchWriterOption {
+	return func(b *BatchWriter) {
+		b.interval = d
+	}
+}
+
+// WithBatchErrorsBuffer sets how many background flush errors are kept until
+// they are read from Errors. Errors past the buffer are dropped.
+func WithBatchErrorsBuffer(n int) BatchWriterOption {
+	return func(b *BatchWriter) {
+		b.errBuffer = n
+	}
+}
+
+// NewBatchWriter returns a BatchWriter that writes through the WriteService.
+// The caller must Close the BatchWriter to write any remaining lines.
+func (s *WriteService) NewBatchWriter(opts ...BatchWriterOption) *BatchWriter {
+	return NewBatchWriter(s, opts...)
+}
+
+// NewBatchWriter returns a BatchWriter that writes through svc.
+// The caller must Close the BatchWriter to write any remaining lines.
+func NewBatchWriter(svc influxdb.WriteService, opts ...BatchWriterOption) *BatchWriter {
+	b := &BatchWriter{
+		svc:       svc,
+		maxBytes:  DefaultBatchSizeBytes,
+		interval:  DefaultFlushInterval,
+		errBuffer: defaultBatchErrorsBuffer,
+		buffers:   make(map[batchDest]*bytes.Buffer),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.errs = make(chan error, b.errBuffer)
+	b.flushCtx, b.cancelFlush = context.WithCancel(context.Background())
+
+	if b.interval > 0 {
+		b.wg.Add(1)
+		go b.flushPeriodically()
+	}
+	return b
+}
+
+// WriteRecord buffers a single line of line protocol for a bucket. The bucket
+// is flushed before returning once its buffer reaches the batch size, in
+// which case the error of that flush is returned.
+func (b *BatchWriter) WriteRecord(ctx context.Context, orgID, bucketID influxdb.ID, line string) error {
+	dest := batchDest{orgID: orgID, bucketID: bucketID}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBatchWriterClosed
+	}
+	buf, ok := b.buffers[dest]
+	if !ok {
+		buf = new(bytes.Buffer)
+		b.buffers[dest] = buf
+	}
+	buf.WriteString(line)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	var full *bytes.Buffer
+	if buf.Len() >= b.maxBytes {
+		full = buf
+		delete(b.buffers, dest)
+	}
+	b.mu.Unlock()
+
+	if full == nil {
+		return nil
+	}
+	return b.svc.Write(ctx, orgID, bucketID, full)
+}
+
+// Flush writes the lines buffered for every bucket. It returns the first
+// error encountered, but attempts to flush every bucket.
+func (b *BatchWriter) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	buffers := b.buffers
+	b.buffers = make(map[batchDest]*bytes.Buffer)
+	b.mu.Unlock()
+
+	var firstErr error
+	for dest, buf := range buffers {
+		if err := b.svc.Write(ctx, dest.orgID, dest.bucketID, buf); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close stops the background flushes and writes any lines that remain.
+// A background flush in progress is waited for until ctx is done, at which
+// point it is canceled. The Errors channel is closed once Close returns.
+func (b *BatchWriter) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	stopped := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		b.cancelFlush()
+		<-stopped
+	}
+	b.cancelFlush()
+
+	err := b.Flush(ctx)
+	close(b.errs)
+	return err
+}
+
+// Errors returns the channel on which errors from background flushes are
+// reported. It buffers as many errors as set by WithBatchErrorsBuffer, and
+// errors reported while it is full are dropped without notice, so it should
+// be read as long as the BatchWriter is used.
+func (b *BatchWriter) Errors() <-chan error {
+	return b.errs
+}
+
+func (b *BatchWriter) flushPeriodically() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if err := b.Flush(b.flushCtx); err != nil {
+				// the error is dropped when the buffer of Errors is full.
+				select {
+				case b.errs <- err:
+				default:
+				}
+			}
+		}
+	}
+}
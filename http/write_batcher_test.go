@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+)
+
+type recordedWrite struct {
+	org, bucket influxdb.ID
+	data        string
+}
+
+func newRecordingWriteService(err error) (*mock.WriteService, func() []recordedWrite) {
+	var (
+		mu     sync.Mutex
+		writes []recordedWrite
+	)
+	svc := &mock.WriteService{
+		WriteF: func(_ context.Context, org, bucket influxdb.ID, r io.Reader) error {
+			data, _ := ioutil.ReadAll(r)
+			mu.Lock()
+			writes = append(writes, recordedWrite{org: org, bucket: bucket, data: string(data)})
+			mu.Unlock()
+			return err
+		},
+	}
+	return svc, func() []recordedWrite {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedWrite{}, writes...)
+	}
+}
+
+func TestBatchWriter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("flushes a bucket at the batch size", func(t *testing.T) {
+		svc, writes := newRecordingWriteService(nil)
+		b := NewBatchWriter(svc, WithBatchSizeBytes(16), WithFlushInterval(0))
+
+		for _, line := range []string{"m f=1", "m f=2", "m f=3"} {
+			if err := b.WriteRecord(ctx, 1, 2, line); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := b.WriteRecord(ctx, 1, 3, "m f=4\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		got := writes()
+		if len(got) != 1 || got[0] != (recordedWrite{org: 1, bucket: 2, data: "m f=1\nm f=2\nm f=3\n"}) {
+			t.Fatalf("unexpected writes: %+v", got)
+		}
+
+		if err := b.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		got = writes()
+		if len(got) != 2 || got[1] != (recordedWrite{org: 1, bucket: 3, data: "m f=4\n"}) {
+			t.Fatalf("unexpected writes after close: %+v", got)
+		}
+
+		if err := b.WriteRecord(ctx, 1, 2, "m f=5"); err != ErrBatchWriterClosed {
+			t.Errorf("unexpected error writing after close: %v", err)
+		}
+	})
+
+	t.Run("flush writes every bucket", func(t *testing.T) {
+		svc, writes := newRecordingWriteService(nil)
+		b := NewBatchWriter(svc, WithFlushInterval(0))
+		defer b.Close(ctx)
+
+		_ = b.WriteRecord(ctx, 1, 2, "m f=1")
+		_ = b.WriteRecord(ctx, 1, 3, "m f=2")
+		if err := b.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := writes(); len(got) != 2 {
+			t.Fatalf("unexpected writes: %+v", got)
+		}
+		if err := b.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := writes(); len(got) != 2 {
+			t.Fatalf("expected an empty flush to write nothing: %+v", got)
+		}
+	})
+
+	t.Run("reports background flush errors", func(t *testing.T) {
+		errWrite := errors.New("write failed")
+		svc, _ := newRecordingWriteService(errWrite)
+		b := NewBatchWriter(svc, WithFlushInterval(time.Millisecond))
+
+		if err := b.WriteRecord(ctx, 1, 2, "m f=1"); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case err := <-b.Errors():
+			if err != errWrite {
+				t.Errorf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the background flush")
+		}
+
+		if err := b.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		for range b.Errors() {
+		}
+	})
+
+	t.Run("close cancels a stalled background flush", func(t *testing.T) {
+		flushing := make(chan struct{}, 1)
+		svc := &mock.WriteService{
+			WriteF: func(ctx context.Context, _, _ influxdb.ID, _ io.Reader) error {
+				select {
+				case flushing <- struct{}{}:
+				default:
+				}
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+		b := NewBatchWriter(svc, WithFlushInterval(time.Millisecond))
+
+		if err := b.WriteRecord(ctx, 1, 2, "m f=1"); err != nil {
+			t.Fatal(err)
+		}
+		<-flushing
+
+		closeCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			_ = b.Close(closeCtx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("close did not return once its context was done")
+		}
+		for range b.Errors() {
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const opWriteBatches = "http/WriteBatches"
+
+// WriteBatchesResult reports how the points of WriteBatches were written.
+type WriteBatchesResult struct {
+	// Batches is the number of batches the points were split into.
+	Batches int
+	// Succeeded is the number of batches written.
+	Succeeded int
+}
+
+// WriteBatches writes points to the bucket in batches of line protocol under
+// maxBytes, DefaultBatchSizeBytes when not positive, so that a large slice of
+// points does not exceed the body limit of the server. The batches are
+// written one after the other. A failed batch does not stop the next ones
+// from being written: the failures are reported together once every batch
+// was attempted, unless ctx is done, with the error code of the first
+// failure. Once ctx is done the batches left are not written, and the error
+// wrapping that of ctx reports how many batches were written before it. A
+// point that does not fit in a batch on its own fails the write before
+// anything is written.
+func (s *WriteService) WriteBatches(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point, maxBytes int) (WriteBatchesResult, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultBatchSizeBytes
+	}
+	batches, err := splitBatches(points, s.Precision, maxBytes)
+	if err != nil {
+		return WriteBatchesResult{}, err
+	}
+
+	res := WriteBatchesResult{Batches: len(batches)}
+	var (
+		failures []string
+		code     string
+	)
+	for i, batch := range batches {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res, batchesCanceledError(res, ctxErr)
+		}
+		if err := s.Write(ctx, orgID, bucketID, bytes.NewReader(batch)); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return res, batchesCanceledError(res, ctxErr)
+			}
+			if code == "" {
+				code = influxdb.ErrorCode(err)
+			}
+			failures = append(failures, fmt.Sprintf("batch %d: %v", i+1, err))
+			continue
+		}
+		res.Succeeded++
+	}
+
+	if len(failures) > 0 {
+		return res, &influxdb.Error{
+			Code: code,
+			Op:   opWriteBatches,
+			Msg:  fmt.Sprintf("%d of %d batches failed", len(failures), len(batches)),
+			Err:  errors.New(strings.Join(failures, "; ")),
+		}
+	}
+	return res, nil
+}
+
+// batchesCanceledError returns the error of batches whose writing stopped
+// when their context was done with err.
+func batchesCanceledError(res WriteBatchesResult, err error) error {
+	e := &influxdb.Error{
+		Op:  opWriteBatches,
+		Msg: fmt.Sprintf("write stopped after %d of %d batches were written", res.Succeeded, res.Batches),
+		Err: err,
+	}
+	if err == context.DeadlineExceeded {
+		e.Code = influxdb.ETimeout
+	}
+	return e
+}
+
+// splitBatches encodes points as lines of line protocol in precision and
+// splits them into batches of at most maxBytes.
+func splitBatches(points []models.Point, precision string, maxBytes int) ([][]byte, error) {
+	var (
+		batches [][]byte
+		buf     []byte
+	)
+	for i, p := range points {
+		line := p.PrecisionString(precision) + "\n"
+		if len(line) > maxBytes {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteBatches,
+				Msg:  fmt.Sprintf("point %d is %d bytes, over the batch size of %d bytes", i, len(line), maxBytes),
+			}
+		}
+		if len(buf)+len(line) > maxBytes {
+			batches = append(batches, buf)
+			buf = nil
+		}
+		buf = append(buf, line...)
+	}
+	if len(buf) > 0 {
+		batches = append(batches, buf)
+	}
+	return batches, nil
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+func TestWriteService_WriteBatches(t *testing.T) {
+	var points []models.Point
+	for i := 0; i < 10; i++ {
+		p, err := models.NewPoint("cpu", models.NewTags(map[string]string{"host": "a"}), models.Fields{"usage": int64(i)}, time.Unix(int64(i), 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, p)
+	}
+	// each line is "cpu,host=a usage=0i 0\n", 22 bytes long.
+	const lineSize = 22
+
+	tests := []struct {
+		name      string
+		precision string
+		maxBytes  int
+		fail      map[int]bool
+		result    WriteBatchesResult
+		code      string
+		bodies    []string
+	}{
+		{
+			name:     "split in batches",
+			maxBytes: 4*lineSize + 1,
+			result:   WriteBatchesResult{Batches: 3, Succeeded: 3},
+			bodies: []string{
+				"cpu,host=a usage=0i 0\ncpu,host=a usage=1i 1\ncpu,host=a usage=2i 2\ncpu,host=a usage=3i 3\n",
+				"cpu,host=a usage=4i 4\ncpu,host=a usage=5i 5\ncpu,host=a usage=6i 6\ncpu,host=a usage=7i 7\n",
+				"cpu,host=a usage=8i 8\ncpu,host=a usage=9i 9\n",
+			},
+		},
+		{
+			name:   "single batch by default",
+			result: WriteBatchesResult{Batches: 1, Succeeded: 1},
+		},
+		{
+			name:     "failed batches are reported",
+			maxBytes: 5 * lineSize,
+			fail:     map[int]bool{0: true},
+			result:   WriteBatchesResult{Batches: 2, Succeeded: 1},
+			code:     influxdb.EInvalid,
+		},
+		{
+			name:     "oversized point",
+			maxBytes: lineSize - 1,
+			code:     influxdb.EInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(b))
+				if tt.fail[len(bodies)-1] {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"code":"invalid","message":"bad batch"}`))
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			s := (&WriteService{Addr: ts.URL, Precision: "s"}).WithCompression(0)
+			res, err := s.WriteBatches(context.Background(), 1, 2, points, tt.maxBytes)
+			if code := influxdb.ErrorCode(err); code != tt.code {
+				t.Fatalf("unexpected error code: got %q want %q: %v", code, tt.code, err)
+			}
+			if res != tt.result {
+				t.Errorf("unexpected result: got %+v want %+v", res, tt.result)
+			}
+			if len(bodies) != tt.result.Batches {
+				t.Errorf("unexpected number of writes: got %d want %d", len(bodies), tt.result.Batches)
+			}
+			for i, want := range tt.bodies {
+				if bodies[i] != want {
+					t.Errorf("unexpected batch %d:\ngot  %q\nwant %q", i, bodies[i], want)
+				}
+			}
+			if tt.fail != nil && !strings.Contains(err.Error(), "batch 1: ") {
+				t.Errorf("expected the failed batch to be reported: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteService_WriteBatches_Deadline(t *testing.T) {
+	var points []models.Point
+	for i := 0; i < 3; i++ {
+		p, err := models.NewPoint("cpu", nil, models.Fields{"usage": int64(i)}, time.Unix(int64(i), 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		points = append(points, p)
+	}
+	// each line is "cpu usage=0i 0\n", 15 bytes long.
+	const lineSize = 15
+
+	// the first batch is written in time, the second outlives the deadline.
+	var writes int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&writes, 1) > 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	s := (&WriteService{Addr: ts.URL, Precision: "s"}).WithCompression(0)
+	start := time.Now()
+	res, err := s.WriteBatches(ctx, 1, 2, points, lineSize)
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("expected the write to stop at the deadline, took %s", took)
+	}
+
+	if code := influxdb.ErrorCode(err); code != influxdb.ETimeout {
+		t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.ETimeout, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the error to wrap the deadline: %v", err)
+	}
+	if !strings.Contains(err.Error(), "after 1 of 3 batches") {
+		t.Errorf("expected the completed batches to be reported: %v", err)
+	}
+	if want := (WriteBatchesResult{Batches: 3, Succeeded: 1}); res != want {
+		t.Errorf("unexpected result: got %+v want %+v", res, want)
+	}
+	if got := atomic.LoadInt32(&writes); got != 2 {
+		t.Errorf("expected the batch after the deadline not to be written: got %d writes", got)
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/influxdata/influxdb/v2"
+)
+
+// WithCapacityCheck rejects writes as unavailable, with a 503, while check
+// returns an error, such as when the storage engine is out of disk space or
+// read-only. check is called at the start of every write, before the body is
+// read, so it must be cheap.
+func WithCapacityCheck(check func() error) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.capacityCheck = check
+	}
+}
+
+// checkCapacity returns an unavailable error when the capacity check of the
+// handler fails.
+func (h *WriteHandler) checkCapacity() error {
+	if h.capacityCheck == nil {
+		return nil
+	}
+	if err := h.capacityCheck(); err != nil {
+		return &influxdb.Error{
+			Code: influxdb.EUnavailable,
+			Op:   opWriteHandler,
+			Msg:  "storage is unable to accept writes",
+			Err:  err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_capacityCheck(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name   string
+		opts   []WriteHandlerOption
+		code   int
+		body   string
+		points int
+	}{
+		{
+			name:   "capacity available",
+			opts:   []WriteHandlerOption{WithCapacityCheck(func() error { return nil })},
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name: "out of disk space",
+			opts: []WriteHandlerOption{WithCapacityCheck(func() error { return errors.New("disk full") })},
+			code: http.StatusServiceUnavailable,
+			body: `{"code":"unavailable","message":"storage is unable to accept writes: disk full"}`,
+		},
+		{
+			name:   "no check by default",
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d", got, tt.code)
+			}
+			if tt.body != "" {
+				if got := w.Body.String(); got != tt.body {
+					t.Errorf("unexpected body: got %s want %s", got, tt.body)
+				}
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+		})
+	}
+}
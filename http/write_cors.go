@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteCORS is the cross-origin policy of the write routes, letting browser
+// apps write to them without a proxy.
+type WriteCORS struct {
+	// AllowedOrigins are the origins allowed to write, such as
+	// "https://app.example.com". "*" allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed by preflight requests, POST when
+	// empty.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed by preflight requests,
+	// the headers the write routes read when empty.
+	AllowedHeaders []string
+	// MaxAge is how long browsers may cache the response to a preflight
+	// request, as they default to when zero.
+	MaxAge time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodPost}
+	defaultCORSHeaders = []string{
+		"Accept",
+		"Authorization",
+		"Content-Encoding",
+		"Content-Type",
+		headerIdempotencyKey,
+		headerDryRun,
+	}
+	// corsExposedHeaders are the response headers of a write that scripts
+	// of other origins may read.
+	corsExposedHeaders = []string{
+		"Retry-After",
+		"X-Request-Id",
+		headerResolvedOrgID,
+		headerResolvedBucketID,
+	}
+)
+
+// WithCORS sets the cross-origin policy of the write and batch routes to
+// cors, answering their OPTIONS preflight requests. Responses to writes from
+// an allowed origin carry the CORS headers, whether they succeed or not, and
+// preflight requests from other origins are answered without them, so that
+// browsers deny the write. Without this option the routes send no CORS
+// headers.
+func WithCORS(cors WriteCORS) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.cors = &cors
+	}
+}
+
+// allowsOrigin returns whether origin may write.
+func (c *WriteCORS) allowsOrigin(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCORS sets the CORS headers of the responses of next to requests from
+// allowed origins.
+func (h *WriteHandler) withCORS(next http.Handler) http.Handler {
+	if h.cors == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && h.cors.allowsOrigin(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+			w.Header().Add("Vary", "Origin")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleCORSPreflight answers the OPTIONS preflight requests of the write
+// routes.
+func (h *WriteHandler) handleCORSPreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" || !h.cors.allowsOrigin(origin) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	methods, headers := h.cors.AllowedMethods, h.cors.AllowedHeaders
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	if h.cors.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(h.cors.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
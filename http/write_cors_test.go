@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_cors(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+		origin   = "https://app.example.com"
+	)
+	writePath := prefixWrite + "?org=" + orgID + "&bucket=" + bucketID
+
+	tests := []struct {
+		name    string
+		cors    *WriteCORS
+		method  string
+		path    string
+		origin  string
+		body    string
+		code    int
+		headers map[string]string
+	}{
+		{
+			name:   "preflight",
+			cors:   &WriteCORS{AllowedOrigins: []string{origin}, MaxAge: time.Hour},
+			method: http.MethodOptions,
+			path:   writePath,
+			origin: origin,
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  origin,
+				"Access-Control-Allow-Methods": "POST",
+				"Access-Control-Allow-Headers": "Accept, Authorization, Content-Encoding, Content-Type, Idempotency-Key, X-Influxdb-Dry-Run",
+				"Access-Control-Max-Age":       "3600",
+			},
+		},
+		{
+			name:   "batch preflight with any origin",
+			cors:   &WriteCORS{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST", "OPTIONS"}, AllowedHeaders: []string{"Authorization"}},
+			method: http.MethodOptions,
+			path:   prefixWriteBatch,
+			origin: origin,
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  origin,
+				"Access-Control-Allow-Methods": "POST, OPTIONS",
+				"Access-Control-Allow-Headers": "Authorization",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+		{
+			name:   "preflight from another origin",
+			cors:   &WriteCORS{AllowedOrigins: []string{origin}},
+			method: http.MethodOptions,
+			path:   writePath,
+			origin: "https://evil.example.com",
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:   "write",
+			cors:   &WriteCORS{AllowedOrigins: []string{origin}},
+			method: http.MethodPost,
+			path:   writePath,
+			origin: origin,
+			body:   "m1 f1=1",
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin":   origin,
+				"Access-Control-Expose-Headers": "Retry-After, X-Request-Id, X-Influx-Org-ID, X-Influx-Bucket-ID",
+			},
+		},
+		{
+			name:   "failed write",
+			cors:   &WriteCORS{AllowedOrigins: []string{origin}},
+			method: http.MethodPost,
+			path:   writePath,
+			origin: origin,
+			body:   "m1 f1=",
+			code:   http.StatusBadRequest,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": origin,
+			},
+		},
+		{
+			name:   "no cors by default",
+			method: http.MethodPost,
+			path:   writePath,
+			origin: origin,
+			body:   "m1 f1=1",
+			code:   http.StatusNoContent,
+			headers: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        &mock.PointsWriter{},
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			var opts []WriteHandlerOption
+			if tt.cors != nil {
+				opts = append(opts, WithCORS(*tt.cors))
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(tt.method, "http://localhost:9999"+tt.path, strings.NewReader(tt.body))
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			for k, want := range tt.headers {
+				if got := w.Header().Get(k); got != want {
+					t.Errorf("unexpected %s header: got %q want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	kitio "github.com/influxdata/influxdb/v2/kit/io"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/escape"
+)
+
+// The query parameters mapping the columns of a CSV write to points. The
+// first row of the CSV names the columns.
+const (
+	// paramCSVMeasurement is the column holding the measurement of a row.
+	paramCSVMeasurement = "csvMeasurement"
+	// paramCSVTags are the comma separated columns holding tags.
+	paramCSVTags = "csvTags"
+	// paramCSVFields are the comma separated columns holding fields,
+	// defaulting to every column that is not the measurement, a tag or the
+	// timestamp.
+	paramCSVFields = "csvFields"
+	// paramCSVTimestamp is the column holding the timestamp of a row, either
+	// an integer in the precision of the write or an RFC3339 time. Rows
+	// without one are written at the time of the write.
+	paramCSVTimestamp = "csvTimestamp"
+
+	opWriteCSV = "http/writeCSV"
+)
+
+// csvMapping maps the columns of a CSV write to the parts of its points.
+type csvMapping struct {
+	Measurement string
+	Tags        []string
+	Fields      []string
+	Timestamp   string
+}
+
+// isCSVWrite reports whether the body of r is CSV rather than line protocol.
+func isCSVWrite(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mt == "text/csv" || mt == "application/csv")
+}
+
+// decodeCSVMapping decodes the column mapping of a CSV write from the query
+// parameters of r.
+func decodeCSVMapping(r *http.Request) (*csvMapping, error) {
+	qp := r.URL.Query()
+	m := &csvMapping{
+		Measurement: qp.Get(paramCSVMeasurement),
+		Tags:        splitColumns(qp.Get(paramCSVTags)),
+		Fields:      splitColumns(qp.Get(paramCSVFields)),
+		Timestamp:   qp.Get(paramCSVTimestamp),
+	}
+	if m.Measurement == "" {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteCSV,
+			Msg:  fmt.Sprintf("%s is required for CSV writes", paramCSVMeasurement),
+		}
+	}
+	return m, nil
+}
+
+func splitColumns(v string) []string {
+	if v == "" {
+		return nil
+	}
+	cols := strings.Split(v, ",")
+	for i := range cols {
+		cols[i] = strings.TrimSpace(cols[i])
+	}
+	return cols
+}
+
+// csvColumns are the indexes of the mapped columns in the header of a CSV.
+type csvColumns struct {
+	names       []string
+	measurement int
+	tags        []int
+	fields      []int
+	timestamp   int
+}
+
+func (m *csvMapping) columns(header []string) (*csvColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	find := func(name string) (int, error) {
+		i, ok := index[name]
+		if !ok {
+			return 0, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteCSV,
+				Msg:  fmt.Sprintf("column %q is not in the CSV header", name),
+			}
+		}
+		return i, nil
+	}
+
+	cols := &csvColumns{names: header, timestamp: -1}
+	var err error
+	if cols.measurement, err = find(m.Measurement); err != nil {
+		return nil, err
+	}
+	if m.Timestamp != "" {
+		if cols.timestamp, err = find(m.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	mapped := map[int]bool{cols.measurement: true, cols.timestamp: true}
+	for _, name := range m.Tags {
+		i, err := find(name)
+		if err != nil {
+			return nil, err
+		}
+		cols.tags = append(cols.tags, i)
+		mapped[i] = true
+	}
+	if len(m.Fields) == 0 {
+		for i := range header {
+			if !mapped[i] {
+				cols.fields = append(cols.fields, i)
+			}
+		}
+		return cols, nil
+	}
+	for _, name := range m.Fields {
+		i, err := find(name)
+		if err != nil {
+			return nil, err
+		}
+		cols.fields = append(cols.fields, i)
+	}
+	return cols, nil
+}
+
+// convertCSV reads the CSV of rc and returns it as line protocol, with the
+// timestamps in precision. Field types are inferred from their values:
+// integers, floats and the booleans true and false, with anything else a
+// string. Empty tags and fields are omitted.
+func (m *csvMapping) convertCSV(rc io.ReadCloser, precision string) (_ io.ReadCloser, err error) {
+	defer func() {
+		// a body over the size limit reads as truncated until it is closed.
+		if cerr := rc.Close(); errors.Is(cerr, kitio.ErrReadLimitExceeded) {
+			err = &influxdb.Error{
+				Code: influxdb.ETooLarge,
+				Op:   opWriteCSV,
+				Msg:  msgUnableToReadData,
+				Err:  ErrMaxBatchSizeExceeded,
+			}
+		} else if cerr != nil && err == nil {
+			err = csvError(cerr)
+		}
+	}()
+
+	r := csv.NewReader(rc)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return ioutil.NopCloser(&bytes.Buffer{}), nil
+	} else if err != nil {
+		return nil, csvError(err)
+	}
+	// the header is reused by the reader.
+	header = append([]string(nil), header...)
+	cols, err := m.columns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for row := 2; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, csvError(err)
+		}
+		if err := cols.appendLine(&buf, record, precision); err != nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteCSV,
+				Msg:  fmt.Sprintf("row %d", row),
+				Err:  err,
+			}
+		}
+	}
+	return ioutil.NopCloser(&buf), nil
+}
+
+func csvError(err error) error {
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   opWriteCSV,
+		Msg:  "unable to parse CSV",
+		Err:  err,
+	}
+}
+
+// appendLine appends the line protocol of record to buf.
+func (c *csvColumns) appendLine(buf *bytes.Buffer, record []string, precision string) error {
+	measurement := record[c.measurement]
+	if measurement == "" {
+		return fmt.Errorf("empty measurement in column %q", c.names[c.measurement])
+	}
+	if strings.ContainsAny(measurement, "\r\n") {
+		return fmt.Errorf("measurement in column %q contains a newline", c.names[c.measurement])
+	}
+	buf.Write(models.EscapeMeasurement([]byte(measurement)))
+	for _, i := range c.tags {
+		if record[i] == "" {
+			continue
+		}
+		if strings.ContainsAny(record[i], "\r\n") {
+			return fmt.Errorf("tag %q contains a newline", c.names[i])
+		}
+		buf.WriteByte(',')
+		buf.WriteString(escape.String(c.names[i]))
+		buf.WriteByte('=')
+		buf.WriteString(escape.String(record[i]))
+	}
+
+	sep := byte(' ')
+	for _, i := range c.fields {
+		if record[i] == "" {
+			continue
+		}
+		buf.WriteByte(sep)
+		sep = ','
+		buf.WriteString(escape.String(c.names[i]))
+		buf.WriteByte('=')
+		buf.WriteString(csvFieldValue(record[i]))
+	}
+	if sep == ' ' {
+		return fmt.Errorf("no fields")
+	}
+
+	if c.timestamp >= 0 && record[c.timestamp] != "" {
+		ts, err := csvTimestamp(record[c.timestamp], precision)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(ts)
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// csvFieldValue returns the line protocol of the field value v.
+func csvFieldValue(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return strings.TrimPrefix(v, "+") + "i"
+	}
+	// ParseFloat also accepts hex, underscores, inf and nan, which line
+	// protocol does not.
+	if _, err := strconv.ParseFloat(v, 64); err == nil && !strings.ContainsAny(v, "xX_iInN") {
+		return strings.TrimPrefix(v, "+")
+	}
+	switch strings.ToLower(v) {
+	case "true", "false":
+		return strings.ToLower(v)
+	}
+	return `"` + models.EscapeStringField(v) + `"`
+}
+
+// csvTimestamp returns the timestamp v, an integer in precision or an
+// RFC3339 time, as an integer in precision.
+func csvTimestamp(v, precision string) (string, error) {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return v, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return "", fmt.Errorf("invalid timestamp %q, expected an integer or an RFC3339 time", v)
+	}
+	ns := t.UnixNano()
+	switch precision {
+	case "us":
+		ns /= int64(time.Microsecond)
+	case "ms":
+		ns /= int64(time.Millisecond)
+	case "s":
+		ns /= int64(time.Second)
+	}
+	return strconv.FormatInt(ns, 10), nil
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCSVMapping_convertCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapping   csvMapping
+		precision string
+		csv       string
+		lp        string
+		// err is the error, or its start for errors of the CSV reader.
+		err string
+	}{
+		{
+			name:    "tags and inferred fields",
+			mapping: csvMapping{Measurement: "m", Tags: []string{"host"}, Timestamp: "time"},
+			csv:     "m,host,i,f,b,s,time\ncpu,a,1,1.5,true,idle,100\n",
+			lp:      "cpu,host=a i=1i,f=1.5,b=true,s=\"idle\" 100\n",
+		},
+		{
+			name:    "selected fields",
+			mapping: csvMapping{Measurement: "m", Fields: []string{"f"}},
+			csv:     "m,f,other\ncpu,2,x\n",
+			lp:      "cpu f=2i\n",
+		},
+		{
+			name:    "numbers line protocol does not accept are strings",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,a,b,c,d,e\ncpu,+1,1e3,NaN,Inf,0x10\n",
+			lp:      "cpu a=1i,b=1e3,c=\"NaN\",d=\"Inf\",e=\"0x10\"\n",
+		},
+		{
+			name:    "booleans are case insensitive",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,a,b\ncpu,TRUE,False\n",
+			lp:      "cpu a=true,b=false\n",
+		},
+		{
+			name:    "quoted values",
+			mapping: csvMapping{Measurement: "m", Tags: []string{"host name"}},
+			csv:     "m,host name,msg\n\"cpu,total\",\"a b,c=d\",\"say \"\"hi\"\"\nbye\\\"\n",
+			lp:      "cpu\\,total,host\\ name=a\\ b\\,c\\=d msg=\"say \\\"hi\\\"\nbye\\\\\"\n",
+		},
+		{
+			name:    "empty tags and fields are omitted",
+			mapping: csvMapping{Measurement: "m", Tags: []string{"host"}, Timestamp: "time"},
+			csv:     "m,host,a,b,time\ncpu,,,1,\n",
+			lp:      "cpu b=1i\n",
+		},
+		{
+			name:      "RFC3339 timestamps in the precision of the write",
+			mapping:   csvMapping{Measurement: "m", Timestamp: "time"},
+			precision: "ms",
+			csv:       "m,f,time\ncpu,1,2020-01-01T00:00:01.5Z\n",
+			lp:        "cpu f=1i 1577836801500\n",
+		},
+		{
+			name:    "header only",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,f\n",
+		},
+		{
+			name:    "empty body",
+			mapping: csvMapping{Measurement: "m"},
+		},
+		{
+			name:    "unknown column",
+			mapping: csvMapping{Measurement: "m", Tags: []string{"host"}},
+			csv:     "m,f\ncpu,1\n",
+			err:     `column "host" is not in the CSV header`,
+		},
+		{
+			name:    "row without fields",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,f\ncpu,1\ncpu,\n",
+			err:     "row 3: no fields",
+		},
+		{
+			name:    "row without measurement",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,f\n,1\n",
+			err:     `row 2: empty measurement in column "m"`,
+		},
+		{
+			name:    "tag with a newline",
+			mapping: csvMapping{Measurement: "m", Tags: []string{"host"}},
+			csv:     "m,host,f\ncpu,\"a\nb\",1\n",
+			err:     `row 2: tag "host" contains a newline`,
+		},
+		{
+			name:    "invalid timestamp",
+			mapping: csvMapping{Measurement: "m", Timestamp: "time"},
+			csv:     "m,f,time\ncpu,1,yesterday\n",
+			err:     `row 2: invalid timestamp "yesterday", expected an integer or an RFC3339 time`,
+		},
+		{
+			name:    "rows of different lengths",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,f\ncpu,1,2\n",
+			err:     "unable to parse CSV: ",
+		},
+		{
+			name:    "unterminated quote",
+			mapping: csvMapping{Measurement: "m"},
+			csv:     "m,f\ncpu,\"1\n",
+			err:     "unable to parse CSV: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			precision := tt.precision
+			if precision == "" {
+				precision = "ns"
+			}
+			body, err := tt.mapping.convertCSV(ioutil.NopCloser(strings.NewReader(tt.csv)), precision)
+			if tt.err != "" {
+				if err == nil {
+					t.Fatalf("expected error %q", tt.err)
+				}
+				if got := err.Error(); !strings.HasPrefix(got, tt.err) {
+					t.Errorf("unexpected error: got %q want %q", got, tt.err)
+				}
+				if got := influxdb.ErrorCode(err); got != influxdb.EInvalid {
+					t.Errorf("unexpected error code: got %q want %q", got, influxdb.EInvalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			lp, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(lp); got != tt.lp {
+				t.Errorf("unexpected line protocol:\ngot  %q\nwant %q", got, tt.lp)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_csv(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		body        string
+		code        int
+		points      int
+	}{
+		{
+			name:        "row without fields",
+			contentType: "text/csv; charset=utf-8",
+			query:       "&csvMeasurement=m&csvTags=host&csvTimestamp=time",
+			body:        "m,host,usage,time\ncpu,a,0.5,1\ncpu,b,,2\ncpu,b,0.7,3\n",
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "application/csv",
+			contentType: "application/csv",
+			query:       "&csvMeasurement=m&csvTags=host&csvTimestamp=time",
+			body:        "m,host,usage,time\ncpu,a,0.5,1\ncpu,b,0.7,2\n",
+			code:        http.StatusNoContent,
+			points:      2,
+		},
+		{
+			name:        "mapping required",
+			contentType: "text/csv",
+			body:        "m,usage\ncpu,0.5\n",
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "line protocol for other content types",
+			contentType: "text/plain",
+			query:       "&csvMeasurement=m",
+			body:        "cpu usage=0.5",
+			code:        http.StatusNoContent,
+			points:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+		})
+	}
+}
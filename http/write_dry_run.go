@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	// paramDryRun and headerDryRun request a write that is parsed and
+	// validated but not written.
+	paramDryRun  = "dry-run"
+	headerDryRun = "X-Influxdb-Dry-Run"
+)
+
+// writeDryRunResponse summarizes the points a dry-run write would write.
+type writeDryRunResponse struct {
+	Points int `json:"points"`
+	// Series is the number of distinct series in the write. It is an upper
+	// bound for the number of series the write would create.
+	Series       int      `json:"series"`
+	Measurements []string `json:"measurements"`
+}
+
+// decodeDryRun reports whether r requests a dry-run, from the dry-run query
+// parameter or else the dry-run header.
+func decodeDryRun(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get(paramDryRun)
+	if v == "" {
+		v = r.Header.Get(headerDryRun)
+	}
+	return parseBoolParam(paramDryRun, v)
+}
+
+func newWriteDryRunResponse(points models.Points) writeDryRunResponse {
+	series := make(map[string]struct{}, len(points))
+	measurements := make(map[string]struct{})
+	for _, p := range points {
+		series[string(p.Key())] = struct{}{}
+		measurements[string(p.Tags().Get(models.MeasurementTagKeyBytes))] = struct{}{}
+	}
+
+	resp := writeDryRunResponse{
+		Points:       len(points),
+		Series:       len(series),
+		Measurements: make([]string, 0, len(measurements)),
+	}
+	for m := range measurements {
+		resp.Measurements = append(resp.Measurements, m)
+	}
+	sort.Strings(resp.Measurements)
+	return resp
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_dryRun(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		auth   influxdb.Authorizer
+		opts   []WriteHandlerOption
+		code   int
+		body   string
+		points int
+	}{
+		{
+			name:  "query parameter",
+			query: "&dry-run=true",
+			auth:  bucketWritePermission(orgID, bucketID),
+			code:  http.StatusOK,
+			body:  `{"points":4,"series":3,"measurements":["m1","m2"]}`,
+		},
+		{
+			name:   "header",
+			header: "true",
+			auth:   bucketWritePermission(orgID, bucketID),
+			code:   http.StatusOK,
+			body:   `{"points":4,"series":3,"measurements":["m1","m2"]}`,
+		},
+		{
+			name:   "dry-run disabled writes the points",
+			query:  "&dry-run=false",
+			auth:   bucketWritePermission(orgID, bucketID),
+			code:   http.StatusNoContent,
+			points: 4,
+		},
+		{
+			name:  "permissions are checked",
+			query: "&dry-run=true",
+			auth:  bucketWritePermission(orgID, "04504b356e23b001"),
+			code:  http.StatusForbidden,
+			body:  `{"code":"forbidden","message":"insufficient permissions for write"}`,
+		},
+		{
+			name:  "write limits are checked",
+			query: "&dry-run=true",
+			auth:  bucketWritePermission(orgID, bucketID),
+			opts:  []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxTagsPerPoint: 1})},
+			code:  http.StatusBadRequest,
+			body:  `{"code":"invalid","message":"points exceed write limits: measurement \"m2\": 2 tags exceed the limit of 1"}`,
+		},
+		{
+			name:  "invalid value",
+			query: "&dry-run=maybe",
+			auth:  bucketWritePermission(orgID, bucketID),
+			code:  http.StatusBadRequest,
+			body:  `{"code":"invalid","message":"dry-run must be a boolean: strconv.ParseBool: parsing \"maybe\": invalid syntax"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, tt.auth)
+
+			body := "m1,t1=v1 f1=1,f2=2\nm1,t1=v1 f1=2\nm2,t1=v1,t2=v2 f1=1"
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID+tt.query, strings.NewReader(body))
+			if tt.header != "" {
+				r.Header.Set(headerDryRun, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d", got, tt.code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.body {
+				t.Errorf("unexpected body: got %s want %s", got, tt.body)
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+		})
+	}
+}
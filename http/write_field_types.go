@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// FieldTypeCache holds the types of the fields of the measurements of
+// buckets, as a write handler with WithFieldTypeCheck learns them from
+// successful writes.
+type FieldTypeCache interface {
+	// FieldType returns the type of the field of measurement in the bucket,
+	// and false when the type is not known.
+	FieldType(orgID, bucketID influxdb.ID, measurement, field string) (models.FieldType, bool)
+	// SetFieldType records the type of the field of measurement in the
+	// bucket.
+	SetFieldType(orgID, bucketID influxdb.ID, measurement, field string, typ models.FieldType)
+}
+
+// WithFieldTypeCheck rejects writes with a 400 when a point has a field of a
+// different type than the one known to cache, or than an earlier point of the
+// same write, rather than failing in storage. The types of the fields of
+// successful writes are recorded in cache. Fields the cache does not know are
+// not checked against storage, so the check is only as complete as cache.
+func WithFieldTypeCheck(cache FieldTypeCache) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.fieldTypes = cache
+	}
+}
+
+type fieldTypeKey struct {
+	measurement string
+	field       string
+}
+
+// checkFieldTypes returns the types of the fields of points that the cache
+// does not know yet, or an EInvalid error naming the first field whose type
+// conflicts.
+func (h *WriteHandler) checkFieldTypes(orgID, bucketID influxdb.ID, points models.Points) (map[fieldTypeKey]models.FieldType, error) {
+	if h.fieldTypes == nil {
+		return nil, nil
+	}
+
+	added := make(map[fieldTypeKey]models.FieldType)
+	for _, p := range points {
+		measurement := string(p.Tags().Get(models.MeasurementTagKeyBytes))
+		iter := p.FieldIterator()
+		for iter.Next() {
+			k := fieldTypeKey{measurement: measurement, field: string(iter.FieldKey())}
+			typ := iter.Type()
+
+			known, ok := added[k]
+			if !ok {
+				known, ok = h.fieldTypes.FieldType(orgID, bucketID, k.measurement, k.field)
+			}
+			if !ok {
+				added[k] = typ
+				continue
+			}
+			if known != typ {
+				return nil, &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Op:   opPointsWriter,
+					Msg: fmt.Sprintf("field type conflict: input field %q on measurement %q is type %s, already exists as type %s",
+						k.field, k.measurement, strings.ToLower(typ.String()), strings.ToLower(known.String())),
+				}
+			}
+		}
+	}
+	return added, nil
+}
+
+// recordFieldTypes records the field types returned by checkFieldTypes once
+// their write succeeded.
+func (h *WriteHandler) recordFieldTypes(orgID, bucketID influxdb.ID, types map[fieldTypeKey]models.FieldType) {
+	for k, typ := range types {
+		h.fieldTypes.SetFieldType(orgID, bucketID, k.measurement, k.field, typ)
+	}
+}
+
+// MemoryFieldTypeCache is a FieldTypeCache in memory, local to the process.
+// It is not bounded, so it suits deployments with a known set of measurements.
+type MemoryFieldTypeCache struct {
+	mu     sync.RWMutex
+	fields map[memoryFieldTypeKey]models.FieldType
+}
+
+type memoryFieldTypeKey struct {
+	orgID, bucketID influxdb.ID
+	fieldTypeKey
+}
+
+// NewMemoryFieldTypeCache returns an empty MemoryFieldTypeCache.
+func NewMemoryFieldTypeCache() *MemoryFieldTypeCache {
+	return &MemoryFieldTypeCache{
+		fields: make(map[memoryFieldTypeKey]models.FieldType),
+	}
+}
+
+// FieldType implements FieldTypeCache.
+func (c *MemoryFieldTypeCache) FieldType(orgID, bucketID influxdb.ID, measurement, field string) (models.FieldType, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	typ, ok := c.fields[memoryFieldTypeKey{orgID, bucketID, fieldTypeKey{measurement, field}}]
+	return typ, ok
+}
+
+// SetFieldType implements FieldTypeCache.
+func (c *MemoryFieldTypeCache) SetFieldType(orgID, bucketID influxdb.ID, measurement, field string, typ models.FieldType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fields[memoryFieldTypeKey{orgID, bucketID, fieldTypeKey{measurement, field}}] = typ
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_fieldTypeCheck(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	type write struct {
+		body       string
+		storageErr error
+		code       int
+		err        string
+	}
+	tests := []struct {
+		name   string
+		check  bool
+		writes []write
+	}{
+		{
+			name:  "conflict with an earlier write",
+			check: true,
+			writes: []write{
+				{body: "cpu usage=0.5", code: http.StatusNoContent},
+				{body: "cpu usage=1i", code: http.StatusBadRequest, err: `field type conflict: input field \"usage\" on measurement \"cpu\" is type integer, already exists as type float`},
+				{body: "mem usage=1i", code: http.StatusNoContent},
+			},
+		},
+		{
+			name:  "conflict within a write",
+			check: true,
+			writes: []write{
+				{body: "cpu usage=0.5\ncpu usage=\"high\"", code: http.StatusBadRequest, err: `is type string, already exists as type float`},
+			},
+		},
+		{
+			name:  "failed writes are not recorded",
+			check: true,
+			writes: []write{
+				{body: "cpu usage=0.5", storageErr: errors.New("disk full"), code: http.StatusInternalServerError},
+				{body: "cpu usage=1i", code: http.StatusNoContent},
+			},
+		},
+		{
+			name: "disabled by default",
+			writes: []write{
+				{body: "cpu usage=0.5", code: http.StatusNoContent},
+				{body: "cpu usage=1i", code: http.StatusNoContent},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			var opts []WriteHandlerOption
+			if tt.check {
+				opts = append(opts, WithFieldTypeCheck(NewMemoryFieldTypeCache()))
+			}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			for i, wr := range tt.writes {
+				pw.ForceError(wr.storageErr)
+				r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader(wr.body))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+
+				if got := w.Code; got != wr.code {
+					t.Errorf("write %d: unexpected status code: got %d want %d: %s", i, got, wr.code, w.Body.String())
+				}
+				if wr.err != "" && !strings.Contains(w.Body.String(), wr.err) {
+					t.Errorf("write %d: unexpected error: got %s want %s", i, w.Body.String(), wr.err)
+				}
+			}
+		})
+	}
+}
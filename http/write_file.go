@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const opWriteLineProtocolFile = "http/WriteLineProtocolFile"
+
+// WriteFileProgress reports how much of a file WriteLineProtocolFile wrote.
+type WriteFileProgress struct {
+	// Offset is the byte offset of the line protocol up to which it was
+	// written, from which a retry resumes with WithFileOffset.
+	Offset int64
+	// Size is the size of the line protocol in bytes, -1 when the file is
+	// gzipped as its decompressed size is not known in advance.
+	Size int64
+}
+
+// WriteFileOption is a functional option of WriteLineProtocolFile.
+type WriteFileOption func(*writeFileOpts)
+
+type writeFileOpts struct {
+	chunkBytes int
+	offset     int64
+	progress   func(WriteFileProgress)
+}
+
+// WithChunkSizeBytes sets the size of the chunks a file is written in,
+// DefaultBatchSizeBytes when not positive.
+func WithChunkSizeBytes(n int) WriteFileOption {
+	return func(o *writeFileOpts) {
+		o.chunkBytes = n
+	}
+}
+
+// WithFileOffset resumes writing a file at the byte offset of its line
+// protocol returned by a failed WriteLineProtocolFile, so that the lines
+// already written are not sent again.
+func WithFileOffset(offset int64) WriteFileOption {
+	return func(o *writeFileOpts) {
+		o.offset = offset
+	}
+}
+
+// WithFileProgress calls fn once each chunk of a file is written.
+func WithFileProgress(fn func(WriteFileProgress)) WriteFileOption {
+	return func(o *writeFileOpts) {
+		o.progress = fn
+	}
+}
+
+// WriteLineProtocolFile streams the line protocol of the file at path to the
+// bucket, in chunks of whole lines under the chunk size, so that a file of
+// any size is loaded without exceeding the body limit of the server or
+// reading it in memory. Gzipped files are decompressed as they are read.
+// The chunks are written one after the other, and the returned offset is
+// that of the line protocol up to which they were written: when the write
+// fails it is to be passed to WithFileOffset by the retry. A line that does
+// not fit in a chunk on its own fails the write.
+func (s *WriteService) WriteLineProtocolFile(ctx context.Context, orgID, bucketID influxdb.ID, path string, opts ...WriteFileOption) (int64, error) {
+	o := writeFileOpts{chunkBytes: DefaultBatchSizeBytes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.chunkBytes <= 0 {
+		o.chunkBytes = DefaultBatchSizeBytes
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return o.offset, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteLineProtocolFile,
+			Msg:  fmt.Sprintf("unable to open %s", path),
+			Err:  err,
+		}
+	}
+	defer f.Close()
+
+	lp, size, err := openLineProtocol(f, o.offset)
+	if err != nil {
+		return o.offset, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteLineProtocolFile,
+			Msg:  fmt.Sprintf("unable to read %s", path),
+			Err:  err,
+		}
+	}
+
+	offset := o.offset
+	var chunk bytes.Buffer
+	flush := func() error {
+		if chunk.Len() == 0 {
+			return nil
+		}
+		if err := s.Write(ctx, orgID, bucketID, bytes.NewReader(chunk.Bytes())); err != nil {
+			return &influxdb.Error{
+				Code: influxdb.ErrorCode(err),
+				Op:   opWriteLineProtocolFile,
+				Msg:  fmt.Sprintf("write of %s failed at offset %d", path, offset),
+				Err:  err,
+			}
+		}
+		offset += int64(chunk.Len())
+		chunk.Reset()
+		if o.progress != nil {
+			o.progress(WriteFileProgress{Offset: offset, Size: size})
+		}
+		return nil
+	}
+
+	r := bufio.NewReader(lp)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return offset, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteLineProtocolFile,
+				Msg:  fmt.Sprintf("unable to read %s", path),
+				Err:  err,
+			}
+		}
+		if len(line) > o.chunkBytes {
+			return offset, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteLineProtocolFile,
+				Msg:  fmt.Sprintf("line at offset %d is %d bytes, over the chunk size of %d bytes", offset+int64(chunk.Len()), len(line), o.chunkBytes),
+			}
+		}
+		if chunk.Len()+len(line) > o.chunkBytes {
+			if err := flush(); err != nil {
+				return offset, err
+			}
+		}
+		chunk.Write(line)
+		if err == io.EOF {
+			err := flush()
+			return offset, err
+		}
+	}
+}
+
+// openLineProtocol returns the line protocol of f from offset, decompressed
+// when f is gzipped, with its size, -1 when it is not known.
+func openLineProtocol(f *os.File, offset int64) (io.Reader, int64, error) {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, 0, err
+		}
+		// the decompressed stream cannot seek, the lines already written
+		// are skipped instead.
+		if _, err := io.CopyN(ioutil.Discard, gz, offset); err != nil {
+			return nil, 0, err
+		}
+		return gz, -1, nil
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
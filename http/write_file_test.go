@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+func TestWriteService_WriteLineProtocolFile(t *testing.T) {
+	// a file of about 4MB.
+	var lp bytes.Buffer
+	for i := 0; lp.Len() < 4<<20; i++ {
+		fmt.Fprintf(&lp, "cpu,host=server%05d usage_user=%d,usage_system=%d %d\n", i%1000, i, i*2, i)
+	}
+	dir, err := ioutil.TempDir("", "write_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "cpu.lp")
+	if err := ioutil.WriteFile(plain, lp.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(lp.Bytes())
+	gw.Close()
+	compressed := filepath.Join(dir, "cpu.lp.gz")
+	if err := ioutil.WriteFile(compressed, gzipped.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const chunkSize = 256 << 10
+
+	// sink receives the chunks, failing the writes in fail.
+	type sink struct {
+		mu       sync.Mutex
+		received bytes.Buffer
+		writes   int
+		fail     map[int]bool
+	}
+	newServer := func(t *testing.T, s *sink) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.writes++
+			if s.fail[s.writes] {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"code":"unavailable","message":"try again"}`))
+				return
+			}
+			if len(body) > chunkSize {
+				t.Errorf("chunk of %d bytes is over the chunk size", len(body))
+			}
+			if len(body) > 0 && body[len(body)-1] != '\n' {
+				t.Errorf("chunk does not end with a whole line")
+			}
+			s.received.Write(body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+
+	for _, path := range []string{plain, compressed} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			s := &sink{}
+			ts := newServer(t, s)
+			defer ts.Close()
+
+			var progress []WriteFileProgress
+			svc := (&WriteService{Addr: ts.URL}).WithCompression(0)
+			offset, err := svc.WriteLineProtocolFile(context.Background(), 1, 2, path,
+				WithChunkSizeBytes(chunkSize),
+				WithFileProgress(func(p WriteFileProgress) { progress = append(progress, p) }),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offset != int64(lp.Len()) {
+				t.Errorf("unexpected offset: got %d want %d", offset, lp.Len())
+			}
+			if !bytes.Equal(s.received.Bytes(), lp.Bytes()) {
+				t.Errorf("unexpected line protocol received: got %d bytes want %d", s.received.Len(), lp.Len())
+			}
+			if len(progress) != s.writes || s.writes < lp.Len()/chunkSize {
+				t.Errorf("expected progress for each of the %d chunks, got %d", s.writes, len(progress))
+			}
+			if last := progress[len(progress)-1]; last.Offset != offset {
+				t.Errorf("unexpected last progress: %+v", last)
+			}
+		})
+	}
+
+	for _, path := range []string{plain, compressed} {
+		t.Run(filepath.Base(path)+" resumes", func(t *testing.T) {
+			s := &sink{fail: map[int]bool{4: true}}
+			ts := newServer(t, s)
+			defer ts.Close()
+
+			svc := (&WriteService{Addr: ts.URL}).WithCompression(0)
+			offset, err := svc.WriteLineProtocolFile(context.Background(), 1, 2, path, WithChunkSizeBytes(chunkSize))
+			if code := influxdb.ErrorCode(err); code != influxdb.EUnavailable {
+				t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.EUnavailable, err)
+			}
+			if offset != int64(s.received.Len()) {
+				t.Fatalf("unexpected offset of the failed write: got %d want %d", offset, s.received.Len())
+			}
+
+			offset, err = svc.WriteLineProtocolFile(context.Background(), 1, 2, path, WithChunkSizeBytes(chunkSize), WithFileOffset(offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if offset != int64(lp.Len()) {
+				t.Errorf("unexpected offset: got %d want %d", offset, lp.Len())
+			}
+			if !bytes.Equal(s.received.Bytes(), lp.Bytes()) {
+				t.Errorf("expected the retry to send the lines left once: got %d bytes want %d", s.received.Len(), lp.Len())
+			}
+		})
+	}
+
+	t.Run("line over the chunk size", func(t *testing.T) {
+		s := &sink{}
+		ts := newServer(t, s)
+		defer ts.Close()
+
+		svc := (&WriteService{Addr: ts.URL}).WithCompression(0)
+		_, err := svc.WriteLineProtocolFile(context.Background(), 1, 2, plain, WithChunkSizeBytes(16))
+		if code := influxdb.ErrorCode(err); code != influxdb.EInvalid {
+			t.Fatalf("unexpected error code: got %q want %q: %v", code, influxdb.EInvalid, err)
+		}
+		if s.writes != 0 {
+			t.Errorf("expected nothing to be written, got %d writes", s.writes)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -8,7 +9,14 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
@@ -21,7 +29,6 @@ import (
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
-	"istio.io/pkg/log"
 )
 
 var (
@@ -41,6 +48,7 @@ type WriteBackend struct {
 	PointsWriter        storage.PointsWriter
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
+	DBRPMappingService  influxdb.DBRPMappingServiceV2
 }
 
 // NewWriteBackend returns a new instance of WriteBackend.
@@ -53,6 +61,7 @@ func NewWriteBackend(log *zap.Logger, b *APIBackend) *WriteBackend {
 		PointsWriter:        b.PointsWriter,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
+		DBRPMappingService:  b.DBRPService,
 	}
 }
 
@@ -61,15 +70,55 @@ type WriteHandler struct {
 	influxdb.HTTPErrorHandler
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
+	DBRPMappingService  influxdb.DBRPMappingServiceV2
 	PointsWriter        storage.PointsWriter
 	EventRecorder       metric.EventRecorder
 
+	// TransformPoints, when set, rewrites the parsed points of every write
+	// before they are validated and written.
+	TransformPoints PointsTransformer
+
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
 	parserOptions     []models.ParserOption
+	metrics           *WriteMetrics
+	idempotency       *idempotencyCache
+	v1Authorizer      V1Authorizer
+	writeLimits       WriteLimits
+	rateLimiter       WriteRateLimiter
+	checkRetention    bool
+	tracer            opentracing.Tracer
+	accessLog         bool
+	writeTimeout      time.Duration
+	capacityCheck     func() error
+	targetHeaders     bool
+	pointSink         PointSink
+	fieldTypes        FieldTypeCache
+	middlewares       []func(http.Handler) http.Handler
+	cors              *WriteCORS
+	v1ErrorResponses  bool
+	traceIDTag        string
+	noPanicRecovery   bool
+	requireTLS        *WriteTLS
+	ready             readyCache
+
+	tokenScopeAuthorizations influxdb.AuthorizationService
+
+	autoCreateBucket    bool
+	autoCreateRetention time.Duration
+
+	shutdownMu   sync.RWMutex
+	shuttingDown bool
+	inflight     sync.WaitGroup
 }
 
+// PointsTransformer rewrites the points of a write, for instance to add
+// default tags or drop fields. The points are named by the encoded org and
+// bucket and carry their measurement and field key as tags. An error rejects
+// the write as invalid.
+type PointsTransformer func(ctx context.Context, points []models.Point) ([]models.Point, error)
+
 // WriteHandlerOption is a functional option for a *WriteHandler
 type WriteHandlerOption func(*WriteHandler)
 
@@ -87,6 +136,34 @@ func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 	}
 }
 
+// WithWriteMetrics records per org and bucket write metrics on m.
+// The caller is responsible for registering m with a prometheus registry.
+func WithWriteMetrics(m *WriteMetrics) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.metrics = m
+	}
+}
+
+// WithTracer traces writes with tracer rather than the global tracer, which
+// does nothing unless one is configured. A write continues the trace of the
+// client when it carries one, including a W3C traceparent header with a
+// Jaeger tracer, and its span is tagged with the resolved org and bucket.
+func WithTracer(tracer opentracing.Tracer) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.tracer = tracer
+	}
+}
+
+// startSpan starts the span of a write to r, returning r with the span in
+// its context.
+func (h *WriteHandler) startSpan(r *http.Request) (opentracing.Span, *http.Request) {
+	tracer := h.tracer
+	if tracer == nil {
+		tracer = opentracing.GlobalTracer()
+	}
+	return tracing.ExtractFromHTTPRequestWithTracer(tracer, r, "WriteHandler")
+}
+
 // Prefix provides the route prefix.
 func (*WriteHandler) Prefix() string {
 	return prefixWrite
@@ -94,8 +171,10 @@ func (*WriteHandler) Prefix() string {
 
 const (
 	prefixWrite              = "/api/v2/write"
+	paramVerbose             = "verbose"
 	msgInvalidGzipHeader     = "gzipped HTTP body contains an invalid header"
-	msgInvalidPrecision      = "invalid precision; valid precision units are ns, us, ms, and s"
+	msgInvalidGzipBody       = "unable to decompress gzipped HTTP body"
+	msgOrgRequired           = "org or orgID required"
 	msgUnableToReadData      = "unable to read data"
 	msgWritingRequiresPoints = "writing requires points"
 	msgUnexpectedWriteError  = "unexpected error writing points to database"
@@ -105,12 +184,18 @@ const (
 )
 
 // NewWriteHandler creates a new handler at /api/v2/write to receive line protocol.
+// A nil logger defaults to a development logger.
 func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOption) *WriteHandler {
+	if log == nil {
+		log = newDevelopmentLogger()
+	}
+
 	h := &WriteHandler{
 		HTTPErrorHandler:    b.HTTPErrorHandler,
 		PointsWriter:        b.PointsWriter,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
+		DBRPMappingService:  b.DBRPMappingService,
 		EventRecorder:       b.WriteEventRecorder,
 
 		router: NewRouter(b.HTTPErrorHandler),
@@ -120,17 +205,34 @@ func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOptio
 	for _, opt := range opts {
 		opt(h)
 	}
+	if h.noPanicRecovery {
+		h.router.PanicHandler = nil
+	}
 
-	h.router.HandlerFunc(http.MethodPost, prefixWrite, h.handleWrite)
+	h.router.Handler(http.MethodPost, prefixWrite, h.wrapWrite(h.withV1Errors(http.HandlerFunc(h.handleWrite))))
+	h.router.Handler(http.MethodPost, prefixWriteBatch, h.wrapWrite(http.HandlerFunc(h.handleWriteBatch)))
+	h.router.Handler(http.MethodPost, prefixPromWrite, h.wrapWrite(http.HandlerFunc(h.handleWritePrometheus)))
+	if h.cors != nil {
+		h.router.HandlerFunc(http.MethodOptions, prefixWrite, h.handleCORSPreflight)
+		h.router.HandlerFunc(http.MethodOptions, prefixWriteBatch, h.handleCORSPreflight)
+	}
+	h.router.HandlerFunc(http.MethodGet, prefixWriteHealth, h.handleHealth)
+	h.router.HandlerFunc(http.MethodGet, prefixWriteReady, h.handleReady)
 	return h
 }
 
+// wrapWrite wraps next, the handler of a write endpoint, in the middleware
+// every write passes through. Writes carry a request id, from the
+// X-Request-Id header or else a new one, which is passed on to the services
+// they call and logged with their panics. Under WithRequireTLS, writes not
+// sent over TLS are rejected once access logged.
+func (h *WriteHandler) wrapWrite(next http.Handler) http.Handler {
+	return h.withCORS(middleware.RequestID(h.withAccessLog(h.withRequireTLS(h.withRecovery(h.withMiddlewares(next))))))
+}
+
 func (h *WriteHandler) findBucket(ctx context.Context, orgID influxdb.ID, bucket string) (*influxdb.Bucket, error) {
 	if id, err := influxdb.IDFromString(bucket); err == nil {
-		b, err := h.BucketService.FindBucket(ctx, influxdb.BucketFilter{
-			OrganizationID: &orgID,
-			ID:             id,
-		})
+		b, err := h.BucketService.FindBucket(ctx, influxdb.NewBucketFilter().WithOrgID(orgID).WithID(*id))
 		if err != nil && influxdb.ErrorCode(err) != influxdb.ENotFound {
 			return nil, err
 		} else if err == nil {
@@ -138,10 +240,26 @@ func (h *WriteHandler) findBucket(ctx context.Context, orgID influxdb.ID, bucket
 		}
 	}
 
-	return h.BucketService.FindBucket(ctx, influxdb.BucketFilter{
-		OrganizationID: &orgID,
-		Name:           &bucket,
-	})
+	return h.BucketService.FindBucket(ctx, influxdb.NewBucketFilter().WithOrgID(orgID).WithName(bucket))
+}
+
+// findOrgV2 resolves the org of a v2 write. An orgID parameter is used as is,
+// saving the write a round trip to the organization service: the bucket is
+// looked up within the org and the permissions are checked against its ID, so
+// an org that does not exist fails the write as its bucket not being found.
+// So is the org of a token scoped to a single org, used when the write has
+// neither an org nor an orgID parameter.
+func (h *WriteHandler) findOrgV2(ctx context.Context, r *http.Request) (*influxdb.Organization, error) {
+	qp := r.URL.Query()
+	if id, err := influxdb.IDFromString(qp.Get(OrgID)); err == nil {
+		return &influxdb.Organization{ID: *id}, nil
+	}
+	if qp.Get(Org) == "" && qp.Get(OrgID) == "" {
+		if id, ok := pcontext.GetOrgID(ctx); ok {
+			return &influxdb.Organization{ID: id}, nil
+		}
+	}
+	return queryOrganization(ctx, r, h.OrganizationService)
 }
 
 func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -149,28 +267,42 @@ func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
-	span, r := tracing.ExtractFromHTTPRequest(r, "WriteHandler")
+	received := time.Now().UTC()
+	span, r := h.startSpan(r)
 	defer span.Finish()
 
 	ctx := r.Context()
-	auth, err := pcontext.GetAuthorizer(ctx)
-	if err != nil {
+	if err := h.beginWrite(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	defer h.inflight.Done()
+
+	if err := h.checkCapacity(); err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 
-	req, err := decodeWriteRequest(ctx, r, h.maxBatchSizeBytes)
+	req, err := decodeWriteRequest(ctx, r, h.maxBatchSizeBytes, h.tokenScopeAuthorizations != nil)
+	if err != nil {
+		h.handleDecodeError(ctx, err, w)
+		return
+	}
+
+	auth, err := h.authorize(ctx, r, req)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
 
-	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	org, bucket, err := h.findTenant(ctx, r, req, auth)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
 		return
 	}
-	span.LogKV("org_id", org.ID)
+	span.SetTag("org_id", org.ID.String())
+	setAccessLogOrg(ctx, org.ID)
+	h.setTargetHeader(w, headerResolvedOrgID, org.ID)
 
 	sw := kithttp.NewStatusResponseWriter(w)
 	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
@@ -180,38 +312,258 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
 	}()
 
-	bucket, err := h.findBucket(ctx, org.ID, req.Bucket)
-	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
+	if bucket == nil {
+		bucket, err = h.findBucketToWrite(ctx, auth, org.ID, req.Bucket)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+	}
+	// a bucket to create is resolved once created, and findBucketToWrite
+	// checked that its writer may write to every bucket of the org.
+	if bucket.ID.Valid() {
+		h.setResolvedBucket(ctx, span, w, bucket.ID)
+		if err := checkBucketWritePermissions(auth, org.ID, bucket.ID); err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
 	}
-	span.LogKV("bucket_id", bucket.ID)
 
-	if err := checkBucketWritePermissions(auth, org.ID, bucket.ID); err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
+	if req.CSV != nil {
+		if req.Body, err = req.CSV.convertCSV(req.Body, req.Precision); err != nil {
+			h.recordError(org.ID, bucket.ID)
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
 	}
 
 	opts := append([]models.ParserOption{}, h.parserOptions...)
 	opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := NewPointsParser(opts...).ParsePoints(ctx, org.ID, bucket.ID, req.Body)
+	parsed, err := NewPointsParser(opts...).WithLogger(h.log).ParsePoints(ctx, org.ID, bucket.ID, req.Body)
 	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
+		h.recordError(org.ID, bucket.ID)
+		h.handleParseError(ctx, err, sw)
 		return
 	}
 	requestBytes = parsed.RawSize
 
-	if err := h.PointsWriter.WritePoints(ctx, parsed.Points); err != nil {
-		h.HandleHTTPError(ctx, &influxdb.Error{
-			Code: influxdb.EInternal,
-			Op:   opWriteHandler,
-			Msg:  "unexpected error writing points to database",
-			Err:  err,
-		}, sw)
+	replay, err := h.writeParsed(ctx, sw, &pointsWrite{
+		op:             opWriteHandler,
+		orgID:          org.ID,
+		bucket:         bucket,
+		parsed:         parsed,
+		precision:      req.Precision,
+		received:       received,
+		setTimeNow:     req.SetTimeNow,
+		autoPrecision:  req.AutoPrecision,
+		dryRun:         req.DryRun,
+		idempotencyKey: r.Header.Get(headerIdempotencyKey),
+		resolved: func(id influxdb.ID) {
+			h.setResolvedBucket(ctx, span, w, id)
+		},
+	})
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
+	if replay {
+		span.LogKV("idempotent_replay", true)
+	}
 
-	sw.WriteHeader(http.StatusNoContent)
+	if req.DryRun {
+		if err := encodeResponse(ctx, sw, http.StatusOK, newWriteDryRunResponse(parsed.Points)); err != nil {
+			logEncodingError(h.log, r, err)
+		}
+		return
+	}
+	h.respondWritten(ctx, sw, r, req.Verbose, parsed)
+}
+
+// pointsWrite is a write of parsed points to a bucket, as decoded by any of
+// the write endpoints.
+type pointsWrite struct {
+	// op is the op of the errors of the write.
+	op    string
+	orgID influxdb.ID
+	// bucket is the bucket written to or a bucket to create, from
+	// findBucketToWrite, which is replaced by the bucket created.
+	bucket *influxdb.Bucket
+	parsed *ParsedPoints
+
+	precision     string
+	received      time.Time
+	setTimeNow    bool
+	autoPrecision bool
+	// dryRun validates the points without writing them.
+	dryRun bool
+	// idempotencyKey is the Idempotency-Key of the write, if any.
+	idempotencyKey string
+
+	// resolved, when set, is called with the ID of the bucket created for
+	// the write.
+	resolved func(id influxdb.ID)
+}
+
+// writeParsed validates the points of pw and writes them, reporting whether
+// the write is the replay of a write that succeeded, whose points are not
+// written again. w receives the Retry-After header of writes refused as rate
+// limited or busy, and may be nil for writes without a response of their own.
+func (h *WriteHandler) writeParsed(ctx context.Context, w http.ResponseWriter, pw *pointsWrite) (bool, error) {
+	orgID, bucket, parsed := pw.orgID, pw.bucket, pw.parsed
+	if pw.setTimeNow {
+		applyTimeNow(parsed.Points, pw.received, pw.precision)
+	} else if pw.autoPrecision {
+		applyAutoPrecision(parsed.Points)
+	}
+
+	if err := h.transformPoints(ctx, parsed); err != nil {
+		h.recordError(orgID, bucket.ID)
+		return false, err
+	}
+
+	if err := h.writeLimits.validate(parsed.Points); err != nil {
+		h.recordError(orgID, bucket.ID)
+		return false, err
+	}
+
+	if err := h.validateRetention(bucket, parsed.Points, time.Now()); err != nil {
+		h.recordError(orgID, bucket.ID)
+		return false, err
+	}
+
+	fieldTypes, err := h.checkFieldTypes(orgID, bucket.ID, parsed.Points)
+	if err != nil {
+		h.recordError(orgID, bucket.ID)
+		return false, err
+	}
+
+	if pw.dryRun {
+		return false, nil
+	}
+
+	// a replay is answered as the write it repeats was, from its own points,
+	// without writing them again. The replays of writes to a bucket that
+	// exists are found before the write is rate limited, and a bucket to
+	// create is only created for writes that are not.
+	var key *idempotencyKey
+	var written bool
+	defer func() {
+		if key == nil {
+			return
+		}
+		// only writes that succeeded are replayed.
+		if written {
+			h.idempotency.Add(*key)
+		} else {
+			h.idempotency.Release(*key)
+		}
+	}()
+	if bucket.ID.Valid() {
+		var replay bool
+		if key, replay, err = h.reserveIdempotencyKey(orgID, bucket.ID, pw.idempotencyKey); err != nil || replay {
+			return replay, err
+		}
+	}
+
+	if err := h.reserveWrite(ctx, w, orgID, len(parsed.Points), parsed.RawSize); err != nil {
+		h.recordError(orgID, bucket.ID)
+		return false, err
+	}
+
+	if !bucket.ID.Valid() {
+		if bucket, err = h.createBucketToWrite(ctx, bucket, parsed.Points); err != nil {
+			return false, err
+		}
+		pw.bucket = bucket
+		if pw.resolved != nil {
+			pw.resolved(bucket.ID)
+		}
+
+		var replay bool
+		if key, replay, err = h.reserveIdempotencyKey(orgID, bucket.ID, pw.idempotencyKey); err != nil || replay {
+			return replay, err
+		}
+	}
+
+	if err := h.writePoints(ctx, pw.op, func(ctx context.Context) error {
+		if h.pointSink != nil {
+			return h.pointSink.Write(ctx, orgID, bucket.ID, parsed.Points)
+		}
+		return h.writeToPointsWriter(ctx, parsed.Points, storage.WriteOptions{
+			Precision: pw.precision,
+		})
+	}); err != nil {
+		h.recordError(orgID, bucket.ID)
+		if w != nil {
+			setBusyRetryAfter(w, err)
+		}
+		return false, err
+	}
+	written = true
+	if fieldTypes != nil {
+		h.recordFieldTypes(orgID, bucket.ID, fieldTypes)
+	}
+	if h.metrics != nil {
+		h.metrics.RecordWrite(orgID, bucket.ID, len(parsed.Points), parsed.RawSize)
+	}
+	return false, nil
+}
+
+// reserveIdempotencyKey reserves the Idempotency-Key of a write to bucketID,
+// returning the reserved key and whether the write is a replay. The key is
+// nil for writes without a key or when writes are not deduplicated.
+func (h *WriteHandler) reserveIdempotencyKey(orgID, bucketID influxdb.ID, key string) (*idempotencyKey, bool, error) {
+	if key == "" || h.idempotency == nil {
+		return nil, false, nil
+	}
+	k := newIdempotencyKey(orgID, bucketID, key)
+	replay, err := h.idempotency.Reserve(k)
+	if err != nil || replay {
+		return nil, replay, err
+	}
+	return &k, false, nil
+}
+
+// respondWritten responds to the write of parsed, with a writeVerboseResponse
+// when verbose and a 204 otherwise.
+func (h *WriteHandler) respondWritten(ctx context.Context, w http.ResponseWriter, r *http.Request, verbose bool, parsed *ParsedPoints) {
+	if !verbose {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	resp := writeVerboseResponse{
+		PointsWritten: len(parsed.Points),
+		BytesReceived: parsed.RawSize,
+	}
+	if err := encodeResponse(ctx, w, http.StatusOK, resp); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+// transformPoints replaces the points of parsed with those returned by the
+// TransformPoints hook, then tags them with the trace id of ctx when
+// configured with WithTraceIDTag.
+func (h *WriteHandler) transformPoints(ctx context.Context, parsed *ParsedPoints) error {
+	if h.TransformPoints != nil {
+		points, err := h.TransformPoints(ctx, parsed.Points)
+		if err != nil {
+			return &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  "unable to transform points",
+				Err:  err,
+			}
+		}
+		parsed.Points = points
+	}
+	h.tagTraceID(ctx, parsed.Points)
+	return nil
+}
+
+func (h *WriteHandler) recordError(orgID, bucketID influxdb.ID) {
+	if h.metrics != nil {
+		h.metrics.RecordError(orgID, bucketID)
+	}
 }
 
 // checkBucketWritePermissions checks an Authorizer for write permissions to a
@@ -242,11 +594,11 @@ func checkBucketWritePermissions(auth influxdb.Authorizer, orgID, bucketID influ
 func PointBatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes int64) (io.ReadCloser, error) {
 	switch encoding {
 	case "gzip", "x-gzip":
-		var err error
-		rc, err = gzip.NewReader(rc)
+		gr, err := gzip.NewReader(rc)
 		if err != nil {
 			return nil, err
 		}
+		rc = &gzipReadCloser{Reader: gr, body: rc}
 	}
 	if maxBatchSizeBytes > 0 {
 		rc = kitio.NewLimitedReadCloser(rc, maxBatchSizeBytes)
@@ -254,13 +606,72 @@ func PointBatchReadCloser(rc io.ReadCloser, encoding string, maxBatchSizeBytes i
 	return rc, nil
 }
 
+// gzipReadCloser closes both the gzip reader and the compressed body it
+// reads from.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+// Read reads the decompressed body. Errors decompressing it are wrapped in a
+// gzipBodyError, as a malformed body is an error of the client.
+func (g *gzipReadCloser) Read(p []byte) (int, error) {
+	n, err := g.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = &gzipBodyError{err: err}
+	}
+	return n, err
+}
+
+// gzipBodyError is an error reading a gzipped body, such as a corrupt or
+// truncated stream.
+type gzipBodyError struct {
+	err error
+}
+
+func (e *gzipBodyError) Error() string { return e.err.Error() }
+func (e *gzipBodyError) Unwrap() error { return e.err }
+
+// readBodyError returns the error of reading the points of a write body.
+func readBodyError(op string, err error) error {
+	code, msg := influxdb.EInternal, msgUnableToReadData
+	var gzErr *gzipBodyError
+	if errors.Is(err, ErrMaxBatchSizeExceeded) {
+		code = influxdb.ETooLarge
+	} else if errors.As(err, &gzErr) {
+		code, msg = influxdb.EInvalid, msgInvalidGzipBody
+	}
+	return &influxdb.Error{
+		Code: code,
+		Op:   op,
+		Msg:  msg,
+		Err:  err,
+	}
+}
+
+func (g *gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.body.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	return err
+}
+
 // NewPointsParser returns a new PointsParser
 func NewPointsParser(parserOptions ...models.ParserOption) *PointsParser {
 	return &PointsParser{
 		ParserOptions: parserOptions,
+		Logger:        zap.NewNop(),
 	}
 }
 
+// WithLogger logs the parse failures of pw to log. It returns pw to allow
+// chaining.
+func (pw *PointsParser) WithLogger(log *zap.Logger) *PointsParser {
+	pw.Logger = log
+	return pw
+}
+
 // ParsedPoints contains the points parsed as well as the total number of bytes
 // after decompression.
 type ParsedPoints struct {
@@ -271,6 +682,10 @@ type ParsedPoints struct {
 // PointsParser parses batches of Points.
 type PointsParser struct {
 	ParserOptions []models.ParserOption
+
+	// Logger receives parse failures. NewPointsParser defaults it to a no-op
+	// logger, and a nil Logger disables logging.
+	Logger *zap.Logger
 }
 
 // ParsePoints parses the points from an io.ReadCloser for a specific Bucket.
@@ -283,18 +698,10 @@ func (pw *PointsParser) ParsePoints(ctx context.Context, orgID, bucketID influxd
 func (pw *PointsParser) parsePoints(ctx context.Context, orgID, bucketID influxdb.ID, rc io.ReadCloser) (*ParsedPoints, error) {
 	data, err := readAll(ctx, rc)
 	if err != nil {
-		code := influxdb.EInternal
-		if errors.Is(err, ErrMaxBatchSizeExceeded) {
-			code = influxdb.ETooLarge
-		} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) {
-			code = influxdb.EInvalid
-		}
-		return nil, &influxdb.Error{
-			Code: code,
-			Op:   opPointsWriter,
-			Msg:  msgUnableToReadData,
-			Err:  err,
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return nil, ctxErr
 		}
+		return nil, readBodyError(opPointsWriter, err)
 	}
 
 	requestBytes := len(data)
@@ -314,13 +721,17 @@ func (pw *PointsParser) parsePoints(ctx context.Context, orgID, bucketID influxd
 	span.LogKV("values_total", len(points))
 	span.Finish()
 	if err != nil {
-		log.Error("Error parsing points", zap.Error(err))
+		if pw.Logger != nil {
+			pw.Logger.Error("Error parsing points", zap.Error(err))
+		}
 
 		code := influxdb.EInvalid
 		if errors.Is(err, models.ErrLimitMaxBytesExceeded) ||
 			errors.Is(err, models.ErrLimitMaxLinesExceeded) ||
 			errors.Is(err, models.ErrLimitMaxValuesExceeded) {
 			code = influxdb.ETooLarge
+		} else if lpe := findParseError(data, mm, pw.ParserOptions); lpe != nil {
+			err = lpe
 		}
 
 		return nil, &influxdb.Error{
@@ -337,29 +748,54 @@ func (pw *PointsParser) parsePoints(ctx context.Context, orgID, bucketID influxd
 	}, nil
 }
 
-func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
-	defer func() {
+// readAll reads rc until EOF and closes it. It returns the context's error as
+// soon as ctx is done, leaving rc to be closed once the read blocked on it has
+// returned, as rc may not be closed while it is read.
+func readAll(ctx context.Context, rc io.ReadCloser) ([]byte, error) {
+	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "read request body")
+	defer span.Finish()
+
+	if err := ctx.Err(); err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		data, err := ioutil.ReadAll(kitio.NewContextReader(ctx, rc))
 		if cerr := rc.Close(); cerr != nil && err == nil {
 			if errors.Is(cerr, kitio.ErrReadLimitExceeded) {
 				cerr = ErrMaxBatchSizeExceeded
 			}
 			err = cerr
 		}
+		res <- result{data: data, err: err}
 	}()
 
-	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "read request body")
-
-	defer func() {
-		span.LogKV("request_bytes", len(data))
-		span.Finish()
-	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-res:
+		span.LogKV("request_bytes", len(r.data))
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.data, nil
+	}
+}
 
-	data, err = ioutil.ReadAll(rc)
+// newDevelopmentLogger returns the development logger of the handlers created
+// without a logger, or a no-op logger when it cannot be built.
+func newDevelopmentLogger() *zap.Logger {
+	log, err := zap.NewDevelopment()
 	if err != nil {
-		return nil, err
-
+		return zap.NewNop()
 	}
-	return data, nil
+	return log
 }
 
 // writeRequest is a request object holding information about a batch of points
@@ -369,27 +805,93 @@ type writeRequest struct {
 	Bucket    string
 	Precision string
 	Body      io.ReadCloser
+
+	// Database and RetentionPolicy address the bucket of v1 writes.
+	Database        string
+	RetentionPolicy string
+
+	// DryRun parses and validates the points without writing them.
+	DryRun bool
+	// Verbose responds to successful writes with a writeVerboseResponse.
+	Verbose bool
+	// CSV maps the columns of a CSV body to points, nil for line protocol.
+	CSV *csvMapping
+	// TokenScoped writes omit org and bucket, writing to the bucket the
+	// token is scoped to.
+	TokenScoped bool
+	// AutoPrecision infers the precision of each timestamp, which is parsed
+	// in nanoseconds, from its magnitude.
+	AutoPrecision bool
+	// SetTimeNow timestamps every point with the time the write was
+	// received, ignoring the timestamps of the lines.
+	SetTimeNow bool
+}
+
+// writeVerboseResponse is the body of a successful verbose write.
+type writeVerboseResponse struct {
+	PointsWritten int `json:"pointsWritten"`
+	BytesReceived int `json:"bytesReceived"`
+}
+
+// parseBoolParam parses the value v of the boolean parameter name. An empty
+// value is false.
+func parseBoolParam(name, v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   "http/newWriteRequest",
+			Msg:  fmt.Sprintf("%s must be a boolean", name),
+			Err:  err,
+		}
+	}
+	return b, nil
 }
 
 // decodeWriteRequest extracts information from an http.Request object to
-// produce a writeRequest.
-func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes int64) (*writeRequest, error) {
+// produce a writeRequest. With tokenScope, a write without org, bucket or
+// database is a TokenScoped write.
+func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes int64, tokenScope bool) (*writeRequest, error) {
 	qp := r.URL.Query()
 	precision := qp.Get("precision")
+	if qp.Get(Bucket) == "" && qp.Get(paramV1Database) != "" {
+		precision = v1Precision(precision)
+	}
 	if precision == "" {
+		// Timestamps without a precision are in nanoseconds, as they are
+		// for v1 writes.
+		precision = "ns"
+	}
+
+	// auto precision writes are parsed in nanoseconds and then scaled.
+	autoPrecision := precision == precisionAuto
+	if autoPrecision {
 		precision = "ns"
 	}
 
 	if !models.ValidPrecision(precision) {
+		return nil, invalidPrecisionError("http/newWriteRequest", qp.Get("precision"), writePrecisions)
+	}
+
+	tokenScoped := tokenScope && qp.Get(Org) == "" && qp.Get(OrgID) == "" &&
+		qp.Get(Bucket) == "" && qp.Get(paramV1Database) == ""
+
+	// v1 writes find their org through the dbrp mapping of the database, and
+	// tokens scoped to a single org default to that org.
+	_, tokenOrg := pcontext.GetOrgID(ctx)
+	if !tokenScoped && !tokenOrg && qp.Get(Org) == "" && qp.Get(OrgID) == "" && qp.Get(paramV1Database) == "" {
 		return nil, &influxdb.Error{
 			Code: influxdb.EInvalid,
 			Op:   "http/newWriteRequest",
-			Msg:  msgInvalidPrecision,
+			Msg:  msgOrgRequired,
 		}
 	}
 
 	bucket := qp.Get("bucket")
-	if bucket == "" {
+	if !tokenScoped && bucket == "" && qp.Get(paramV1Database) == "" {
 		return nil, &influxdb.Error{
 			Code: influxdb.ENotFound,
 			Op:   "http/newWriteRequest",
@@ -397,10 +899,35 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		}
 	}
 
+	dryRun, err := decodeDryRun(r)
+	if err != nil {
+		return nil, err
+	}
+	verbose, err := parseBoolParam(paramVerbose, qp.Get(paramVerbose))
+	if err != nil {
+		return nil, err
+	}
+	setTimeNow, err := parseBoolParam(paramSetTimeNow, qp.Get(paramSetTimeNow))
+	if err != nil {
+		return nil, err
+	}
+
+	var csvMap *csvMapping
+	if isCSVWrite(r) {
+		if csvMap, err = decodeCSVMapping(r); err != nil {
+			return nil, err
+		}
+	}
+
 	encoding := r.Header.Get("Content-Encoding")
 	body, err := PointBatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
-		return nil, err
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  msgInvalidGzipHeader,
+			Err:  err,
+		}
 	}
 
 	return &writeRequest{
@@ -408,89 +935,203 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		Org:       qp.Get("org"),
 		Precision: precision,
 		Body:      body,
+
+		Database:        qp.Get(paramV1Database),
+		RetentionPolicy: qp.Get(paramV1RetentionPolicy),
+		DryRun:          dryRun,
+		Verbose:         verbose,
+		CSV:             csvMap,
+		TokenScoped:     tokenScoped,
+		AutoPrecision:   autoPrecision,
+		SetTimeNow:      setTimeNow,
 	}, nil
 }
 
 // WriteService sends data over HTTP to influxdb via line protocol.
+// Request bodies are gzipped unless disabled with WithCompression.
 type WriteService struct {
-	Addr               string
+	Addr string
+	// Addrs are the hosts to write to when there is more than one, such as
+	// the hosts of an HA setup. A write fails over to the next host on a
+	// connection error or a 5xx response, starting from the host of the last
+	// successful write. Addr is used when Addrs is empty.
+	Addrs              []string
 	Token              string
 	Precision          string
 	InsecureSkipVerify bool
+
+	// MinCompressSize is the size in bytes below which a body is sent
+	// uncompressed, as gzip only adds overhead to tiny payloads. Zero
+	// compresses every body.
+	MinCompressSize int
+
+	// compression is the gzip level, nil means gzip.DefaultCompression.
+	compression *int
+
+	// preferred is the index in Addrs of the host of the last successful
+	// write.
+	preferred int32
+}
+
+// WithCompression sets the gzip level of request bodies. gzip.NoCompression
+// sends bodies uncompressed. It returns s to allow chaining.
+func (s *WriteService) WithCompression(level int) *WriteService {
+	s.compression = &level
+	return s
+}
+
+func (s *WriteService) compressionLevel() int {
+	if s.compression == nil {
+		return gzip.DefaultCompression
+	}
+	return *s.compression
 }
 
 var _ influxdb.WriteService = (*WriteService)(nil)
 
+// Write writes the line protocol read from r to the bucket. With several
+// Addrs, r is read into memory so that it can be sent to the next host when
+// a host fails.
 func (s *WriteService) Write(ctx context.Context, orgID, bucketID influxdb.ID, r io.Reader) error {
 	precision := s.Precision
 	if precision == "" {
 		precision = "ns"
 	}
 
-	if !models.ValidPrecision(precision) {
+	if precision != precisionAuto && !models.ValidPrecision(precision) {
+		return invalidPrecisionError("http/Write", precision, writePrecisions)
+	}
+
+	level := s.compressionLevel()
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
 		return &influxdb.Error{
 			Code: influxdb.EInvalid,
 			Op:   "http/Write",
-			Msg:  msgInvalidPrecision,
+			Msg:  fmt.Sprintf("invalid gzip compression level %d", level),
 		}
 	}
 
-	u, err := NewURL(s.Addr, prefixWrite)
+	org, err := orgID.Encode()
 	if err != nil {
 		return err
 	}
 
-	r, err = compressWithGzip(r)
+	bucket, err := bucketID.Encode()
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), r)
+	// the org is sent as an orgID, which the write handler uses as is, rather
+	// than as an org looked up by name first.
+	params := url.Values{}
+	params.Set("orgID", string(org))
+	params.Set("bucket", string(bucket))
+	params.Set("precision", string(precision))
+
+	if len(s.Addrs) == 0 {
+		_, err := s.writeHost(ctx, s.Addr, params, level, r)
+		return err
+	}
+
+	body, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	req.Header.Set("Content-Encoding", "gzip")
-	SetToken(s.Token, req)
+	var failures []string
+	start := int(atomic.LoadInt32(&s.preferred))
+	for i := range s.Addrs {
+		idx := (start + i) % len(s.Addrs)
+		addr := s.Addrs[idx]
 
-	org, err := orgID.Encode()
+		failover, err := s.writeHost(ctx, addr, params, level, bytes.NewReader(body))
+		if err == nil {
+			atomic.StoreInt32(&s.preferred, int32(idx))
+			return nil
+		}
+		if !failover || ctx.Err() != nil {
+			return err
+		}
+		failures = append(failures, addr+": "+err.Error())
+	}
+
+	return &influxdb.Error{
+		Code: influxdb.EUnavailable,
+		Op:   "http/Write",
+		Msg:  "write failed on all hosts",
+		Err:  errors.New(strings.Join(failures, "; ")),
+	}
+}
+
+// writeHost writes the body read from r to the host addr. It reports whether
+// the error is one of the host, a connection error or a 5xx response, that
+// another host may not have.
+func (s *WriteService) writeHost(ctx context.Context, addr string, params url.Values, level int, r io.Reader) (bool, error) {
+	u, err := NewURL(addr, prefixWrite)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	bucket, err := bucketID.Encode()
+	compress := level != gzip.NoCompression
+	if compress && s.MinCompressSize > 0 {
+		head := make([]byte, s.MinCompressSize)
+		n, err := io.ReadFull(r, head)
+		switch err {
+		case nil:
+			r = io.MultiReader(bytes.NewReader(head), r)
+		case io.EOF, io.ErrUnexpectedEOF:
+			r = bytes.NewReader(head[:n])
+			compress = false
+		default:
+			return false, err
+		}
+	}
+	if compress {
+		r = compressWithGzip(r, level)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), r)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	params := req.URL.Query()
-	params.Set("org", string(org))
-	params.Set("bucket", string(bucket))
-	params.Set("precision", string(precision))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	SetToken(s.Token, req)
 	req.URL.RawQuery = params.Encode()
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
 
 	resp, err := hc.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
 	defer resp.Body.Close()
 
-	return CheckError(resp)
+	return resp.StatusCode >= http.StatusInternalServerError, CheckError(resp)
 }
 
-func compressWithGzip(data io.Reader) (io.Reader, error) {
+// compressWithGzip streams data through a gzip writer of the given level.
+// Any error reading data is returned from reads of the compressed stream,
+// and closing the stream stops the compression. The level must be valid for
+// gzip.NewWriterLevel.
+func compressWithGzip(data io.Reader, level int) io.ReadCloser {
 	pr, pw := io.Pipe()
-	gw := gzip.NewWriter(pw)
-	var err error
 
 	go func() {
-		_, err = io.Copy(gw, data)
-		gw.Close()
-		pw.Close()
+		gw, err := gzip.NewWriterLevel(pw, level)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(gw, data); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(gw.Close())
 	}()
 
-	return pr, err
+	return pr
 }
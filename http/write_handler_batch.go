@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const (
+	prefixWriteBatch = prefixWrite + "/batch"
+
+	opWriteBatchHandler = "http/writeBatchHandler"
+)
+
+// writeBatchRequest is the body of a POST to /api/v2/write/batch. Each batch
+// holds the line protocol destined for a single bucket of the org named by the
+// org or orgID query parameter, for example
+//
+//	{
+//	  "batches": [
+//	    {"bucket": "cpu", "data": "cpu,host=a usage=1\ncpu,host=b usage=2"},
+//	    {"bucket": "04504b356e23b000", "data": "mem,host=a used=3"}
+//	  ]
+//	}
+//
+// Buckets may be referenced by name or by id. The precision and set-time-now
+// query parameters and the Idempotency-Key header apply to every batch, the
+// key of each batch being that of its bucket.
+type writeBatchRequest struct {
+	Batches []writeBatch `json:"batches"`
+
+	precision      string
+	received       time.Time
+	setTimeNow     bool
+	idempotencyKey string
+}
+
+type writeBatch struct {
+	Bucket string `json:"bucket"`
+	Data   string `json:"data"`
+}
+
+// writeBatchResponse reports the outcome of each batch in the order they
+// appeared in the request.
+type writeBatchResponse struct {
+	Results []writeBatchResult `json:"results"`
+}
+
+type writeBatchResult struct {
+	Bucket   string `json:"bucket"`
+	BucketID string `json:"bucketID,omitempty"`
+	Points   int    `json:"points"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// handleWriteBatch fans a multi-bucket batch out to the points writer. The
+// response is a 200 when every batch is written and a 207 when any batch
+// fails, with the error of each failed batch in its result.
+func (h *WriteHandler) handleWriteBatch(w http.ResponseWriter, r *http.Request) {
+	span, r := h.startSpan(r)
+	defer span.Finish()
+
+	ctx := r.Context()
+	if err := h.beginWrite(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	defer h.inflight.Done()
+
+	if err := h.checkCapacity(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	req, err := decodeWriteBatchRequest(ctx, r, h.maxBatchSizeBytes)
+	if err != nil {
+		h.handleDecodeError(ctx, err, w)
+		return
+	}
+
+	org, err := h.findOrgV2(ctx, r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	span.SetTag("org_id", org.ID.String())
+	setAccessLogOrg(ctx, org.ID)
+	h.setTargetHeader(w, headerResolvedOrgID, org.ID)
+
+	sw := kithttp.NewStatusResponseWriter(w)
+	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
+	var requestBytes int
+	defer func() {
+		recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
+	}()
+
+	opts := append([]models.ParserOption{}, h.parserOptions...)
+	opts = append(opts, models.WithParserPrecision(req.precision))
+
+	status := http.StatusOK
+	resp := writeBatchResponse{
+		Results: make([]writeBatchResult, 0, len(req.Batches)),
+	}
+	for _, b := range req.Batches {
+		res, n := h.writeBatch(ctx, auth, org.ID, req, b, opts)
+		if res.Code != "" {
+			status = http.StatusMultiStatus
+		}
+		requestBytes += n
+		resp.Results = append(resp.Results, res)
+	}
+
+	if err := encodeResponse(ctx, sw, status, resp); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+// writeBatch writes a single batch and returns its result along with the
+// number of bytes written.
+func (h *WriteHandler) writeBatch(ctx context.Context, auth influxdb.Authorizer, orgID influxdb.ID, req *writeBatchRequest, b writeBatch, opts []models.ParserOption) (writeBatchResult, int) {
+	res := writeBatchResult{Bucket: b.Bucket}
+	failed := func(err error) (writeBatchResult, int) {
+		res.Code = influxdb.ErrorCode(err)
+		res.Message = err.Error()
+		return res, 0
+	}
+
+	if b.Bucket == "" {
+		return failed(&influxdb.Error{
+			Code: influxdb.ENotFound,
+			Op:   opWriteBatchHandler,
+			Msg:  "bucket not found",
+		})
+	}
+
+	bucket, err := h.findBucketToWrite(ctx, auth, orgID, b.Bucket)
+	if err != nil {
+		return failed(err)
+	}
+	// findBucketToWrite checked that the writer of a bucket to create may
+	// write to every bucket of the org.
+	if bucket.ID.Valid() {
+		res.BucketID = bucket.ID.String()
+		if err := checkBucketWritePermissions(auth, orgID, bucket.ID); err != nil {
+			return failed(err)
+		}
+	}
+
+	parsed, err := NewPointsParser(opts...).WithLogger(h.log).ParsePoints(ctx, orgID, bucket.ID, ioutil.NopCloser(strings.NewReader(b.Data)))
+	if err != nil {
+		h.recordError(orgID, bucket.ID)
+		return failed(err)
+	}
+
+	// batches have no response of their own to carry a Retry-After.
+	if _, err := h.writeParsed(ctx, nil, &pointsWrite{
+		op:             opWriteBatchHandler,
+		orgID:          orgID,
+		bucket:         bucket,
+		parsed:         parsed,
+		precision:      req.precision,
+		received:       req.received,
+		setTimeNow:     req.setTimeNow,
+		idempotencyKey: req.idempotencyKey,
+		resolved: func(id influxdb.ID) {
+			res.BucketID = id.String()
+		},
+	}); err != nil {
+		return failed(err)
+	}
+
+	res.Points = len(parsed.Points)
+	return res, parsed.RawSize
+}
+
+func decodeWriteBatchRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes int64) (*writeBatchRequest, error) {
+	received := time.Now().UTC()
+	qp := r.URL.Query()
+	precision := qp.Get("precision")
+	if precision == "" {
+		precision = "ns"
+	}
+	if !models.ValidPrecision(precision) {
+		return nil, invalidPrecisionError(opWriteBatchHandler, precision, batchPrecisions)
+	}
+	setTimeNow, err := parseBoolParam(paramSetTimeNow, qp.Get(paramSetTimeNow))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := PointBatchReadCloser(r.Body, r.Header.Get("Content-Encoding"), maxBatchSizeBytes)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteBatchHandler,
+			Msg:  msgInvalidGzipHeader,
+			Err:  err,
+		}
+	}
+
+	data, err := readAll(ctx, body)
+	if err != nil {
+		return nil, readBodyError(opWriteBatchHandler, err)
+	}
+
+	var req writeBatchRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteBatchHandler,
+			Msg:  "invalid batch write request",
+			Err:  err,
+		}
+	}
+	if len(req.Batches) == 0 {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteBatchHandler,
+			Msg:  msgWritingRequiresPoints,
+		}
+	}
+	req.precision = precision
+	req.received = received
+	req.setTimeNow = setTimeNow
+	req.idempotencyKey = r.Header.Get(headerIdempotencyKey)
+	return &req, nil
+}
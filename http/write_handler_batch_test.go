@@ -0,0 +1,255 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWriteBatch(t *testing.T) {
+	const (
+		orgID   = "043e0780ee2b1000"
+		cpuID   = "04504b356e23b000"
+		memID   = "04504b356e23b001"
+		orgPath = "http://localhost:9999/api/v2/write/batch?org=" + orgID
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(_ context.Context, filter influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		switch {
+		case filter.Name != nil && *filter.Name == "cpu",
+			filter.ID != nil && *filter.ID == influxtesting.MustIDBase16(cpuID):
+			return testBucket(orgID, cpuID), nil
+		case filter.Name != nil && *filter.Name == "mem":
+			return testBucket(orgID, memID), nil
+		}
+		return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		results []writeBatchResult
+		points  int
+	}{
+		{
+			name:   "writes every batch",
+			body:   `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1\ncpu,host=b usage=2"}, {"bucket": "` + cpuID + `", "data": "cpu,host=c usage=3"}]}`,
+			status: http.StatusOK,
+			results: []writeBatchResult{
+				{Bucket: "cpu", BucketID: cpuID, Points: 2},
+				{Bucket: cpuID, BucketID: cpuID, Points: 1},
+			},
+			points: 3,
+		},
+		{
+			name:   "reports the batches that fail",
+			body:   `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1"}, {"bucket": "mem", "data": "mem used=1"}, {"bucket": "disk", "data": "disk used=1"}, {"bucket": "cpu", "data": "invalid"}]}`,
+			status: http.StatusMultiStatus,
+			results: []writeBatchResult{
+				{Bucket: "cpu", BucketID: cpuID, Points: 1},
+				{Bucket: "mem", BucketID: memID, Code: influxdb.EForbidden, Message: "insufficient permissions for write"},
+				{Bucket: "disk", Code: influxdb.ENotFound, Message: "bucket not found"},
+				{Bucket: "cpu", BucketID: cpuID, Code: influxdb.EInvalid},
+			},
+			points: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw := &mock.PointsWriter{}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, orgPath, strings.NewReader(tt.body)))
+
+			if got := w.Code; got != tt.status {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.status, w.Body.String())
+			}
+
+			var resp writeBatchResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != len(tt.results) {
+				t.Fatalf("unexpected number of results: got %d want %d", len(resp.Results), len(tt.results))
+			}
+			for i, want := range tt.results {
+				got := resp.Results[i]
+				if want.Message == "" && want.Code != "" {
+					// parse errors are checked by code only
+					got.Message = ""
+				}
+				if got != want {
+					t.Errorf("unexpected result %d: got %+v want %+v", i, got, want)
+				}
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+		})
+	}
+
+	t.Run("writes with the precision of the batch", func(t *testing.T) {
+		pw := &optionsPointsWriter{}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        pw,
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+		w := httptest.NewRecorder()
+		body := `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1 1600000000"}]}`
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, orgPath+"&precision=s", strings.NewReader(body)))
+		if got := w.Code; got != http.StatusOK {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusOK, w.Body.String())
+		}
+		if len(pw.opts) != 1 || pw.opts[0].Precision != "s" {
+			t.Errorf("unexpected write options: got %+v want precision s", pw.opts)
+		}
+	})
+
+	t.Run("timestamps the points with the time received", func(t *testing.T) {
+		pw := &mock.PointsWriter{}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        pw,
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+		before := time.Now()
+		w := httptest.NewRecorder()
+		body := `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1 1"}]}`
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, orgPath+"&set-time-now=true", strings.NewReader(body)))
+		if got := w.Code; got != http.StatusOK {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusOK, w.Body.String())
+		}
+		if len(pw.Points) != 1 || pw.Points[0].Time().Before(before.Truncate(time.Second)) {
+			t.Errorf("expected the point to be timestamped when received: %v", pw.Points)
+		}
+	})
+
+	t.Run("replays are not written again", func(t *testing.T) {
+		pw := &mock.PointsWriter{}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        pw,
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithIdempotencyCache(10, time.Minute))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+		body := `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1\ncpu,host=b usage=2"}]}`
+		for i := 0; i < 2; i++ {
+			r := httptest.NewRequest(http.MethodPost, orgPath, strings.NewReader(body))
+			r.Header.Set(headerIdempotencyKey, "batch-1")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != http.StatusOK {
+				t.Fatalf("write %d: unexpected status code: got %d want %d: %s", i, got, http.StatusOK, w.Body.String())
+			}
+
+			var resp writeBatchResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if want := (writeBatchResult{Bucket: "cpu", BucketID: cpuID, Points: 2}); len(resp.Results) != 1 || resp.Results[0] != want {
+				t.Errorf("write %d: unexpected results: got %+v want %+v", i, resp.Results, want)
+			}
+		}
+		if got := len(pw.Points); got != 2 {
+			t.Errorf("unexpected points written: got %d want 2", got)
+		}
+	})
+
+	t.Run("orgID is not looked up", func(t *testing.T) {
+		orgs := mock.NewOrganizationService()
+		orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+			t.Error("unexpected org lookup")
+			return testOrg(orgID), nil
+		}
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        &mock.PointsWriter{},
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+		w := httptest.NewRecorder()
+		body := `{"batches": [{"bucket": "cpu", "data": "cpu,host=a usage=1"}]}`
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write/batch?orgID="+orgID, strings.NewReader(body)))
+		if got := w.Code; got != http.StatusOK {
+			t.Errorf("unexpected status code: got %d want %d: %s", got, http.StatusOK, w.Body.String())
+		}
+	})
+
+	t.Run("requires batches", func(t *testing.T) {
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        &mock.PointsWriter{},
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, cpuID))
+
+		for _, body := range []string{`{"batches": []}`, `not json`} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, orgPath, strings.NewReader(body)))
+			if got := w.Code; got != http.StatusBadRequest {
+				t.Errorf("%s: unexpected status code: got %d want %d", body, got, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// optionsPointsWriter records the options of the writes it is sent.
+type optionsPointsWriter struct {
+	mock.PointsWriter
+	opts []storage.WriteOptions
+}
+
+func (w *optionsPointsWriter) WritePointsWithOptions(ctx context.Context, points []models.Point, opts storage.WriteOptions) error {
+	w.opts = append(w.opts, opts)
+	return w.WritePoints(ctx, points)
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/check"
+)
+
+const (
+	prefixWriteHealth = prefixWrite + "/health"
+	prefixWriteReady  = prefixWrite + "/ready"
+)
+
+// readyCacheTTL is how long the result of a ready check is reused. The ready
+// check is served without authentication, so probes reach the stores at most
+// once per readyCacheTTL however often they come.
+const readyCacheTTL = time.Second
+
+// readyCache holds the result of the last ready check.
+type readyCache struct {
+	mu      sync.Mutex
+	checked time.Time
+	resp    check.Response
+}
+
+// handleHealth is a liveness check: the write handler is alive whenever it can
+// respond. Whether its dependencies are configured and respond is reported by
+// handleReady.
+func (h *WriteHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeCheckResponse(w, check.Response{
+		Name:   "write",
+		Status: check.StatusPass,
+	})
+}
+
+// handleReady attempts a trivial call against each dependency of the write
+// handler, including the dbrp mapping service when set, and responds with a
+// 503 if any of them errors or is not configured. The result is reused for
+// readyCacheTTL.
+func (h *WriteHandler) handleReady(w http.ResponseWriter, r *http.Request) {
+	h.ready.mu.Lock()
+	if now := time.Now(); now.Sub(h.ready.checked) >= readyCacheTTL {
+		h.ready.resp = h.checkReady(r.Context())
+		h.ready.checked = now
+	}
+	resp := h.ready.resp
+	h.ready.mu.Unlock()
+
+	writeCheckResponse(w, resp)
+}
+
+func (h *WriteHandler) checkReady(ctx context.Context) check.Response {
+	resp := check.Response{
+		Name:   "write",
+		Status: check.StatusPass,
+		Checks: check.Responses{
+			dependencyCheck(ctx, "bucketService", func(ctx context.Context) error {
+				if h.BucketService == nil {
+					return errDependencyNotConfigured
+				}
+				_, _, err := h.BucketService.FindBuckets(ctx, influxdb.BucketFilter{}, influxdb.FindOptions{Limit: 1})
+				return err
+			}),
+			dependencyCheck(ctx, "organizationService", func(ctx context.Context) error {
+				if h.OrganizationService == nil {
+					return errDependencyNotConfigured
+				}
+				_, _, err := h.OrganizationService.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{Limit: 1})
+				return err
+			}),
+			configuredCheck("pointsWriter", h.PointsWriter != nil),
+		},
+	}
+	if h.DBRPMappingService != nil {
+		resp.Checks = append(resp.Checks, dependencyCheck(ctx, "dbrpMappingService", func(ctx context.Context) error {
+			_, _, err := h.DBRPMappingService.FindMany(ctx, influxdb.DBRPMappingFilterV2{}, influxdb.FindOptions{Limit: 1})
+			return err
+		}))
+	}
+	for _, c := range resp.Checks {
+		if c.Status == check.StatusFail {
+			resp.Status = check.StatusFail
+		}
+	}
+	sort.Sort(resp.Checks)
+	return resp
+}
+
+var errDependencyNotConfigured = &influxdb.Error{
+	Code: influxdb.EUnavailable,
+	Msg:  "dependency not configured",
+}
+
+func configuredCheck(name string, configured bool) check.Response {
+	resp := check.Pass()
+	if !configured {
+		resp = check.Error(errDependencyNotConfigured)
+	}
+	resp.Name = name
+	return resp
+}
+
+func dependencyCheck(ctx context.Context, name string, fn func(ctx context.Context) error) check.Response {
+	resp := check.Pass()
+	if err := fn(ctx); err != nil {
+		resp = check.Error(err)
+	}
+	resp.Name = name
+	return resp
+}
+
+// writeCheckResponse writes the check response as JSON, with a 503 if it
+// failed.
+func writeCheckResponse(w http.ResponseWriter, resp check.Response) {
+	status := http.StatusOK
+	if resp.Status == check.StatusFail {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,238 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/prometheus/prompb"
+)
+
+const (
+	prefixPromWrite = "/api/v1/prom/write"
+
+	// promValueField is the field holding the value of a sample.
+	promValueField = "value"
+
+	opPromWriteHandler = "http/promWriteHandler"
+)
+
+// handleWritePrometheus receives a Prometheus remote write. Its bucket is
+// addressed as that of a line protocol write, by the org or orgID and bucket
+// query parameters or by the db and rp parameters of v1 writes. Each sample
+// of a series is written as a point of the measurement named by the __name__
+// label of the series, with its other labels as tags and its value in the
+// value field. Samples with a NaN or infinite value, such as the staleness
+// markers of Prometheus, cannot be stored and are dropped.
+func (h *WriteHandler) handleWritePrometheus(w http.ResponseWriter, r *http.Request) {
+	span, r := h.startSpan(r)
+	defer span.Finish()
+
+	ctx := r.Context()
+	if err := h.beginWrite(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	defer h.inflight.Done()
+
+	if err := h.checkCapacity(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	qp := r.URL.Query()
+	req := &writeRequest{
+		Bucket:          qp.Get("bucket"),
+		Database:        qp.Get(paramV1Database),
+		RetentionPolicy: qp.Get(paramV1RetentionPolicy),
+	}
+	org, bucket, err := h.findTenant(ctx, r, req, auth)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	span.SetTag("org_id", org.ID.String())
+	setAccessLogOrg(ctx, org.ID)
+	h.setTargetHeader(w, headerResolvedOrgID, org.ID)
+
+	sw := kithttp.NewStatusResponseWriter(w)
+	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
+	var requestBytes int
+	defer func() {
+		recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
+	}()
+
+	if bucket == nil {
+		if req.Bucket == "" {
+			h.HandleHTTPError(ctx, &influxdb.Error{
+				Code: influxdb.ENotFound,
+				Op:   opPromWriteHandler,
+				Msg:  "bucket not found",
+			}, sw)
+			return
+		}
+		if bucket, err = h.findBucketToWrite(ctx, auth, org.ID, req.Bucket); err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+	}
+	// a bucket to create is resolved once created, and findBucketToWrite
+	// checked that its writer may write to every bucket of the org.
+	if bucket.ID.Valid() {
+		h.setResolvedBucket(ctx, span, w, bucket.ID)
+		if err := checkBucketWritePermissions(auth, org.ID, bucket.ID); err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+	}
+
+	lines, err := decodePromWriteRequest(ctx, r, h.maxBatchSizeBytes)
+	if err != nil {
+		h.recordError(org.ID, bucket.ID)
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	if len(lines) == 0 {
+		// a remote write of only dropped samples has nothing to write.
+		sw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	parsed, err := NewPointsParser(h.parserOptions...).WithLogger(h.log).ParsePoints(ctx, org.ID, bucket.ID, ioutil.NopCloser(bytes.NewReader(lines)))
+	if err != nil {
+		h.recordError(org.ID, bucket.ID)
+		h.handleParseError(ctx, err, sw)
+		return
+	}
+	requestBytes = parsed.RawSize
+
+	if _, err := h.writeParsed(ctx, sw, &pointsWrite{
+		op:     opPromWriteHandler,
+		orgID:  org.ID,
+		bucket: bucket,
+		parsed: parsed,
+		resolved: func(id influxdb.ID) {
+			h.setResolvedBucket(ctx, span, w, id)
+		},
+	}); err != nil {
+		h.HandleHTTPError(ctx, err, sw)
+		return
+	}
+	sw.WriteHeader(http.StatusNoContent)
+}
+
+// decodePromWriteRequest reads the snappy compressed remote write of r and
+// returns its samples as line protocol, in nanoseconds. maxBatchSizeBytes
+// bounds both the compressed and the decompressed body.
+func decodePromWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes int64) ([]byte, error) {
+	body, err := PointBatchReadCloser(r.Body, "", maxBatchSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := readAll(ctx, body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return nil, ctxErr
+		}
+		return nil, readBodyError(opPromWriteHandler, err)
+	}
+
+	n, err := snappy.DecodedLen(compressed)
+	if err == nil && maxBatchSizeBytes > 0 && int64(n) > maxBatchSizeBytes {
+		return nil, &influxdb.Error{
+			Code: influxdb.ETooLarge,
+			Op:   opPromWriteHandler,
+			Msg:  msgUnableToReadData,
+			Err:  ErrMaxBatchSizeExceeded,
+		}
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opPromWriteHandler,
+			Msg:  "unable to decompress snappy encoded remote write",
+			Err:  err,
+		}
+	}
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opPromWriteHandler,
+			Msg:  "unable to decode remote write",
+			Err:  err,
+		}
+	}
+	return promLineProtocol(&req)
+}
+
+// promLineProtocol returns the samples of req as line protocol.
+func promLineProtocol(req *prompb.WriteRequest) ([]byte, error) {
+	var buf []byte
+	for _, ts := range req.Timeseries {
+		var name string
+		tags := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			if l.Name == prompb.MetricNameLabel {
+				name = l.Value
+				continue
+			}
+			tags[l.Name] = l.Value
+		}
+		if name == "" {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opPromWriteHandler,
+				Msg:  fmt.Sprintf("series %s has no %s label", promLabelsString(ts.Labels), prompb.MetricNameLabel),
+			}
+		}
+
+		for _, s := range ts.Samples {
+			if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+				continue
+			}
+			fields := models.Fields{promValueField: s.Value}
+			p, err := models.NewPoint(name, models.NewTags(tags), fields, time.Unix(0, s.Timestamp*int64(time.Millisecond)))
+			if err != nil {
+				return nil, &influxdb.Error{
+					Code: influxdb.EInvalid,
+					Op:   opPromWriteHandler,
+					Msg:  fmt.Sprintf("series %s", promLabelsString(ts.Labels)),
+					Err:  err,
+				}
+			}
+			buf = append(p.AppendString(buf), '\n')
+		}
+	}
+	return buf, nil
+}
+
+// promLabelsString formats labels as Prometheus does, sorted by name.
+func promLabelsString(labels []*prompb.Label) string {
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.Name, l.Value))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
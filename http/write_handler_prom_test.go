@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap/zaptest"
+)
+
+// promRemoteWrite is a remote write of the series up{job="prom"} with the
+// sample 1 at 1s and http_requests_total{code="200"} with the samples 5 at 2s
+// and a staleness marker at 3s, as encoded by Prometheus.
+const promRemoteWrite = "0a2b0a0e0a085f5f6e616d655f5f120275700a0b0a036a6f62120470726f6d120c09000000000000f03f10e8070a4a0a1f0a085f5f6e616d655f5f1213687474705f72657175657374735f746f74616c0a0b0a04636f64651203323030120c09000000000000144010d00f120c09000000000000f87f10b817"
+
+func TestWriteHandler_handleWritePrometheus(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+	payload, err := hex.DecodeString(promRemoteWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a remote write of a series without a metric name.
+	unnamed := []byte{0x0a, 0x0b, 0x0a, 0x09, 0x0a, 0x03, 'j', 'o', 'b', 0x12, 0x02, 'p', 'r'}
+
+	type point struct {
+		measurement string
+		tag         string
+		value       float64
+		unixNano    int64
+	}
+	tests := []struct {
+		name   string
+		query  string
+		body   []byte
+		code   int
+		points []point
+	}{
+		{
+			name:  "remote write",
+			query: "?org=" + orgID + "&bucket=" + bucketID,
+			body:  snappy.Encode(nil, payload),
+			code:  http.StatusNoContent,
+			points: []point{
+				{measurement: "up", tag: "job=prom", value: 1, unixNano: 1e9},
+				{measurement: "http_requests_total", tag: "code=200", value: 5, unixNano: 2e9},
+			},
+		},
+		{
+			name:  "bucket required",
+			query: "?org=" + orgID,
+			body:  snappy.Encode(nil, payload),
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "not snappy encoded",
+			query: "?org=" + orgID + "&bucket=" + bucketID,
+			body:  payload,
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "invalid protobuf",
+			query: "?org=" + orgID + "&bucket=" + bucketID,
+			body:  snappy.Encode(nil, []byte("up 1")),
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "series without a name",
+			query: "?org=" + orgID + "&bucket=" + bucketID,
+			body:  snappy.Encode(nil, unnamed),
+			code:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999"+prefixPromWrite+tt.query, bytes.NewReader(tt.body))
+			r.Header.Set("Content-Encoding", "snappy")
+			r.Header.Set("Content-Type", "application/x-protobuf")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got, want := len(pw.Points), len(tt.points); got != want {
+				t.Fatalf("unexpected points written: got %d want %d: %v", got, want, pw.Points)
+			}
+			for i, want := range tt.points {
+				p := pw.Points[i]
+				if got := string(p.Tags().Get(models.MeasurementTagKeyBytes)); got != want.measurement {
+					t.Errorf("point %d: unexpected measurement: got %q want %q", i, got, want.measurement)
+				}
+				tags := p.Tags()
+				var tag string
+				for _, kv := range tags {
+					if k := string(kv.Key); k != models.MeasurementTagKey && k != models.FieldKeyTagKey {
+						tag = k + "=" + string(kv.Value)
+					}
+				}
+				if tag != want.tag {
+					t.Errorf("point %d: unexpected tag: got %q want %q", i, tag, want.tag)
+				}
+				fields, err := p.Fields()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := fields[promValueField]; got != want.value {
+					t.Errorf("point %d: unexpected value: got %v want %v", i, got, want.value)
+				}
+				if got := p.UnixNano(); got != want.unixNano {
+					t.Errorf("point %d: unexpected time: got %d want %d", i, got, want.unixNano)
+				}
+			}
+		})
+	}
+}
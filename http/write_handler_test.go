@@ -1,24 +1,36 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/kit/check"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/models"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestWriteService_Write(t *testing.T) {
@@ -49,7 +61,7 @@ func TestWriteService_Write(t *testing.T) {
 			var org, bucket *influxdb.ID
 			var lp []byte
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				org, _ = influxdb.IDFromString(r.URL.Query().Get("org"))
+				org, _ = influxdb.IDFromString(r.URL.Query().Get("orgID"))
 				bucket, _ = influxdb.IDFromString(r.URL.Query().Get("bucket"))
 				defer r.Body.Close()
 				in, _ := gzip.NewReader(r.Body)
@@ -78,6 +90,37 @@ func TestWriteService_Write(t *testing.T) {
 	}
 }
 
+func TestWriteService_Write_orgLookups(t *testing.T) {
+	b, pw := newV1APIBackend(t)
+	var lookups int
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		lookups++
+		return testOrg(v1OrgID), nil
+	}
+	orgs.FindOrganizationByIDF = func(context.Context, influxdb.ID) (*influxdb.Organization, error) {
+		lookups++
+		return testOrg(v1OrgID), nil
+	}
+	b.OrganizationService = orgs
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	ts := httptest.NewServer(httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID)))
+	defer ts.Close()
+
+	s := &WriteService{Addr: ts.URL}
+	for i := 0; i < 3; i++ {
+		if err := s.Write(context.Background(), influxtesting.MustIDBase16(v1OrgID), influxtesting.MustIDBase16(v1BucketID), strings.NewReader("m1 f1=1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := pw.WritePointsCalled(); got != 3 {
+		t.Errorf("unexpected number of writes: got %d want 3", got)
+	}
+	if lookups != 0 {
+		t.Errorf("unexpected org lookups: got %d want 0", lookups)
+	}
+}
+
 func TestWriteHandler_handleWrite(t *testing.T) {
 	// state is the internal state of org and bucket services
 	type state struct {
@@ -97,10 +140,12 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 
 	// request is sent to the HTTP endpoint
 	type request struct {
-		auth   influxdb.Authorizer
-		org    string
-		bucket string
-		body   string
+		auth    influxdb.Authorizer
+		org     string
+		orgID   string
+		bucket  string
+		body    string
+		verbose string
 	}
 
 	tests := []struct {
@@ -125,6 +170,178 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 				code: 204,
 			},
 		},
+		{
+			name: "org found by orgID",
+			request: request{
+				orgID:  "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 204,
+			},
+		},
+		{
+			name: "missing org defaults to the org of a single org token",
+			request: request{
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				orgErr: &influxdb.Error{Code: influxdb.EInternal, Msg: "org service called"},
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 204,
+			},
+		},
+		{
+			name: "missing org is rejected before the org service",
+			request: request{
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1",
+				auth:   multiOrgWritePermission("043e0780ee2b1000", "04504b356e23b000", "043e0780ee2b2000"),
+			},
+			state: state{
+				orgErr: &influxdb.Error{Code: influxdb.EInternal, Msg: "org service called"},
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"org or orgID required"}`,
+			},
+		},
+		{
+			name: "tags per point limit rejected",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1,t2=v2,t3=v3 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+				opts:   []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxTagsPerPoint: 2})},
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"points exceed write limits: measurement \"m1\": 3 tags exceed the limit of 2"}`,
+			},
+		},
+		{
+			name: "tag value length limit rejected",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1,t2=value2 f1=1,f2=2",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+				opts:   []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxTagValueLength: 4})},
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"points exceed write limits: measurement \"m1\": value of tag \"t2\" is longer than 4 bytes"}`,
+			},
+		},
+		{
+			name: "measurement length limit rejected",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1\nmeasurement,t1=v1 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+				opts:   []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxMeasurementLength: 4})},
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"points exceed write limits: measurement \"measurement\" is longer than 4 bytes"}`,
+			},
+		},
+		{
+			name: "points per request limit rejected",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1 f1=1\nm1,t1=v2 f1=2\nm1,t1=v3 f1=3",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+				opts:   []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxPointsPerRequest: 2})},
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"write of 3 points exceeds the limit of 2 points per request"}`,
+			},
+		},
+		{
+			name: "points within the write limits are accepted",
+			request: request{
+				org:    "043e0780ee2b1000",
+				bucket: "04504b356e23b000",
+				body:   "m1,t1=v1,t2=v2 f1=1",
+				auth:   bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+				opts:   []WriteHandlerOption{WithWriteLimits(WriteLimits{MaxTagsPerPoint: 2, MaxTagValueLength: 2, MaxMeasurementLength: 2, MaxPointsPerRequest: 1})},
+			},
+			wants: wants{
+				code: 204,
+			},
+		},
+		{
+			name: "verbose write reports the points written",
+			request: request{
+				org:     "043e0780ee2b1000",
+				bucket:  "04504b356e23b000",
+				body:    "m1,t1=v1 f1=1,f2=2\nm1,t1=v1 f1=3",
+				auth:    bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				verbose: "true",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 200,
+				body: `{"pointsWritten":3,"bytesReceived":32}` + "\n",
+			},
+		},
+		{
+			name: "invalid verbose is rejected",
+			request: request{
+				org:     "043e0780ee2b1000",
+				bucket:  "04504b356e23b000",
+				body:    "m1,t1=v1 f1=1",
+				auth:    bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"),
+				verbose: "yes",
+			},
+			state: state{
+				org:    testOrg("043e0780ee2b1000"),
+				bucket: testBucket("043e0780ee2b1000", "04504b356e23b000"),
+			},
+			wants: wants{
+				code: 400,
+				body: `{"code":"invalid","message":"verbose must be a boolean: strconv.ParseBool: parsing \"yes\": invalid syntax"}`,
+			},
+		},
 		{
 			name: "points writer error is an internal error",
 			request: request{
@@ -239,7 +456,7 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 			},
 			wants: wants{
 				code: 400,
-				body: `{"code":"invalid","message":"unable to parse 'invalid': missing fields"}`,
+				body: `{"code":"invalid","message":"unable to parse line 1 \"invalid\": missing fields","line":1,"snippet":"invalid"}`,
 			},
 		},
 		{
@@ -378,6 +595,12 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 
 			params := r.URL.Query()
 			params.Set("org", tt.request.org)
+			if tt.request.orgID != "" {
+				params.Set("orgID", tt.request.orgID)
+			}
+			if tt.request.verbose != "" {
+				params.Set("verbose", tt.request.verbose)
+			}
 			params.Set("bucket", tt.request.bucket)
 			r.URL.RawQuery = params.Encode()
 
@@ -394,8 +617,373 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+func TestNewWriteHandler_nilLogger(t *testing.T) {
+	b := &APIBackend{
+		HTTPErrorHandler:   DefaultErrorHandler,
+		PointsWriter:       &mock.PointsWriter{},
+		WriteEventRecorder: &metric.NopEventRecorder{},
+	}
+	h := NewWriteHandler(nil, NewWriteBackend(zaptest.NewLogger(t), b))
+	if h.log == nil {
+		t.Fatal("expected a default logger")
+	}
+	if !h.log.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("expected the default logger to be a development logger")
+	}
+}
+
+func TestWriteHandler_handleReady(t *testing.T) {
+	tests := []struct {
+		name      string
+		bucketErr error
+		orgErr    error
+		dbrps     bool
+		dbrpErr   error
+		code      int
+	}{
+		{
+			name: "ready when dependencies respond",
+			code: http.StatusOK,
+		},
+		{
+			name:      "unavailable when bucket service errors",
+			bucketErr: &influxdb.Error{Code: influxdb.EInternal, Msg: "bucket store down"},
+			code:      http.StatusServiceUnavailable,
+		},
+		{
+			name:   "unavailable when org service errors",
+			orgErr: &influxdb.Error{Code: influxdb.EInternal, Msg: "org store down"},
+			code:   http.StatusServiceUnavailable,
+		},
+		{
+			name:  "ready when the dbrp mapping service responds",
+			dbrps: true,
+			code:  http.StatusOK,
+		},
+		{
+			name:    "unavailable when dbrp mapping service errors",
+			dbrps:   true,
+			dbrpErr: &influxdb.Error{Code: influxdb.EInternal, Msg: "dbrp store down"},
+			code:    http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationsF = func(context.Context, influxdb.OrganizationFilter, ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+				return nil, 0, tt.orgErr
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketsFn = func(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+				return nil, 0, tt.bucketErr
+			}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        &mock.PointsWriter{},
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			if tt.dbrps {
+				b.DBRPService = &mock.DBRPMappingServiceV2{
+					FindManyFn: func(context.Context, influxdb.DBRPMappingFilterV2, ...influxdb.FindOptions) ([]*influxdb.DBRPMappingV2, int, error) {
+						return nil, 0, tt.dbrpErr
+					},
+				}
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+
+			w := httptest.NewRecorder()
+			writeHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:9999"+prefixWriteReady, nil))
+			if got, want := w.Code, tt.code; got != want {
+				t.Errorf("unexpected status code: got %d want %d", got, want)
+			}
+
+			var resp check.Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !resp.HasCheck("bucketService") || !resp.HasCheck("organizationService") || !resp.HasCheck("pointsWriter") {
+				t.Errorf("missing dependency checks: %+v", resp.Checks)
+			}
+			if got := resp.HasCheck("dbrpMappingService"); got != tt.dbrps {
+				t.Errorf("unexpected dbrp mapping service check: got %t want %t", got, tt.dbrps)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleReady_cached(t *testing.T) {
+	var finds int
+	buckets := mock.NewBucketService()
+	buckets.FindBucketsFn = func(context.Context, influxdb.BucketFilter, ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+		finds++
+		return nil, 0, nil
+	}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: mock.NewOrganizationService(),
+		BucketService:       buckets,
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		writeHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:9999"+prefixWriteReady, nil))
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("unexpected status code: got %d want %d", got, want)
+		}
+	}
+	if finds != 1 {
+		t.Errorf("expected the ready check to be reused, got %d bucket lookups", finds)
+	}
+}
+
+func TestWriteHandler_handleHealth(t *testing.T) {
+	// the health check is a liveness check, which passes whatever the
+	// dependencies of the handler.
+	b := &APIBackend{
+		HTTPErrorHandler:   DefaultErrorHandler,
+		WriteEventRecorder: &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+
+	w := httptest.NewRecorder()
+	writeHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:9999"+prefixWriteHealth, nil))
+	if got, want := w.Code, http.StatusOK; got != want {
+		t.Errorf("unexpected health status code: got %d want %d", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	writeHandler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost:9999"+prefixWriteReady, nil))
+	if got, want := w.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("unexpected ready status code: got %d want %d", got, want)
+	}
+}
+
+func TestPointsParser_Logger(t *testing.T) {
+	body := func() io.ReadCloser { return ioutil.NopCloser(strings.NewReader("m1,t1=v1 f1=")) }
+
+	// the default logger is a no-op one rather than nil.
+	if _, err := NewPointsParser().ParsePoints(context.Background(), 1, 2, body()); err == nil {
+		t.Fatal("expected a parse error")
+	}
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	if _, err := NewPointsParser().WithLogger(zap.New(core)).ParsePoints(context.Background(), 1, 2, body()); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if got := logs.FilterMessage("Error parsing points").Len(); got != 1 {
+		t.Errorf("expected the parse error to be logged, got %d entries", got)
+	}
+}
+
+func TestPointsParser_ParsePoints_canceled(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte("m1,t1=v1 f1=1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := &closeRecorder{Reader: &buf}
+	rc, err := PointBatchReadCloser(body, "gzip", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewPointsParser().ParsePoints(ctx, 1, 2, rc)
+	if err != context.Canceled {
+		t.Errorf("unexpected error: got %v want %v", err, context.Canceled)
+	}
+	if !body.closed {
+		t.Error("expected request body to be closed")
+	}
+}
+
+// blockingBody blocks every Read until unblocked, recording when it is closed.
+type blockingBody struct {
+	unblock chan struct{}
+	reading chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (b *blockingBody) Read([]byte) (int, error) {
+	b.once.Do(func() { close(b.reading) })
+	<-b.unblock
+	return 0, io.EOF
+}
+
+func (b *blockingBody) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestReadAll_canceledWhileBlocked(t *testing.T) {
+	body := &blockingBody{
+		unblock: make(chan struct{}),
+		reading: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := readAll(ctx, body)
+		done <- err
+	}()
+
+	<-body.reading
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("unexpected error: got %v want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked read was not interrupted by the canceled context")
+	}
+
+	// the body is closed once the blocked read returns, not while it is read.
+	select {
+	case <-body.closed:
+		t.Fatal("body closed while it was read")
+	default:
+	}
+	close(body.unblock)
+	select {
+	case <-body.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("body not closed once the read returned")
+	}
+}
+
+func TestWriteHandler_malformedGzip(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.Write([]byte(s))
+		gw.Close()
+		return buf.Bytes()
+	}
+	lines := gzipped("m1,t1=v1 f1=1\nm1,t1=v2 f1=2")
+	corrupt := append([]byte(nil), lines...)
+	corrupt[len(corrupt)-5] ^= 0xff
+
+	tests := []struct {
+		name string
+		path string
+		body []byte
+		msg  string
+	}{
+		{
+			name: "plaintext body",
+			path: prefixWrite + "?org=" + orgID + "&bucket=" + bucketID,
+			body: []byte("m1,t1=v1 f1=1"),
+			msg:  msgInvalidGzipHeader,
+		},
+		{
+			name: "truncated body",
+			path: prefixWrite + "?org=" + orgID + "&bucket=" + bucketID,
+			body: lines[:len(lines)-10],
+			msg:  msgInvalidGzipBody,
+		},
+		{
+			name: "corrupt checksum",
+			path: prefixWrite + "?org=" + orgID + "&bucket=" + bucketID,
+			body: corrupt,
+			msg:  msgInvalidGzipBody,
+		},
+		{
+			name: "plaintext batch",
+			path: prefixWriteBatch,
+			body: []byte(`{"writes":[]}`),
+			msg:  msgInvalidGzipHeader,
+		},
+		{
+			name: "truncated batch",
+			path: prefixWriteBatch,
+			body: gzipped(`{"writes":[]}`)[:10],
+			msg:  msgInvalidGzipBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999"+tt.path, bytes.NewReader(tt.body))
+			r.Header.Set("Content-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != http.StatusBadRequest {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, http.StatusBadRequest, w.Body.String())
+			}
+			if got := w.Body.String(); !strings.Contains(got, tt.msg) {
+				t.Errorf("unexpected body: got %s want message %q", got, tt.msg)
+			}
+			if len(pw.Points) != 0 {
+				t.Errorf("unexpected points written: %v", pw.Points)
+			}
+		})
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
 var DefaultErrorHandler = kithttp.ErrorHandler(0)
 
+// multiOrgWritePermission is bucketWritePermission along with writing the
+// buckets of otherOrg, so that the org of the token is ambiguous.
+func multiOrgWritePermission(org, bucket, otherOrg string) *influxdb.Authorization {
+	a := bucketWritePermission(org, bucket)
+	oid := influxtesting.MustIDBase16(otherOrg)
+	a.Permissions = append(a.Permissions, influxdb.Permission{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, OrgID: &oid},
+	})
+	return a
+}
+
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
 	oid := influxtesting.MustIDBase16(org)
 	bid := influxtesting.MustIDBase16(bucket)
@@ -431,3 +1019,445 @@ func testBucket(org, bucket string) *influxdb.Bucket {
 		OrgID: oid,
 	}
 }
+
+func TestWriteService_Write_compression(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+		lp       = "m1,t1=v1 f1=1\nm1,t1=v2 f1=2"
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+
+	tests := []struct {
+		name     string
+		svc      func(addr string) *WriteService
+		encoding string
+	}{
+		{
+			name:     "gzip by default",
+			svc:      func(addr string) *WriteService { return &WriteService{Addr: addr} },
+			encoding: "gzip",
+		},
+		{
+			name: "configured level",
+			svc: func(addr string) *WriteService {
+				return (&WriteService{Addr: addr}).WithCompression(gzip.BestCompression)
+			},
+			encoding: "gzip",
+		},
+		{
+			name: "compression disabled",
+			svc: func(addr string) *WriteService {
+				return (&WriteService{Addr: addr}).WithCompression(gzip.NoCompression)
+			},
+		},
+		{
+			name: "payload under the minimum size",
+			svc: func(addr string) *WriteService {
+				return &WriteService{Addr: addr, MinCompressSize: 1024}
+			},
+		},
+		{
+			name: "payload over the minimum size",
+			svc: func(addr string) *WriteService {
+				return &WriteService{Addr: addr, MinCompressSize: 8}
+			},
+			encoding: "gzip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw := &mock.PointsWriter{}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			var encoding string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding = r.Header.Get("Content-Encoding")
+				handler.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			err := tt.svc(ts.URL).Write(context.Background(), influxtesting.MustIDBase16(orgID), influxtesting.MustIDBase16(bucketID), strings.NewReader(lp))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if encoding != tt.encoding {
+				t.Errorf("unexpected content encoding: got %q want %q", encoding, tt.encoding)
+			}
+			if got := len(pw.Points); got != 2 {
+				t.Errorf("unexpected points written: got %d want 2", got)
+			}
+		})
+	}
+
+	t.Run("invalid level", func(t *testing.T) {
+		s := (&WriteService{Addr: "http://localhost:9999"}).WithCompression(42)
+		err := s.Write(context.Background(), 1, 2, strings.NewReader(lp))
+		if got := influxdb.ErrorCode(err); got != influxdb.EInvalid {
+			t.Errorf("unexpected error code: got %q want %q", got, influxdb.EInvalid)
+		}
+	})
+}
+
+func TestWriteService_Write_failover(t *testing.T) {
+	const lp = "m1,t1=v1 f1=1"
+
+	// server answers writes with code and records the bodies it received.
+	type server struct {
+		*httptest.Server
+		code   int
+		bodies []string
+	}
+	newServer := func(code int) *server {
+		s := &server{code: code}
+		s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			s.bodies = append(s.bodies, string(body))
+			w.WriteHeader(s.code)
+		}))
+		return s
+	}
+	write := func(s *WriteService) error {
+		s.WithCompression(gzip.NoCompression)
+		return s.Write(context.Background(), 1, 2, strings.NewReader(lp))
+	}
+
+	t.Run("fails over to the healthy host and prefers it", func(t *testing.T) {
+		failing, healthy := newServer(http.StatusServiceUnavailable), newServer(http.StatusNoContent)
+		defer failing.Close()
+		defer healthy.Close()
+
+		s := &WriteService{Addrs: []string{failing.URL, healthy.URL}}
+		for i := 0; i < 2; i++ {
+			if err := write(s); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if got := len(failing.bodies); got != 1 {
+			t.Errorf("unexpected writes to the failing host: got %d want 1", got)
+		}
+		if got := strings.Join(healthy.bodies, "\n"); got != lp+"\n"+lp {
+			t.Errorf("unexpected bodies written to the healthy host: %q", got)
+		}
+	})
+
+	t.Run("fails over on connection errors", func(t *testing.T) {
+		down, healthy := newServer(http.StatusNoContent), newServer(http.StatusNoContent)
+		down.Close()
+		defer healthy.Close()
+
+		s := &WriteService{Addrs: []string{down.URL, healthy.URL}}
+		if err := write(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := len(healthy.bodies); got != 1 {
+			t.Errorf("unexpected writes to the healthy host: got %d want 1", got)
+		}
+	})
+
+	t.Run("client errors are not failed over", func(t *testing.T) {
+		rejecting, healthy := newServer(http.StatusBadRequest), newServer(http.StatusNoContent)
+		defer rejecting.Close()
+		defer healthy.Close()
+
+		s := &WriteService{Addrs: []string{rejecting.URL, healthy.URL}}
+		if err := write(s); err == nil {
+			t.Fatal("expected an error")
+		}
+		if got := len(healthy.bodies); got != 0 {
+			t.Errorf("unexpected writes to the other host: got %d want 0", got)
+		}
+	})
+
+	t.Run("all hosts failing", func(t *testing.T) {
+		first, second := newServer(http.StatusInternalServerError), newServer(http.StatusServiceUnavailable)
+		defer first.Close()
+		defer second.Close()
+
+		err := write(&WriteService{Addrs: []string{first.URL, second.URL}})
+		if got := influxdb.ErrorCode(err); got != influxdb.EUnavailable {
+			t.Fatalf("unexpected error code: got %q want %q", got, influxdb.EUnavailable)
+		}
+		for _, u := range []string{first.URL, second.URL} {
+			if !strings.Contains(err.Error(), u) {
+				t.Errorf("expected error to name host %s: %v", u, err)
+			}
+		}
+	})
+}
+
+func TestWriteHandler_handleWrite_transformPoints(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name      string
+		transform PointsTransformer
+		code      int
+		tenant    string
+	}{
+		{
+			name: "points are written as transformed",
+			transform: func(ctx context.Context, points []models.Point) ([]models.Point, error) {
+				auth, err := pcontext.GetAuthorizer(ctx)
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range points {
+					p.AddTag("tenant", auth.(*influxdb.Authorization).OrgID.String())
+				}
+				return points, nil
+			},
+			code:   http.StatusNoContent,
+			tenant: orgID,
+		},
+		{
+			name: "transform errors are invalid",
+			transform: func(context.Context, []models.Point) ([]models.Point, error) {
+				return nil, errors.New("missing tenant")
+			},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			writeHandler.TransformPoints = tt.transform
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader("m1,t1=v1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if tt.tenant == "" {
+				if len(pw.Points) != 0 {
+					t.Errorf("unexpected points written: %v", pw.Points)
+				}
+				return
+			}
+			if len(pw.Points) != 1 {
+				t.Fatalf("unexpected points written: %v", pw.Points)
+			}
+			if got := string(pw.Points[0].Tags().Get([]byte("tenant"))); got != tt.tenant {
+				t.Errorf("unexpected tenant tag: got %q want %q", got, tt.tenant)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_tracer(t *testing.T) {
+	tracer := mocktracer.New()
+	writeHandler, _ := newV1WriteHandler(t, WithTracer(tracer))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+	r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader("m1 f1=1"))
+	parent := tracer.StartSpan("client write")
+	if err := tracer.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Code; got != http.StatusNoContent {
+		t.Fatalf("unexpected status code: got %d want %d", got, http.StatusNoContent)
+	}
+
+	var span *mocktracer.MockSpan
+	for _, s := range tracer.FinishedSpans() {
+		if s.OperationName == "request" {
+			span = s
+		}
+	}
+	if span == nil {
+		t.Fatal("expected the write to be traced by the configured tracer")
+	}
+	if got, want := span.ParentID, parent.(*mocktracer.MockSpan).SpanContext.SpanID; got != want {
+		t.Errorf("unexpected parent span: got %d want %d", got, want)
+	}
+	if got := span.Tag("org_id"); got != v1OrgID {
+		t.Errorf("unexpected org_id tag: %v", got)
+	}
+	if got := span.Tag("bucket_id"); got != v1BucketID {
+		t.Errorf("unexpected bucket_id tag: %v", got)
+	}
+}
+
+func TestWriteHandler_handleWrite_requestID(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	// the tenant is resolved by a remote service, which records the
+	// request id of each call.
+	var ids []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.URL.Path+" "+r.Header.Get("X-Request-Id"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case prefixOrganizations:
+			fmt.Fprintf(w, `{"orgs":[{"id":%q,"name":"org"}]}`, orgID)
+		case prefixBuckets:
+			fmt.Fprintf(w, `{"buckets":[{"id":%q,"orgID":%q,"name":"bucket","retentionRules":[]}]}`, bucketID, orgID)
+		}
+	}))
+	defer ts.Close()
+	client := mustNewHTTPClient(t, ts.URL, "")
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: &OrganizationService{Client: client},
+		BucketService:       &BucketService{Client: client},
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	t.Run("request id of the write", func(t *testing.T) {
+		ids = nil
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org=org&bucket=bucket", strings.NewReader("m1 f1=1"))
+		r.Header.Set("X-Request-Id", "req-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Code; got != http.StatusNoContent {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusNoContent, w.Body.String())
+		}
+		if got, want := strings.Join(ids, ","), prefixOrganizations+" req-1,"+prefixBuckets+" req-1"; got != want {
+			t.Errorf("unexpected downstream requests: got %s want %s", got, want)
+		}
+	})
+
+	t.Run("generated request id", func(t *testing.T) {
+		ids = nil
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org=org&bucket=bucket", strings.NewReader("m1 f1=1"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Code; got != http.StatusNoContent {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusNoContent, w.Body.String())
+		}
+		if len(ids) != 2 {
+			t.Fatalf("unexpected downstream requests: %v", ids)
+		}
+		for _, id := range ids {
+			if strings.HasSuffix(id, " ") {
+				t.Errorf("expected a request id on downstream request %q", id)
+			}
+		}
+		if strings.Split(ids[0], " ")[1] != strings.Split(ids[1], " ")[1] {
+			t.Errorf("expected downstream requests to share the request id: %v", ids)
+		}
+	})
+}
+
+func TestWriteHandler_handleWrite_orgIDLookup(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case prefixOrganizations:
+			fmt.Fprintf(w, `{"orgs":[{"id":%q,"name":"org"}]}`, orgID)
+		case prefixBuckets:
+			fmt.Fprintf(w, `{"buckets":[{"id":%q,"orgID":%q,"name":"bucket","retentionRules":[]}]}`, bucketID, orgID)
+		}
+	}))
+	defer ts.Close()
+	client := mustNewHTTPClient(t, ts.URL, "")
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: &OrganizationService{Client: client},
+		BucketService:       &BucketService{Client: client},
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	tests := []struct {
+		name  string
+		query string
+		calls []string
+	}{
+		{
+			name:  "orgID skips the org lookup",
+			query: "orgID=" + orgID + "&bucket=bucket",
+			calls: []string{prefixBuckets},
+		},
+		{
+			name:  "org name",
+			query: "org=org&bucket=bucket",
+			calls: []string{prefixOrganizations, prefixBuckets},
+		},
+		{
+			name:  "invalid orgID",
+			query: "orgID=org&bucket=bucket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			want := http.StatusNoContent
+			if tt.calls == nil {
+				want = http.StatusBadRequest
+			}
+			if got := w.Code; got != want {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, want, w.Body.String())
+			}
+			if got, want := strings.Join(calls, ","), strings.Join(tt.calls, ","); got != want {
+				t.Errorf("unexpected downstream requests: got %s want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+)
+
+const (
+	// v1 clients address the points they write by database and retention
+	// policy and authenticate with a username and password.
+	paramV1Database        = "db"
+	paramV1RetentionPolicy = "rp"
+	paramV1Username        = "u"
+	paramV1Password        = "p"
+
+	opWriteHandlerV1 = "http/writeHandlerV1"
+)
+
+// V1Authorizer validates the username and password sent by InfluxDB 1.x
+// clients.
+type V1Authorizer interface {
+	// AuthorizeV1 returns the authorizer for the credentials. Invalid
+	// credentials return an error.
+	AuthorizeV1(ctx context.Context, username, password string) (influxdb.Authorizer, error)
+}
+
+// V1AuthorizerFunc adapts a function to a V1Authorizer.
+type V1AuthorizerFunc func(ctx context.Context, username, password string) (influxdb.Authorizer, error)
+
+// AuthorizeV1 calls fn.
+func (fn V1AuthorizerFunc) AuthorizeV1(ctx context.Context, username, password string) (influxdb.Authorizer, error) {
+	return fn(ctx, username, password)
+}
+
+// WithV1Authorizer authorizes v1 writes that carry credentials in the u and p
+// query parameters or in a basic Authorization header with a. The credentials
+// take precedence over the authorizer on the request context. Without a
+// V1Authorizer the credentials are ignored. The handlers of NewPlatformHandler
+// authenticate them with APIBackend.V1Authorizer instead.
+func WithV1Authorizer(a V1Authorizer) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.v1Authorizer = a
+	}
+}
+
+// isV1 reports whether the request addresses its bucket by database and
+// retention policy rather than by bucket.
+func (r *writeRequest) isV1() bool {
+	return r.Bucket == "" && r.Database != ""
+}
+
+// v1Precision maps the precision of a v1 write to its v2 name. v1 clients send
+// n and u for nanoseconds and microseconds. Other values are returned as is to
+// be validated as v2 precisions.
+func v1Precision(precision string) string {
+	switch precision {
+	case "n":
+		return "ns"
+	case "u":
+		return "us"
+	}
+	return precision
+}
+
+// authorize returns the authorizer for a write. v1 writes with credentials
+// are authorized by the V1Authorizer, everything else by the authorizer on
+// the request context.
+func (h *WriteHandler) authorize(ctx context.Context, r *http.Request, req *writeRequest) (influxdb.Authorizer, error) {
+	if h.v1Authorizer == nil || !req.isV1() {
+		return pcontext.GetAuthorizer(ctx)
+	}
+
+	username, password, ok := v1Credentials(r)
+	if !ok {
+		return pcontext.GetAuthorizer(ctx)
+	}
+
+	auth, err := h.v1Authorizer.AuthorizeV1(ctx, username, password)
+	if err != nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EUnauthorized,
+			Op:   opWriteHandlerV1,
+			Msg:  "invalid v1 credentials",
+			Err:  err,
+		}
+	}
+	return auth, nil
+}
+
+// isV1WriteRequest reports whether r is a v1 write, one to the write route
+// addressing its bucket by database.
+func isV1WriteRequest(r *http.Request) bool {
+	qp := r.URL.Query()
+	return r.Method == http.MethodPost && r.URL.Path == prefixWrite &&
+		qp.Get(Bucket) == "" && qp.Get(paramV1Database) != ""
+}
+
+// v1Credentials returns the credentials of a v1 request, preferring the u and
+// p query parameters over basic auth.
+func v1Credentials(r *http.Request) (username, password string, ok bool) {
+	qp := r.URL.Query()
+	if username = qp.Get(paramV1Username); username != "" {
+		return username, qp.Get(paramV1Password), true
+	}
+	return r.BasicAuth()
+}
+
+// findTenantV1 resolves the org and bucket of a v1 write through the dbrp
+// mapping of its database and retention policy. When several orgs map the
+// same database and retention policy the org or orgID parameter picks one.
+func (h *WriteHandler) findTenantV1(ctx context.Context, r *http.Request, req *writeRequest) (*influxdb.Organization, *influxdb.Bucket, error) {
+	if h.DBRPMappingService == nil {
+		return nil, nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Op:   opWriteHandlerV1,
+			Msg:  "bucket not found",
+		}
+	}
+
+	mappings, err := h.findDBRPMappings(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var org *influxdb.Organization
+	if qp := r.URL.Query(); qp.Get(Org) != "" || qp.Get(OrgID) != "" {
+		if org, err = queryOrganization(ctx, r, h.OrganizationService); err != nil {
+			return nil, nil, err
+		}
+		mappings = filterDBRPMappingsByOrg(mappings, org.ID)
+	}
+
+	switch len(mappings) {
+	case 0:
+		return nil, nil, &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Op:   opWriteHandlerV1,
+			Msg:  fmt.Sprintf("no dbrp mapping found for database %q and retention policy %q", req.Database, req.RetentionPolicy),
+		}
+	case 1:
+	default:
+		return nil, nil, &influxdb.Error{
+			Code: influxdb.EConflict,
+			Op:   opWriteHandlerV1,
+			Msg:  fmt.Sprintf("multiple dbrp mappings found for database %q and retention policy %q", req.Database, req.RetentionPolicy),
+		}
+	}
+
+	if org == nil {
+		if org, err = h.OrganizationService.FindOrganizationByID(ctx, mappings[0].OrganizationID); err != nil {
+			return nil, nil, err
+		}
+	}
+	bucket, err := h.BucketService.FindBucketByID(ctx, mappings[0].BucketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return org, bucket, nil
+}
+
+func filterDBRPMappingsByOrg(mappings []*influxdb.DBRPMappingV2, orgID influxdb.ID) []*influxdb.DBRPMappingV2 {
+	var filtered []*influxdb.DBRPMappingV2
+	for _, m := range mappings {
+		if m.OrganizationID == orgID {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// findDBRPMappings returns the dbrp mappings of a v1 write. As in v1, writes
+// without a retention policy use the default mapping of the database, and
+// only fall back to a mapping with an empty retention policy without one.
+func (h *WriteHandler) findDBRPMappings(ctx context.Context, req *writeRequest) ([]*influxdb.DBRPMappingV2, error) {
+	if req.RetentionPolicy == "" {
+		mappings, err := h.findDefaultDBRPMappings(ctx, req.Database)
+		if err != nil || len(mappings) > 0 {
+			return mappings, err
+		}
+	}
+
+	mappings, _, err := h.DBRPMappingService.FindMany(ctx, influxdb.DBRPMappingFilterV2{
+		Database:        &req.Database,
+		RetentionPolicy: &req.RetentionPolicy,
+	})
+	return mappings, err
+}
+
+// findDefaultDBRPMappings returns the default mappings of database, at most
+// one for every org.
+func (h *WriteHandler) findDefaultDBRPMappings(ctx context.Context, database string) ([]*influxdb.DBRPMappingV2, error) {
+	isDefault := true
+	mappings, _, err := h.DBRPMappingService.FindMany(ctx, influxdb.DBRPMappingFilterV2{
+		Database: &database,
+		Default:  &isDefault,
+	})
+	return mappings, err
+}
@@ -0,0 +1,407 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/kit/feature"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	v1OrgID    = "043e0780ee2b1000"
+	v1BucketID = "04504b356e23b000"
+)
+
+// newV1WriteHandler returns a write handler that maps the telegraf database
+// and its default autogen retention policy to v1BucketID.
+func newV1WriteHandler(t *testing.T, opts ...WriteHandlerOption) (*WriteHandler, *mock.PointsWriter) {
+	t.Helper()
+
+	b, pw := newV1APIBackend(t)
+	return NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), opts...), pw
+}
+
+// newV1APIBackend returns the backend of newV1WriteHandler.
+func newV1APIBackend(t *testing.T) (*APIBackend, *mock.PointsWriter) {
+	t.Helper()
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationByIDF = func(_ context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+		return testOrg(v1OrgID), nil
+	}
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(v1OrgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+		return testBucket(v1OrgID, id.String()), nil
+	}
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(v1OrgID, v1BucketID), nil
+	}
+	dbrps := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(_ context.Context, f influxdb.DBRPMappingFilterV2, _ ...influxdb.FindOptions) ([]*influxdb.DBRPMappingV2, int, error) {
+			switch {
+			case *f.Database != "telegraf":
+				return nil, 0, nil
+			case f.Default != nil:
+				// the autogen mapping is the default of telegraf
+			case *f.RetentionPolicy != "autogen":
+				return nil, 0, nil
+			}
+			return []*influxdb.DBRPMappingV2{{
+				Database:        "telegraf",
+				RetentionPolicy: "autogen",
+				Default:         true,
+				OrganizationID:  influxtesting.MustIDBase16(v1OrgID),
+				BucketID:        influxtesting.MustIDBase16(v1BucketID),
+			}}, 1, nil
+		},
+	}
+
+	pw := &mock.PointsWriter{}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		DBRPService:         dbrps,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	return b, pw
+}
+
+func TestWriteHandler_handleWrite_v1(t *testing.T) {
+	const otherBucketID = "04504b356e23b001"
+
+	users := V1AuthorizerFunc(func(_ context.Context, username, password string) (influxdb.Authorizer, error) {
+		switch {
+		case username == "writer" && password == "secret":
+			return bucketWritePermission(v1OrgID, v1BucketID), nil
+		case username == "other" && password == "secret":
+			return bucketWritePermission(v1OrgID, otherBucketID), nil
+		}
+		return nil, errors.New("wrong username or password")
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		basic []string
+		auth  influxdb.Authorizer
+		code  int
+	}{
+		{
+			name:  "query credentials",
+			query: "db=telegraf&rp=autogen&u=writer&p=secret",
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "basic auth credentials",
+			query: "db=telegraf&rp=autogen",
+			basic: []string{"writer", "secret"},
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "query credentials take precedence over basic auth",
+			query: "db=telegraf&rp=autogen&u=writer&p=secret",
+			basic: []string{"writer", "letmein"},
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "invalid credentials",
+			query: "db=telegraf&rp=autogen&u=writer&p=letmein",
+			auth:  bucketWritePermission(v1OrgID, v1BucketID),
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name:  "credentials without access to the mapped bucket",
+			query: "db=telegraf&rp=autogen&u=other&p=secret",
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "no credentials uses the request authorizer",
+			query: "db=telegraf&rp=autogen",
+			auth:  bucketWritePermission(v1OrgID, v1BucketID),
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "empty retention policy uses the default mapping",
+			query: "db=telegraf&u=writer&p=secret",
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "unmapped retention policy",
+			query: "db=telegraf&rp=weekly&u=writer&p=secret",
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "unmapped database without a retention policy",
+			query: "db=unknown&u=writer&p=secret",
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "unmapped database",
+			query: "db=unknown&rp=autogen&u=writer&p=secret",
+			code:  http.StatusNotFound,
+		},
+		{
+			name:  "v2 writes ignore v1 credentials",
+			query: "org=" + v1OrgID + "&bucket=" + v1BucketID + "&u=writer&p=letmein",
+			auth:  bucketWritePermission(v1OrgID, v1BucketID),
+			code:  http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeHandler, _ := newV1WriteHandler(t, WithV1Authorizer(users))
+			var handler http.Handler = writeHandler
+			if tt.auth != nil {
+				handler = httpmock.NewAuthMiddlewareHandler(writeHandler, tt.auth)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader("m1 f1=1"))
+			if tt.basic != nil {
+				r.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "letmein") {
+				t.Errorf("response leaks the credentials: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPlatformHandler_v1Write(t *testing.T) {
+	users := V1AuthorizerFunc(func(_ context.Context, username, password string) (influxdb.Authorizer, error) {
+		if username == "writer" && password == "secret" {
+			return bucketWritePermission(v1OrgID, v1BucketID), nil
+		}
+		return nil, errors.New("wrong username or password")
+	})
+
+	tests := []struct {
+		name       string
+		authorizer V1Authorizer
+		query      string
+		basic      []string
+		token      string
+		code       int
+	}{
+		{
+			name:       "query credentials",
+			authorizer: users,
+			query:      "db=telegraf&rp=autogen&u=writer&p=secret",
+			code:       http.StatusNoContent,
+		},
+		{
+			name:       "basic auth credentials",
+			authorizer: users,
+			query:      "db=telegraf&rp=autogen",
+			basic:      []string{"writer", "secret"},
+			code:       http.StatusNoContent,
+		},
+		{
+			name:       "invalid credentials",
+			authorizer: users,
+			query:      "db=telegraf&rp=autogen&u=writer&p=letmein",
+			code:       http.StatusUnauthorized,
+		},
+		{
+			name:       "token",
+			authorizer: users,
+			query:      "db=telegraf&rp=autogen",
+			token:      "mytoken",
+			code:       http.StatusNoContent,
+		},
+		{
+			name:       "v2 writes require a token",
+			authorizer: users,
+			query:      "org=" + v1OrgID + "&bucket=" + v1BucketID + "&u=writer&p=secret",
+			code:       http.StatusUnauthorized,
+		},
+		{
+			name:  "credentials without a v1 authorizer",
+			query: "db=telegraf&rp=autogen&u=writer&p=secret",
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newV1APIBackend(t)
+			b.Logger = zaptest.NewLogger(t)
+			b.Flagger = feature.DefaultFlagger()
+			b.V1Authorizer = tt.authorizer
+			auths := mock.NewAuthorizationService()
+			auths.FindAuthorizationByTokenFn = func(_ context.Context, token string) (*influxdb.Authorization, error) {
+				if token != "mytoken" {
+					return nil, errors.New("unknown token")
+				}
+				return bucketWritePermission(v1OrgID, v1BucketID), nil
+			}
+			b.AuthorizationService = auths
+			handler := NewPlatformHandler(b)
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader("m1 f1=1"))
+			if tt.basic != nil {
+				r.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			if tt.token != "" {
+				SetToken(tt.token, r)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_v1Precision(t *testing.T) {
+	want := time.Unix(1600000000, 0)
+	tests := []struct {
+		name      string
+		precision string
+		body      string
+		code      int
+	}{
+		{name: "empty precision is nanoseconds", body: "m1 f1=1 1600000000000000000", code: http.StatusNoContent},
+		{name: "nanoseconds", precision: "n", body: "m1 f1=1 1600000000000000000", code: http.StatusNoContent},
+		{name: "microseconds", precision: "u", body: "m1 f1=1 1600000000000000", code: http.StatusNoContent},
+		{name: "milliseconds", precision: "ms", body: "m1 f1=1 1600000000000", code: http.StatusNoContent},
+		{name: "seconds", precision: "s", body: "m1 f1=1 1600000000", code: http.StatusNoContent},
+		{name: "unsupported precision", precision: "h", body: "m1 f1=1 444444", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeHandler, pw := newV1WriteHandler(t)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?db=telegraf&rp=autogen&precision="+tt.precision, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if tt.code != http.StatusNoContent {
+				return
+			}
+			if len(pw.Points) != 1 {
+				t.Fatalf("unexpected points written: %v", pw.Points)
+			}
+			if got := pw.Points[0].Time(); !got.Equal(want) {
+				t.Errorf("unexpected point time: got %v want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_v1MultipleOrgs(t *testing.T) {
+	const (
+		orgA    = "043e0780ee2b1000"
+		orgB    = "043e0780ee2b2000"
+		bucketA = "04504b356e23b000"
+		bucketB = "04504b356e23c000"
+	)
+	orgNames := map[string]string{"a": orgA, "b": orgB}
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationByIDF = func(_ context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+		return testOrg(id.String()), nil
+	}
+	orgs.FindOrganizationF = func(_ context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		if f.ID != nil {
+			return testOrg(f.ID.String()), nil
+		}
+		if id, ok := orgNames[*f.Name]; ok {
+			return testOrg(id), nil
+		}
+		return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "organization not found"}
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+		if id.String() == bucketB {
+			return testBucket(orgB, bucketB), nil
+		}
+		return testBucket(orgA, bucketA), nil
+	}
+	dbrps := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(context.Context, influxdb.DBRPMappingFilterV2, ...influxdb.FindOptions) ([]*influxdb.DBRPMappingV2, int, error) {
+			return []*influxdb.DBRPMappingV2{
+				{Database: "telegraf", RetentionPolicy: "autogen", OrganizationID: influxtesting.MustIDBase16(orgA), BucketID: influxtesting.MustIDBase16(bucketA)},
+				{Database: "telegraf", RetentionPolicy: "autogen", OrganizationID: influxtesting.MustIDBase16(orgB), BucketID: influxtesting.MustIDBase16(bucketB)},
+			}, 2, nil
+		},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		code   int
+		bucket string
+	}{
+		{name: "org name picks the mapping", query: "&org=b", code: http.StatusNoContent, bucket: bucketB},
+		{name: "org id picks the mapping", query: "&orgID=" + orgA, code: http.StatusNoContent, bucket: bucketA},
+		{name: "ambiguous without an org", code: http.StatusUnprocessableEntity},
+		{name: "org without a mapping", query: "&orgID=043e0780ee2b3000", code: http.StatusNotFound},
+		{name: "unknown org", query: "&org=c", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw := &mock.PointsWriter{}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				DBRPService:         dbrps,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			auth := &influxdb.Authorization{
+				Status: influxdb.Active,
+				Permissions: append(
+					bucketWritePermission(orgA, bucketA).Permissions,
+					bucketWritePermission(orgB, bucketB).Permissions...,
+				),
+			}
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, auth)
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?db=telegraf&rp=autogen"+tt.query, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if tt.bucket == "" {
+				return
+			}
+			if len(pw.Points) != 1 {
+				t.Fatalf("unexpected points written: %v", pw.Points)
+			}
+			if _, got := tsdb.DecodeNameSlice(pw.Points[0].Name()); got.String() != tt.bucket {
+				t.Errorf("unexpected bucket written: got %s want %s", got, tt.bucket)
+			}
+		})
+	}
+}
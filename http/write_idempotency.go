@@ -0,0 +1,155 @@
+package http
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// headerIdempotencyKey is the request header a client sets to have retries of
+// the same write deduplicated.
+const headerIdempotencyKey = "Idempotency-Key"
+
+// WithIdempotencyCache deduplicates writes that carry an Idempotency-Key header.
+// Once a write to a bucket succeeds, a repeat of its key to the same bucket
+// within window is answered as the write was, without writing the points
+// again. A write with the key of a write to the same bucket still in progress
+// is rejected as a conflict.
+//
+// Deduplication is best-effort: at most size keys are remembered, the least
+// recently used key being evicted first, and the cache is local to this
+// handler.
+func WithIdempotencyCache(size int, window time.Duration) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		if size <= 0 || window <= 0 {
+			w.idempotency = nil
+			return
+		}
+		w.idempotency = newIdempotencyCache(size, window)
+	}
+}
+
+// errIdempotencyKeyInProgress rejects a write whose key is that of a write
+// still in progress.
+var errIdempotencyKeyInProgress = &influxdb.Error{
+	Code: influxdb.EConflict,
+	Op:   opWriteHandler,
+	Msg:  "a write with this idempotency key is in progress",
+}
+
+type idempotencyKey [sha256.Size]byte
+
+// newIdempotencyKey hashes the client supplied key together with the org and
+// bucket so keys from different tenants never collide.
+func newIdempotencyKey(orgID, bucketID influxdb.ID, key string) idempotencyKey {
+	h := sha256.New()
+	h.Write([]byte(orgID.String()))
+	h.Write([]byte(bucketID.String()))
+	h.Write([]byte(key))
+
+	var k idempotencyKey
+	copy(k[:], h.Sum(nil))
+	return k
+}
+
+// idempotencyCache is an LRU cache of the keys of recent writes. Entries
+// expire once they are older than the window.
+type idempotencyCache struct {
+	mu       sync.Mutex
+	capacity int
+	window   time.Duration
+	now      func() time.Time
+
+	entries map[idempotencyKey]*list.Element
+	evictor *list.List
+}
+
+type idempotencyEntry struct {
+	key     idempotencyKey
+	expires time.Time
+	// pending entries are those of writes in progress, which do not expire.
+	pending bool
+}
+
+func newIdempotencyCache(capacity int, window time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		capacity: capacity,
+		window:   window,
+		now:      time.Now,
+		entries:  make(map[idempotencyKey]*list.Element),
+		evictor:  list.New(),
+	}
+}
+
+// Reserve reserves k for a write, returning whether the write is the replay
+// of a write added within the window. A reserved key is either added once its
+// write succeeds or released once it fails. The key of a write in progress
+// cannot be reserved again, which fails with errIdempotencyKeyInProgress.
+func (c *idempotencyCache) Reserve(k idempotencyKey) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ele := c.find(k); ele != nil {
+		if ele.Value.(*idempotencyEntry).pending {
+			return false, errIdempotencyKeyInProgress
+		}
+		return true, nil
+	}
+	c.push(&idempotencyEntry{key: k, pending: true})
+	return false, nil
+}
+
+// Release forgets k, reserved for a write that failed.
+func (c *idempotencyCache) Release(k idempotencyKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ele, ok := c.entries[k]; ok {
+		c.remove(ele)
+	}
+}
+
+// Add records k, evicting the least recently used key if the cache is full.
+func (c *idempotencyCache) Add(k idempotencyKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.window)
+	if ele, ok := c.entries[k]; ok {
+		entry := ele.Value.(*idempotencyEntry)
+		entry.expires, entry.pending = expires, false
+		c.evictor.MoveToFront(ele)
+		return
+	}
+	c.push(&idempotencyEntry{key: k, expires: expires})
+}
+
+// find returns the entry of k, unless it expired.
+func (c *idempotencyCache) find(k idempotencyKey) *list.Element {
+	ele, ok := c.entries[k]
+	if !ok {
+		return nil
+	}
+	entry := ele.Value.(*idempotencyEntry)
+	if !entry.pending && c.now().After(entry.expires) {
+		c.remove(ele)
+		return nil
+	}
+	c.evictor.MoveToFront(ele)
+	return ele
+}
+
+func (c *idempotencyCache) push(entry *idempotencyEntry) {
+	c.entries[entry.key] = c.evictor.PushFront(entry)
+	for c.evictor.Len() > c.capacity {
+		c.remove(c.evictor.Back())
+	}
+}
+
+func (c *idempotencyCache) remove(ele *list.Element) {
+	c.evictor.Remove(ele)
+	delete(c.entries, ele.Value.(*idempotencyEntry).key)
+}
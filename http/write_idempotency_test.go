@@ -0,0 +1,245 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newIdempotencyCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	a := newIdempotencyKey(1, 2, "a")
+	b := newIdempotencyKey(1, 2, "b")
+	if a == newIdempotencyKey(1, 3, "a") {
+		t.Fatal("keys for different buckets must differ")
+	}
+
+	replayed := func(k idempotencyKey) bool {
+		t.Helper()
+		replay, err := c.Reserve(k)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !replay {
+			c.Release(k)
+		}
+		return replay
+	}
+
+	c.Add(a)
+	c.Add(b)
+	if !replayed(a) || !replayed(b) {
+		t.Fatal("expected both keys to be replays")
+	}
+
+	// a is now the most recently used, so adding c evicts b
+	replayed(a)
+	c.Add(newIdempotencyKey(1, 2, "c"))
+	if !replayed(a) {
+		t.Error("expected the recently used key to be kept")
+	}
+	if replayed(b) {
+		t.Error("expected the least recently used key to be evicted")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if replayed(a) {
+		t.Error("expected the key to expire after the window")
+	}
+}
+
+func TestIdempotencyCache_Reserve(t *testing.T) {
+	c := newIdempotencyCache(10, time.Minute)
+	k := newIdempotencyKey(1, 2, "a")
+
+	if replay, err := c.Reserve(k); err != nil || replay {
+		t.Fatalf("unexpected reservation: replay %t, error %v", replay, err)
+	}
+	// the key of a write in progress cannot be reserved again.
+	if _, err := c.Reserve(k); err != errIdempotencyKeyInProgress {
+		t.Fatalf("unexpected error: got %v want %v", err, errIdempotencyKeyInProgress)
+	}
+
+	// the key of a write that failed is released for its retry.
+	c.Release(k)
+	if replay, err := c.Reserve(k); err != nil || replay {
+		t.Fatalf("unexpected reservation after release: replay %t, error %v", replay, err)
+	}
+
+	c.Add(k)
+	if replay, err := c.Reserve(k); err != nil || !replay {
+		t.Fatalf("unexpected reservation after add: replay %t, error %v", replay, err)
+	}
+}
+
+func TestWriteHandler_handleWrite_idempotencyKey(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	pw := &mock.PointsWriter{}
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithIdempotencyCache(10, time.Minute))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	write := func(key, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(headerIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for _, tt := range []struct {
+		key    string
+		body   string
+		code   int
+		points int
+	}{
+		{key: "batch-1", body: "m1 f1=1", code: http.StatusNoContent, points: 1},
+		{key: "batch-1", body: "m1 f1=1", code: http.StatusNoContent, points: 1},
+		{key: "batch-2", body: "invalid", code: http.StatusBadRequest, points: 1},
+		{key: "batch-2", body: "m1 f1=2", code: http.StatusNoContent, points: 2},
+		{body: "m1 f1=3", code: http.StatusNoContent, points: 3},
+		{body: "m1 f1=3", code: http.StatusNoContent, points: 4},
+	} {
+		if got := write(tt.key, tt.body); got != tt.code {
+			t.Errorf("key %q: unexpected status code: got %d want %d", tt.key, got, tt.code)
+		}
+		if got := len(pw.Points); got != tt.points {
+			t.Errorf("key %q: unexpected points written: got %d want %d", tt.key, got, tt.points)
+		}
+	}
+}
+
+func TestWriteHandler_handleWrite_idempotencyKey_verbose(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	pw := &mock.PointsWriter{}
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: mock.NewOrganizationService(),
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithIdempotencyCache(10, time.Minute))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	// a verbose replay is answered with the verbose response of the write.
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?orgID="+orgID+"&bucket="+bucketID+"&verbose=true", strings.NewReader("m1 f1=1\nm1 f1=2"))
+		r.Header.Set(headerIdempotencyKey, "batch-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got, want := w.Code, http.StatusOK; got != want {
+			t.Fatalf("write %d: unexpected status code: got %d want %d", i, got, want)
+		}
+		if got, want := w.Body.String(), `{"pointsWritten":2,"bytesReceived":15}`+"\n"; got != want {
+			t.Errorf("write %d: unexpected body: got %s want %s", i, got, want)
+		}
+	}
+	if got := len(pw.Points); got != 2 {
+		t.Errorf("unexpected points written: got %d want 2", got)
+	}
+}
+
+// blockingPointsWriter blocks writes until unblocked.
+type blockingPointsWriter struct {
+	writing chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingPointsWriter) WritePoints(ctx context.Context, points []models.Point) error {
+	w.writing <- struct{}{}
+	<-w.unblock
+	return nil
+}
+
+func TestWriteHandler_handleWrite_idempotencyKey_inProgress(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	pw := &blockingPointsWriter{writing: make(chan struct{}), unblock: make(chan struct{})}
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: mock.NewOrganizationService(),
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithIdempotencyCache(10, time.Minute))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	write := func() int {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?orgID="+orgID+"&bucket="+bucketID, strings.NewReader("m1 f1=1"))
+		r.Header.Set(headerIdempotencyKey, "batch-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	first := make(chan int, 1)
+	go func() { first <- write() }()
+	<-pw.writing
+
+	// a duplicate of the write in progress is not written.
+	if got, want := write(), http.StatusUnprocessableEntity; got != want {
+		t.Errorf("unexpected status code of the duplicate: got %d want %d", got, want)
+	}
+
+	close(pw.unblock)
+	if got, want := <-first, http.StatusNoContent; got != want {
+		t.Errorf("unexpected status code of the write: got %d want %d", got, want)
+	}
+	if got, want := write(), http.StatusNoContent; got != want {
+		t.Errorf("unexpected status code of the replay: got %d want %d", got, want)
+	}
+}
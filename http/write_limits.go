@@ -0,0 +1,106 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// maxReportedViolations is the number of limit violations listed in the
+// error of a rejected write.
+const maxReportedViolations = 10
+
+// WriteLimits bound the shape of the points accepted by the WriteHandler, to
+// protect against accidental high cardinality writes. A zero limit is not
+// enforced.
+type WriteLimits struct {
+	// MaxTagsPerPoint is the maximum number of tags of a point.
+	MaxTagsPerPoint int
+	// MaxTagValueLength is the maximum length in bytes of a tag value.
+	MaxTagValueLength int
+	// MaxMeasurementLength is the maximum length in bytes of a measurement.
+	MaxMeasurementLength int
+	// MaxPointsPerRequest is the maximum number of points of a write. It
+	// complements the limit of WithMaxBatchSizeBytes, as a small gzipped
+	// body can expand to many points.
+	MaxPointsPerRequest int
+}
+
+// WithWriteLimits rejects writes with a point that exceeds limits. The limits
+// are checked after parsing, so no point of a rejected write is written.
+func WithWriteLimits(limits WriteLimits) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.writeLimits = limits
+	}
+}
+
+func (l WriteLimits) enabled() bool {
+	return l.MaxTagsPerPoint > 0 || l.MaxTagValueLength > 0 || l.MaxMeasurementLength > 0 || l.MaxPointsPerRequest > 0
+}
+
+// validate returns an invalid error listing the limits exceeded by points.
+func (l WriteLimits) validate(points models.Points) error {
+	if !l.enabled() {
+		return nil
+	}
+	if l.MaxPointsPerRequest > 0 && len(points) > l.MaxPointsPerRequest {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opPointsWriter,
+			Msg:  fmt.Sprintf("write of %d points exceeds the limit of %d points per request", len(points), l.MaxPointsPerRequest),
+		}
+	}
+
+	var (
+		violations []string
+		total      int
+		seen       = make(map[string]bool)
+	)
+	violate := func(format string, args ...interface{}) {
+		v := fmt.Sprintf(format, args...)
+		if seen[v] {
+			return
+		}
+		seen[v] = true
+		if total++; total <= maxReportedViolations {
+			violations = append(violations, v)
+		}
+	}
+
+	for _, p := range points {
+		tags := p.Tags()
+		measurement := tags.Get(models.MeasurementTagKeyBytes)
+		if l.MaxMeasurementLength > 0 && len(measurement) > l.MaxMeasurementLength {
+			violate("measurement %q is longer than %d bytes", measurement, l.MaxMeasurementLength)
+		}
+
+		var n int
+		for _, tag := range tags {
+			if bytes.Equal(tag.Key, models.MeasurementTagKeyBytes) || bytes.Equal(tag.Key, models.FieldKeyTagKeyBytes) {
+				continue
+			}
+			n++
+			if l.MaxTagValueLength > 0 && len(tag.Value) > l.MaxTagValueLength {
+				violate("measurement %q: value of tag %q is longer than %d bytes", measurement, tag.Key, l.MaxTagValueLength)
+			}
+		}
+		if l.MaxTagsPerPoint > 0 && n > l.MaxTagsPerPoint {
+			violate("measurement %q: %d tags exceed the limit of %d", measurement, n, l.MaxTagsPerPoint)
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+	if total > len(violations) {
+		violations = append(violations, fmt.Sprintf("and %d more", total-len(violations)))
+	}
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   opPointsWriter,
+		Msg:  "points exceed write limits: " + strings.Join(violations, "; "),
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// overflowLabel replaces the org and bucket labels once a WriteMetrics
+// has reached its tenant limit.
+const overflowLabel = "other"
+
+// WriteMetrics tracks the points, bytes and errors seen by the WriteHandler
+// broken down by org and bucket id.
+//
+// The general structure of the metrics produced should be
+//
+// http_write_points_written{org_id=<org_id>, bucket_id=<bucket_id>} ...
+// http_write_bytes_received{org_id=<org_id>, bucket_id=<bucket_id>} ...
+// http_write_errors{org_id=<org_id>, bucket_id=<bucket_id>} ...
+type WriteMetrics struct {
+	pointsWritten *prometheus.CounterVec
+	bytesReceived *prometheus.CounterVec
+	writeErrors   *prometheus.CounterVec
+
+	// maxTenants is the number of distinct org/bucket pairs that receive
+	// their own labels. Zero means unlimited.
+	maxTenants int
+
+	mu      sync.Mutex
+	tenants map[[2]influxdb.ID]struct{}
+}
+
+// WriteMetricsOption is a functional option for a *WriteMetrics.
+type WriteMetricsOption func(*WriteMetrics)
+
+// WithMaxTenantLabels limits the number of distinct org/bucket pairs that
+// are labeled individually. Writes to pairs past the limit are recorded
+// under the "other" label to bound the cardinality of the metrics.
+func WithMaxTenantLabels(n int) WriteMetricsOption {
+	return func(m *WriteMetrics) {
+		m.maxTenants = n
+	}
+}
+
+// NewWriteMetrics returns a new instance of WriteMetrics.
+func NewWriteMetrics(opts ...WriteMetricsOption) *WriteMetrics {
+	const (
+		namespace = "http"
+		subsystem = "write"
+	)
+
+	labels := []string{"org_id", "bucket_id"}
+
+	m := &WriteMetrics{
+		pointsWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "points_written",
+			Help:      "Count of points written",
+		}, labels),
+		bytesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "bytes_received",
+			Help:      "Count of bytes received for writes",
+		}, labels),
+		writeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errors",
+			Help:      "Count of failed writes",
+		}, labels),
+		tenants: make(map[[2]influxdb.ID]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// PrometheusCollectors exposes the prometheus collectors of the write metrics.
+func (m *WriteMetrics) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.pointsWritten,
+		m.bytesReceived,
+		m.writeErrors,
+	}
+}
+
+// RecordWrite records a successful write of points to a bucket.
+func (m *WriteMetrics) RecordWrite(orgID, bucketID influxdb.ID, points, bytes int) {
+	labels := m.labels(orgID, bucketID)
+	m.pointsWritten.With(labels).Add(float64(points))
+	m.bytesReceived.With(labels).Add(float64(bytes))
+}
+
+// RecordError records a failed write to a bucket.
+func (m *WriteMetrics) RecordError(orgID, bucketID influxdb.ID) {
+	m.writeErrors.With(m.labels(orgID, bucketID)).Inc()
+}
+
+func (m *WriteMetrics) labels(orgID, bucketID influxdb.ID) prometheus.Labels {
+	if !m.track(orgID, bucketID) {
+		return prometheus.Labels{
+			"org_id":    overflowLabel,
+			"bucket_id": overflowLabel,
+		}
+	}
+	return prometheus.Labels{
+		"org_id":    orgID.String(),
+		"bucket_id": bucketID.String(),
+	}
+}
+
+// track reports whether the org/bucket pair should receive its own labels.
+func (m *WriteMetrics) track(orgID, bucketID influxdb.ID) bool {
+	if m.maxTenants <= 0 {
+		return true
+	}
+
+	key := [2]influxdb.ID{orgID, bucketID}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tenants[key]; ok {
+		return true
+	}
+	if len(m.tenants) >= m.maxTenants {
+		return false
+	}
+	m.tenants[key] = struct{}{}
+	return true
+}
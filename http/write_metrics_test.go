@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/kit/prom"
+	"github.com/influxdata/influxdb/v2/kit/prom/promtest"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	m := NewWriteMetrics(WithMaxTenantLabels(1))
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(m.PrometheusCollectors()...)
+
+	m.RecordWrite(1, 2, 3, 30)
+	m.RecordWrite(1, 2, 1, 10)
+	m.RecordWrite(1, 3, 5, 50)
+	m.RecordError(4, 5)
+
+	mfs := promtest.MustGather(t, reg)
+	tenant := map[string]string{"org_id": influxdb.ID(1).String(), "bucket_id": influxdb.ID(2).String()}
+	other := map[string]string{"org_id": overflowLabel, "bucket_id": overflowLabel}
+
+	if got := promtest.MustFindMetric(t, mfs, "http_write_points_written", tenant).GetCounter().GetValue(); got != 4 {
+		t.Errorf("unexpected points written: got %v want 4", got)
+	}
+	if got := promtest.MustFindMetric(t, mfs, "http_write_bytes_received", tenant).GetCounter().GetValue(); got != 40 {
+		t.Errorf("unexpected bytes received: got %v want 40", got)
+	}
+	if got := promtest.MustFindMetric(t, mfs, "http_write_points_written", other).GetCounter().GetValue(); got != 5 {
+		t.Errorf("unexpected overflow points written: got %v want 5", got)
+	}
+	if got := promtest.MustFindMetric(t, mfs, "http_write_errors", other).GetCounter().GetValue(); got != 1 {
+		t.Errorf("unexpected overflow errors: got %v want 1", got)
+	}
+}
+
+func TestWriteHandler_writeMetrics(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+
+	m := NewWriteMetrics()
+	reg := prom.NewRegistry(zaptest.NewLogger(t))
+	reg.MustRegister(m.PrometheusCollectors()...)
+
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), WithWriteMetrics(m))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	for _, body := range []string{"m1,t1=v1 f1=1\nm1,t1=v2 f1=2", "invalid"} {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader(body))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	mfs := promtest.MustGather(t, reg)
+	labels := map[string]string{"org_id": orgID, "bucket_id": bucketID}
+	if got := promtest.MustFindMetric(t, mfs, "http_write_points_written", labels).GetCounter().GetValue(); got != 2 {
+		t.Errorf("unexpected points written: got %v want 2", got)
+	}
+	if got := promtest.MustFindMetric(t, mfs, "http_write_errors", labels).GetCounter().GetValue(); got != 1 {
+		t.Errorf("unexpected errors: got %v want 1", got)
+	}
+}
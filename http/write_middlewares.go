@@ -0,0 +1,24 @@
+package http
+
+import "net/http"
+
+// WithMiddlewares wraps the write and batch routes of the handler in mws, for
+// instance to add auth or metrics of a deployment. The built-in middlewares
+// run first: the CORS headers of WithCORS and the request id are set and the
+// access log started before mws run. mws then run in the order given, the
+// first seeing the request first, and the handler last. Options with more
+// middlewares append to mws. The health and readiness routes are not
+// wrapped, so that probes are unaffected.
+func WithMiddlewares(mws ...func(http.Handler) http.Handler) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.middlewares = append(w.middlewares, mws...)
+	}
+}
+
+// withMiddlewares wraps next in the middlewares of WithMiddlewares.
+func (h *WriteHandler) withMiddlewares(next http.Handler) http.Handler {
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		next = h.middlewares[i](next)
+	}
+	return next
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_middlewares(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	var calls []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" "+middleware.GetReqID(r.Context()))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	reject := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Reject") != "" {
+				w.WriteHeader(http.StatusTeapot)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	pw := &mock.PointsWriter{}
+	b := &APIBackend{
+		HTTPErrorHandler:    DefaultErrorHandler,
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b),
+		WithMiddlewares(record("first"), reject),
+		WithMiddlewares(record("second")),
+	)
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		reject bool
+		code   int
+		calls  []string
+	}{
+		{
+			name:   "write",
+			method: http.MethodPost,
+			path:   prefixWrite + "?org=" + orgID + "&bucket=" + bucketID,
+			code:   http.StatusNoContent,
+			calls:  []string{"first req-1", "second req-1"},
+		},
+		{
+			name:   "rejected by a middleware",
+			method: http.MethodPost,
+			path:   prefixWrite + "?org=" + orgID + "&bucket=" + bucketID,
+			reject: true,
+			code:   http.StatusTeapot,
+			calls:  []string{"first req-1"},
+		},
+		{
+			name:   "batch",
+			method: http.MethodPost,
+			path:   prefixWriteBatch,
+			code:   http.StatusBadRequest,
+			calls:  []string{"first req-1", "second req-1"},
+		},
+		{
+			name:   "health is not wrapped",
+			method: http.MethodGet,
+			path:   prefixWriteHealth,
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			r := httptest.NewRequest(tt.method, "http://localhost:9999"+tt.path, strings.NewReader("m1 f1=1"))
+			r.Header.Set("X-Request-Id", "req-1")
+			if tt.reject {
+				r.Header.Set("X-Reject", "true")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got, want := strings.Join(calls, ","), strings.Join(tt.calls, ","); got != want {
+				t.Errorf("unexpected middleware calls: got %q want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
+)
+
+// WithoutPanicRecovery lets the panics of the write routes propagate to the
+// http server rather than recovering them, so that they are not hidden while
+// debugging the write path. The http server then logs the panic and aborts
+// the response. Panics are recovered by default, as production servers must
+// keep serving other writes.
+func WithoutPanicRecovery() WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.noPanicRecovery = true
+	}
+}
+
+// withRecovery recovers the panics of next, logging them to the logger of the
+// handler with their stack and the id of the request, and responds to the
+// write with an internal error.
+func (h *WriteHandler) withRecovery(next http.Handler) http.Handler {
+	if h.noPanicRecovery {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rcv := recover()
+			if rcv == nil {
+				return
+			}
+			// aborting a response is how a handler asks the server to
+			// drop the connection.
+			if rcv == http.ErrAbortHandler {
+				panic(rcv)
+			}
+
+			ctx := r.Context()
+			pe := &influxdb.Error{
+				Code: influxdb.EInternal,
+				Msg:  "a panic has occurred",
+				Err:  fmt.Errorf("%s: %v", r.URL.String(), rcv),
+			}
+			h.log.Error("Panic while serving a write",
+				zap.String("request_id", middleware.GetReqID(ctx)),
+				zap.Error(pe.Err),
+				zap.Stack("stack"),
+			)
+			h.HandleHTTPError(ctx, pe, w)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
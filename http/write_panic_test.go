@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWriteHandler_handleWrite_panic(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader("m,host=a f=1"))
+		r.Header.Set(middleware.RequestIDHeader, "req-1")
+		return r
+	}
+	panicking := func(context.Context, []models.Point) error {
+		panic("points writer exploded")
+	}
+
+	t.Run("recovered", func(t *testing.T) {
+		writeHandler, pw := newV1WriteHandler(t)
+		pw.WritePointsFn = panicking
+		core, logs := observer.New(zap.ErrorLevel)
+		writeHandler.log = zap.New(core)
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest())
+
+		if got := w.Code; got != http.StatusInternalServerError {
+			t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusInternalServerError, w.Body.String())
+		}
+		entries := logs.All()
+		if len(entries) != 1 {
+			t.Fatalf("expected the panic to be logged once, got %d entries", len(entries))
+		}
+		fields := entries[0].ContextMap()
+		if fields["request_id"] != "req-1" {
+			t.Errorf("expected the request id to be logged: %v", fields)
+		}
+		if stack, _ := fields["stack"].(string); !strings.Contains(stack, "write_panic_test.go") {
+			t.Errorf("expected the stack of the panic to be logged: %s", stack)
+		}
+		if msg, _ := fields["error"].(string); !strings.Contains(msg, "points writer exploded") {
+			t.Errorf("expected the panic to be logged: %v", fields)
+		}
+	})
+
+	t.Run("without recovery", func(t *testing.T) {
+		writeHandler, pw := newV1WriteHandler(t, WithoutPanicRecovery())
+		pw.WritePointsFn = panicking
+		handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+		defer func() {
+			if rcv := recover(); rcv != "points writer exploded" {
+				t.Errorf("expected the panic to propagate, got %v", rcv)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	})
+}
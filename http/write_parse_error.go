@@ -0,0 +1,113 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// maxParseErrorSnippet is the most bytes of a malformed line echoed back in
+// a parse error.
+const maxParseErrorSnippet = 128
+
+// lineParseError is the error of the first malformed line of a write.
+type lineParseError struct {
+	// Line is the 1-based line of the body the point starts on.
+	Line int
+	// Snippet is the start of the line, at most maxParseErrorSnippet bytes.
+	Snippet string
+	Err     error
+}
+
+func (e *lineParseError) Error() string {
+	return fmt.Sprintf("unable to parse line %d %q: %v", e.Line, e.Snippet, e.Err)
+}
+
+func (e *lineParseError) Unwrap() error {
+	return e.Err
+}
+
+// findParseError parses the points of data one at a time to find the first
+// that fails to parse, as the error of parsing all of data does not tell
+// which line failed. It returns nil when every point parses on its own.
+func findParseError(data, mm []byte, opts []models.ParserOption) *lineParseError {
+	line := 1
+	for pos := 0; pos < len(data); pos++ {
+		end, block := models.ScanLine(data, pos)
+		start := line
+		line += bytes.Count(block, []byte{'\n'})
+		if end < len(data) {
+			line++
+		}
+		pos = end
+
+		block = bytes.TrimSuffix(bytes.TrimLeft(block, " \t\x00"), []byte{'\r'})
+		if len(block) == 0 || block[0] == '#' {
+			continue
+		}
+		if _, err := models.ParsePointsWithOptions(block, mm, opts...); err != nil {
+			// the parser echoes the entire line, which the snippet replaces.
+			msg := strings.TrimPrefix(err.Error(), fmt.Sprintf("unable to parse '%s': ", block))
+			return &lineParseError{
+				Line:    start,
+				Snippet: parseErrorSnippet(block),
+				Err:     errors.New(msg),
+			}
+		}
+	}
+	return nil
+}
+
+// parseErrorSnippet returns the start of line up to maxParseErrorSnippet
+// bytes, not splitting a multi-byte character.
+func parseErrorSnippet(line []byte) string {
+	if len(line) <= maxParseErrorSnippet {
+		return string(line)
+	}
+	n := maxParseErrorSnippet
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return string(line[:n]) + "..."
+}
+
+// writeParseErrorResponse is the body of a write whose line protocol fails
+// to parse.
+type writeParseErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Snippet string `json:"snippet"`
+}
+
+// handleParseError responds with err, including the line and snippet of the
+// malformed point when err has them.
+func (h *WriteHandler) handleParseError(ctx context.Context, err error, w http.ResponseWriter) {
+	var lpe *lineParseError
+	if _, v1 := v1ErrorsDatabase(ctx); v1 || !errors.As(err, &lpe) {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	code := influxdb.ErrorCode(err)
+	// encoded as the error handler encodes errors, without a trailing newline.
+	b, _ := json.Marshal(writeParseErrorResponse{
+		Code:    code,
+		Message: err.Error(),
+		Line:    lpe.Line,
+		Snippet: lpe.Snippet,
+	})
+	w.Header().Set(kithttp.PlatformErrorCodeHeader, code)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, code))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindParseError(t *testing.T) {
+	long := "m1 f1=" + strings.Repeat("x", 200)
+
+	tests := []struct {
+		name    string
+		data    string
+		line    int
+		snippet string
+		err     string
+	}{
+		{
+			name:    "first line",
+			data:    "m1\nm1 f1=1",
+			line:    1,
+			snippet: "m1",
+			err:     "missing fields",
+		},
+		{
+			name:    "after comments and blank lines",
+			data:    "# comment\n\nm1 f1=1\n  m1 f1=\n",
+			line:    4,
+			snippet: "m1 f1=",
+			err:     "missing field value",
+		},
+		{
+			name:    "after a string field spanning lines",
+			data:    "m1 s=\"a\nb\"\nm1 f1=1\nm1 f1=1i2",
+			line:    4,
+			snippet: "m1 f1=1i2",
+			err:     "invalid number",
+		},
+		{
+			name:    "long line",
+			data:    long,
+			line:    1,
+			snippet: long[:maxParseErrorSnippet] + "...",
+		},
+		{
+			name: "valid line protocol",
+			data: "m1 f1=1\r\nm1 f1=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lpe := findParseError([]byte(tt.data), []byte("m"), nil)
+			if tt.line == 0 {
+				if lpe != nil {
+					t.Fatalf("unexpected error: %v", lpe)
+				}
+				return
+			}
+			if lpe == nil {
+				t.Fatal("expected an error")
+			}
+			if lpe.Line != tt.line {
+				t.Errorf("unexpected line: got %d want %d", lpe.Line, tt.line)
+			}
+			if lpe.Snippet != tt.snippet {
+				t.Errorf("unexpected snippet: got %q want %q", lpe.Snippet, tt.snippet)
+			}
+			if tt.err != "" && lpe.Err.Error() != tt.err {
+				t.Errorf("unexpected error: got %q want %q", lpe.Err, tt.err)
+			}
+		})
+	}
+}
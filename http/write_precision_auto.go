@@ -0,0 +1,55 @@
+package http
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// precisionAuto is the precision of writes whose timestamps are in seconds,
+// milliseconds, microseconds or nanoseconds, inferred point by point from
+// their magnitude. It is never the default: a client asks for it with
+// precision=auto, typically for legacy sources that mix precisions.
+const precisionAuto = "auto"
+
+// autoPrecisionLimit is the absolute timestamp, in each unit, below which a
+// timestamp of an auto precision write is taken to be in that unit rather
+// than a finer one:
+//
+//	|ts| < 5e9   seconds
+//	|ts| < 5e12  milliseconds
+//	|ts| < 5e15  microseconds
+//	otherwise    nanoseconds
+//
+// 5e9 of any unit is past the year 2128, so each unit covers 1970 to 2128 in
+// its range, while nanosecond timestamps are only recognized from February
+// 1970 on. Earlier nanosecond timestamps are misread as a coarser precision.
+const autoPrecisionLimit = 5e9
+
+// inferPrecision returns the timestamp ts, parsed as nanoseconds, in
+// nanoseconds of the precision inferred from its magnitude.
+func inferPrecision(ts int64) int64 {
+	abs := ts
+	if abs < 0 {
+		abs = -abs
+	}
+	unit := int64(time.Second)
+	for limit := int64(autoPrecisionLimit); unit > 1; limit *= 1000 {
+		if abs < limit {
+			return ts * unit
+		}
+		unit /= 1000
+	}
+	return ts
+}
+
+// applyAutoPrecision scales the timestamps of points, parsed as nanoseconds,
+// to the precisions inferred from their magnitudes.
+func applyAutoPrecision(points []models.Point) {
+	for _, p := range points {
+		ns := p.UnixNano()
+		if scaled := inferPrecision(ns); scaled != ns {
+			p.SetTime(time.Unix(0, scaled))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestInferPrecision(t *testing.T) {
+	const want = int64(1577836800123000000) // 2020-01-01T00:00:00.123Z
+
+	tests := []struct {
+		name string
+		ts   int64
+		want int64
+	}{
+		{name: "seconds", ts: 1577836800, want: 1577836800000000000},
+		{name: "milliseconds", ts: 1577836800123, want: want},
+		{name: "microseconds", ts: 1577836800123000, want: want},
+		{name: "nanoseconds", ts: want, want: want},
+		{name: "negative seconds", ts: -86400, want: -86400000000000},
+		{name: "last second", ts: 4999999999, want: 4999999999000000000},
+		{name: "first millisecond", ts: 5000000000, want: 5000000000000000},
+		{name: "first nanosecond", ts: 5000000000000000, want: 5000000000000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferPrecision(tt.ts); got != tt.want {
+				t.Errorf("unexpected timestamp: got %d want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_autoPrecision(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name      string
+		precision string
+		code      int
+		times     []int64
+	}{
+		{
+			name:      "mixed precisions",
+			precision: "auto",
+			code:      http.StatusNoContent,
+			times:     []int64{1577836800000000000, 1577836800001000000, 1577836800000002000, 1577836800000000003},
+		},
+		{
+			name:  "nanoseconds by default",
+			code:  http.StatusNoContent,
+			times: []int64{1577836800, 1577836800001, 1577836800000002, 1577836800000000003},
+		},
+		{
+			name:      "invalid precision",
+			precision: "minutes",
+			code:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			body := "m f=1 1577836800\nm f=2 1577836800001\nm f=3 1577836800000002\nm f=4 1577836800000000003"
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID+"&precision="+tt.precision, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got, want := len(pw.Points), len(tt.times); got != want {
+				t.Fatalf("unexpected points written: got %d want %d", got, want)
+			}
+			for i, p := range pw.Points {
+				if got := p.UnixNano(); got != tt.times[i] {
+					t.Errorf("point %d: unexpected timestamp: got %d want %d", i, got, tt.times[i])
+				}
+			}
+		})
+	}
+}
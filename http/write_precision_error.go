@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+)
+
+// writePrecisions are the precisions of the timestamps of writes.
+var writePrecisions = []string{"ns", "us", "ms", "s", precisionAuto}
+
+// batchPrecisions are the precisions of the timestamps of batch writes, which
+// do not detect the precision of timestamps.
+var batchPrecisions = []string{"ns", "us", "ms", "s"}
+
+// precisionError is the error of a write with a precision that is not one of
+// the Allowed precisions.
+type precisionError struct {
+	Precision string
+	Allowed   []string
+}
+
+func (e *precisionError) Error() string {
+	return fmt.Sprintf("got %q", e.Precision)
+}
+
+// invalidPrecisionError returns the error of a write of op with precision,
+// which is not one of allowed.
+func invalidPrecisionError(op, precision string, allowed []string) error {
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   op,
+		Msg:  invalidPrecisionMessage(allowed),
+		Err:  &precisionError{Precision: precision, Allowed: allowed},
+	}
+}
+
+func invalidPrecisionMessage(allowed []string) string {
+	last := len(allowed) - 1
+	return fmt.Sprintf("invalid precision; valid precision units are %s, and %s", strings.Join(allowed[:last], ", "), allowed[last])
+}
+
+// writePrecisionErrorResponse is the body of a write with an invalid
+// precision, listing the precisions allowed so that clients can offer them.
+type writePrecisionErrorResponse struct {
+	Code              string   `json:"code"`
+	Message           string   `json:"message"`
+	Precision         string   `json:"precision"`
+	AllowedPrecisions []string `json:"allowedPrecisions"`
+}
+
+// handleDecodeError responds with err, the error of decoding a write,
+// including the precision and the allowed precisions when err is the error of
+// an invalid precision.
+func (h *WriteHandler) handleDecodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	var pe *precisionError
+	if _, v1 := v1ErrorsDatabase(ctx); v1 || !errors.As(err, &pe) {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	code := influxdb.ErrorCode(err)
+	// encoded as the error handler encodes errors, without a trailing newline.
+	b, _ := json.Marshal(writePrecisionErrorResponse{
+		Code:              code,
+		Message:           influxdb.ErrorMessage(err),
+		Precision:         pe.Precision,
+		AllowedPrecisions: pe.Allowed,
+	})
+	w.Header().Set(kithttp.PlatformErrorCodeHeader, code)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, code))
+	_, _ = w.Write(b)
+}
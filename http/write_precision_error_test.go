@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+)
+
+func TestWriteHandler_invalidPrecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		body    string
+		allowed []string
+	}{
+		{
+			name:    "write",
+			url:     "http://localhost:9999/api/v2/write?org=" + v1OrgID + "&bucket=" + v1BucketID + "&precision=minutes",
+			body:    "m1 f1=1",
+			allowed: []string{"ns", "us", "ms", "s", "auto"},
+		},
+		{
+			name:    "batch write",
+			url:     "http://localhost:9999/api/v2/write/batch?org=" + v1OrgID + "&precision=minutes",
+			body:    `{"batches":[{"bucket":"` + v1BucketID + `","data":"m1 f1=1"}]}`,
+			allowed: []string{"ns", "us", "ms", "s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeHandler, _ := newV1WriteHandler(t)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body)))
+
+			if got := w.Code; got != http.StatusBadRequest {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusBadRequest, w.Body.String())
+			}
+			var got writePrecisionErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unexpected body %s: %v", w.Body.String(), err)
+			}
+			want := writePrecisionErrorResponse{
+				Code:              "invalid",
+				Message:           invalidPrecisionMessage(tt.allowed),
+				Precision:         "minutes",
+				AllowedPrecisions: tt.allowed,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("unexpected error: got %+v want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("message", func(t *testing.T) {
+		if got, want := invalidPrecisionMessage(writePrecisions), "invalid precision; valid precision units are ns, us, ms, s, and auto"; got != want {
+			t.Errorf("unexpected message: got %q want %q", got, want)
+		}
+	})
+}
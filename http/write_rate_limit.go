@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"golang.org/x/time/rate"
+)
+
+// WriteRateLimiter limits the rate at which each org writes.
+type WriteRateLimiter interface {
+	// ReserveWrite accounts a write of points and bytes to orgID. It returns
+	// false along with how long to wait before retrying when the write
+	// exceeds the rate of the org, in which case nothing is accounted.
+	ReserveWrite(ctx context.Context, orgID influxdb.ID, points, bytes int) (time.Duration, bool)
+}
+
+// WithWriteRateLimiter rate limits the writes of each org with l. A write
+// exceeding the rate of its org is rejected with a 429 and a Retry-After
+// header.
+func WithWriteRateLimiter(l WriteRateLimiter) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.rateLimiter = l
+	}
+}
+
+// reserveWrite accounts a write to the rate limiter. It sets the Retry-After
+// header of w when the write is rate limited.
+func (h *WriteHandler) reserveWrite(ctx context.Context, w http.ResponseWriter, orgID influxdb.ID, points, bytes int) error {
+	if h.rateLimiter == nil {
+		return nil
+	}
+
+	retryAfter, ok := h.rateLimiter.ReserveWrite(ctx, orgID, points, bytes)
+	if ok {
+		return nil
+	}
+	if w != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+	}
+	return &influxdb.Error{
+		Code: influxdb.ETooManyRequests,
+		Op:   opWriteHandler,
+		Msg:  "org write rate limit exceeded",
+	}
+}
+
+// retryAfterSeconds rounds d up to whole seconds, as Retry-After has no finer
+// resolution, waiting at least one second.
+func retryAfterSeconds(d time.Duration) int {
+	s := int(math.Ceil(d.Seconds()))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+// minIdleLimiterSweep is the least time between two sweeps of the idle
+// limiters of an OrgRateLimiter.
+const minIdleLimiterSweep = time.Minute
+
+// OrgRateLimiter is a WriteRateLimiter that gives each org a token bucket
+// of its own. The bucket of an org that stops writing is dropped once it
+// has refilled, as a full bucket is the same as a new one, so that the
+// buckets kept are only those of the orgs writing.
+type OrgRateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	limiters  map[influxdb.ID]*orgLimiter
+	lastSweep time.Time
+	now       func() time.Time
+
+	// cost is the number of tokens a write takes.
+	cost func(points, bytes int) int
+}
+
+// NewPointsRateLimiter limits each org to writing limit points per second,
+// with bursts of up to burst points.
+func NewPointsRateLimiter(limit rate.Limit, burst int) *OrgRateLimiter {
+	return newOrgRateLimiter(limit, burst, func(points, _ int) int { return points })
+}
+
+// NewBytesRateLimiter limits each org to writing limit bytes of line
+// protocol per second, with bursts of up to burst bytes.
+func NewBytesRateLimiter(limit rate.Limit, burst int) *OrgRateLimiter {
+	return newOrgRateLimiter(limit, burst, func(_, bytes int) int { return bytes })
+}
+
+// orgLimiter is the token bucket of an org along with when it last wrote.
+type orgLimiter struct {
+	*rate.Limiter
+	lastWrite time.Time
+}
+
+func newOrgRateLimiter(limit rate.Limit, burst int, cost func(points, bytes int) int) *OrgRateLimiter {
+	return &OrgRateLimiter{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[influxdb.ID]*orgLimiter),
+		now:      time.Now,
+		cost:     cost,
+	}
+}
+
+// ReserveWrite takes the cost of the write from the bucket of orgID. A write
+// costing more than the burst takes the whole burst, so that large writes
+// are admitted once the bucket is full rather than never.
+func (l *OrgRateLimiter) ReserveWrite(_ context.Context, orgID influxdb.ID, points, bytes int) (time.Duration, bool) {
+	now := l.now()
+
+	l.mu.Lock()
+	l.dropIdle(now)
+	lim, ok := l.limiters[orgID]
+	if !ok {
+		lim = &orgLimiter{Limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[orgID] = lim
+	}
+	lim.lastWrite = now
+	l.mu.Unlock()
+
+	n := l.cost(points, bytes)
+	if n > l.burst {
+		n = l.burst
+	}
+
+	r := lim.ReserveN(now, n)
+	if !r.OK() {
+		return 0, false
+	}
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return d, false
+	}
+	return 0, true
+}
+
+// dropIdle drops the limiters that have not written for long enough to have
+// refilled their whole burst, sweeping at most once per idle period. Limiters
+// of a zero limit never refill and are never dropped.
+func (l *OrgRateLimiter) dropIdle(now time.Time) {
+	if l.limit <= 0 {
+		return
+	}
+	idle := minIdleLimiterSweep
+	if l.limit != rate.Inf {
+		if refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second)); refill > idle {
+			idle = refill
+		}
+	}
+	if now.Sub(l.lastSweep) < idle {
+		return
+	}
+	l.lastSweep = now
+	for id, lim := range l.limiters {
+		if now.Sub(lim.lastWrite) >= idle {
+			delete(l.limiters, id)
+		}
+	}
+}
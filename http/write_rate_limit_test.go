@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+func TestOrgRateLimiter_ReserveWrite(t *testing.T) {
+	var (
+		org1 = influxtesting.MustIDBase16("043e0780ee2b1000")
+		org2 = influxtesting.MustIDBase16("043e0780ee2b1001")
+	)
+
+	now := time.Unix(0, 0)
+	l := NewPointsRateLimiter(10, 20)
+	l.now = func() time.Time { return now }
+
+	if _, ok := l.ReserveWrite(context.Background(), org1, 15, 100); !ok {
+		t.Fatal("expected write within the burst to be allowed")
+	}
+	d, ok := l.ReserveWrite(context.Background(), org1, 10, 100)
+	if ok {
+		t.Fatal("expected write exceeding the rate to be limited")
+	}
+	if want := 500 * time.Millisecond; d != want {
+		t.Errorf("unexpected retry after: got %v want %v", d, want)
+	}
+	if _, ok := l.ReserveWrite(context.Background(), org2, 10, 100); !ok {
+		t.Error("expected other org not to be limited")
+	}
+
+	// the rejected write was not accounted, so waiting as told admits it.
+	now = now.Add(d)
+	if _, ok := l.ReserveWrite(context.Background(), org1, 10, 100); !ok {
+		t.Error("expected write to be allowed after waiting")
+	}
+
+	// a write larger than the burst is admitted once the bucket is full.
+	now = now.Add(time.Minute)
+	if _, ok := l.ReserveWrite(context.Background(), org1, 50, 100); !ok {
+		t.Error("expected write larger than the burst to be allowed with a full bucket")
+	}
+}
+
+func TestOrgRateLimiter_dropIdle(t *testing.T) {
+	var (
+		org1 = influxtesting.MustIDBase16("043e0780ee2b1000")
+		org2 = influxtesting.MustIDBase16("043e0780ee2b1001")
+	)
+
+	now := time.Unix(0, 0)
+	// refilling the burst takes 2 minutes.
+	l := NewPointsRateLimiter(1, 120)
+	l.now = func() time.Time { return now }
+
+	if _, ok := l.ReserveWrite(context.Background(), org1, 120, 100); !ok {
+		t.Fatal("expected write within the burst to be allowed")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := l.ReserveWrite(context.Background(), org2, 1, 100); !ok {
+		t.Fatal("expected other org not to be limited")
+	}
+
+	// org1 has not refilled yet, so it is kept and still limited.
+	if _, ok := l.ReserveWrite(context.Background(), org1, 120, 100); ok {
+		t.Error("expected the limiter of a writing org to be kept")
+	}
+	if got := len(l.limiters); got != 2 {
+		t.Errorf("unexpected number of limiters: got %d want 2", got)
+	}
+
+	// org2 stops writing while org1 keeps writing.
+	now = now.Add(time.Minute)
+	if _, ok := l.ReserveWrite(context.Background(), org1, 1, 100); !ok {
+		t.Fatal("expected write to be allowed once the bucket refilled")
+	}
+	lim := l.limiters[org1]
+	for i := 0; i < 2; i++ {
+		now = now.Add(time.Minute)
+		if _, ok := l.ReserveWrite(context.Background(), org1, 1, 100); !ok {
+			t.Fatal("expected write within the burst to be allowed")
+		}
+	}
+	if _, ok := l.limiters[org2]; ok {
+		t.Error("expected the limiter of an idle org to be dropped")
+	}
+	if l.limiters[org1] != lim {
+		t.Error("expected the limiter of a writing org to be kept")
+	}
+}
+
+func TestNewBytesRateLimiter(t *testing.T) {
+	org := influxtesting.MustIDBase16("043e0780ee2b1000")
+
+	now := time.Unix(0, 0)
+	l := NewBytesRateLimiter(100, 100)
+	l.now = func() time.Time { return now }
+
+	if _, ok := l.ReserveWrite(context.Background(), org, 1000, 80); !ok {
+		t.Fatal("expected write within the burst to be allowed")
+	}
+	if _, ok := l.ReserveWrite(context.Background(), org, 1, 80); ok {
+		t.Error("expected write exceeding the rate to be limited")
+	}
+}
+
+func TestWriteHandler_handleWrite_rateLimit(t *testing.T) {
+	limiter := NewPointsRateLimiter(1, 2)
+	limiter.now = func() time.Time { return time.Unix(0, 0) }
+
+	writeHandler, pw := newV1WriteHandler(t, WithWriteRateLimiter(limiter))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+	write := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := write("m1 f1=1\nm1 f1=2"); w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status code: got %d want %d", w.Code, http.StatusNoContent)
+	}
+
+	w := write("m1 f1=3\nm1 f1=4")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status code: got %d want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got, want := w.Header().Get("Retry-After"), "2"; got != want {
+		t.Errorf("unexpected Retry-After: got %q want %q", got, want)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), `{"code":"too many requests","message":"org write rate limit exceeded"}`; got != want {
+		t.Errorf("unexpected body: got %s want %s", got, want)
+	}
+	if got := len(pw.Points); got != 2 {
+		t.Errorf("unexpected points written: got %d want 2", got)
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// WithRetentionCheck rejects writes with points older than the retention
+// period of their bucket, which storage would otherwise drop silently. No
+// point of a rejected write is written.
+func WithRetentionCheck() WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.checkRetention = true
+	}
+}
+
+// validateRetention returns an invalid error when some of points are older
+// than the retention period of bucket at now.
+func (h *WriteHandler) validateRetention(bucket *influxdb.Bucket, points models.Points, now time.Time) error {
+	if !h.checkRetention || bucket.RetentionPeriod <= 0 {
+		return nil
+	}
+
+	cutoff := now.Add(-bucket.RetentionPeriod)
+	var (
+		expired int
+		oldest  time.Time
+	)
+	for _, p := range points {
+		if t := p.Time(); t.Before(cutoff) {
+			if expired == 0 || t.Before(oldest) {
+				oldest = t
+			}
+			expired++
+		}
+	}
+	if expired == 0 {
+		return nil
+	}
+
+	return &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Op:   opWriteHandler,
+		Msg: fmt.Sprintf("%d of %d points are older than the retention period %s of bucket %q, the oldest at %s",
+			expired, len(points), bucket.RetentionPeriod, bucket.Name, oldest.UTC().Format(time.RFC3339Nano)),
+	}
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_retentionCheck(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	now := time.Now()
+	recent := fmt.Sprintf("m1 f1=1 %d", now.Add(-time.Minute).UnixNano())
+	expired := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := fmt.Sprintf("m1 f1=2 %d", expired.UnixNano())
+
+	tests := []struct {
+		name      string
+		opts      []WriteHandlerOption
+		retention time.Duration
+		body      string
+		code      int
+		err       string
+		points    int
+	}{
+		{
+			name:      "points within the retention period",
+			opts:      []WriteHandlerOption{WithRetentionCheck()},
+			retention: time.Hour,
+			body:      recent,
+			code:      http.StatusNoContent,
+			points:    1,
+		},
+		{
+			name:      "points older than the retention period",
+			opts:      []WriteHandlerOption{WithRetentionCheck()},
+			retention: time.Hour,
+			body:      recent + "\n" + old,
+			code:      http.StatusBadRequest,
+			err:       `1 of 2 points are older than the retention period 1h0m0s of bucket \"telegraf\", the oldest at 2000-01-01T00:00:00Z`,
+		},
+		{
+			name:      "infinite retention",
+			opts:      []WriteHandlerOption{WithRetentionCheck()},
+			retention: 0,
+			body:      old,
+			code:      http.StatusNoContent,
+			points:    1,
+		},
+		{
+			name:      "check disabled",
+			retention: time.Hour,
+			body:      old,
+			code:      http.StatusNoContent,
+			points:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				b := testBucket(orgID, bucketID)
+				b.Name = "telegraf"
+				b.RetentionPeriod = tt.retention
+				return b, nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d", got, tt.code)
+			}
+			if tt.err != "" {
+				if got := w.Body.String(); !strings.Contains(got, tt.err) {
+					t.Errorf("unexpected body: got %s want it to contain %s", got, tt.err)
+				}
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const opWriteHandlerShutdown = "http/WriteHandler.Shutdown"
+
+// flusher is implemented by points writers that buffer points, such as
+// storage.BufferedPointsWriter.
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Shutdown stops the handler from accepting new writes, waits for writes in
+// flight to finish and flushes the points writer if it buffers points. New
+// writes are rejected as unavailable. An error is returned when ctx is done
+// before the writes in flight finish.
+func (h *WriteHandler) Shutdown(ctx context.Context) error {
+	h.shutdownMu.Lock()
+	h.shuttingDown = true
+	h.shutdownMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return &influxdb.Error{
+			Code: influxdb.EUnavailable,
+			Op:   opWriteHandlerShutdown,
+			Msg:  "timed out waiting for writes in flight",
+			Err:  ctx.Err(),
+		}
+	}
+
+	if f, ok := h.PointsWriter.(flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// beginWrite registers a write in flight, which must be ended with
+// h.inflight.Done. It returns an unavailable error once the handler is
+// shutting down.
+func (h *WriteHandler) beginWrite() error {
+	h.shutdownMu.RLock()
+	defer h.shutdownMu.RUnlock()
+
+	if h.shuttingDown {
+		return &influxdb.Error{
+			Code: influxdb.EUnavailable,
+			Op:   opWriteHandler,
+			Msg:  "write handler is shutting down",
+		}
+	}
+	h.inflight.Add(1)
+	return nil
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// flushingPointsWriter blocks writes until release is closed and records
+// calls to Flush.
+type flushingPointsWriter struct {
+	mock.PointsWriter
+	started chan struct{}
+	release chan struct{}
+	flushed bool
+}
+
+func (w *flushingPointsWriter) WritePoints(ctx context.Context, points []models.Point) error {
+	close(w.started)
+	<-w.release
+	return w.PointsWriter.WritePoints(ctx, points)
+}
+
+func (w *flushingPointsWriter) Flush(context.Context) error {
+	w.flushed = true
+	return nil
+}
+
+func TestWriteHandler_Shutdown(t *testing.T) {
+	writeHandler, _ := newV1WriteHandler(t)
+	pw := &flushingPointsWriter{started: make(chan struct{}), release: make(chan struct{})}
+	writeHandler.PointsWriter = pw
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+	write := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader("m1 f1=1"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	inflight := make(chan *httptest.ResponseRecorder)
+	go func() { inflight <- write() }()
+	<-pw.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := writeHandler.Shutdown(ctx); influxdb.ErrorCode(err) != influxdb.EUnavailable {
+		t.Fatalf("expected drain to time out with an unavailable error, got %v", err)
+	}
+	if pw.flushed {
+		t.Error("points writer flushed before writes in flight finished")
+	}
+
+	if w := write(); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected status code for write during shutdown: got %d want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	done := make(chan error)
+	go func() { done <- writeHandler.Shutdown(context.Background()) }()
+	close(pw.release)
+
+	if w := <-inflight; w.Code != http.StatusNoContent {
+		t.Errorf("unexpected status code for write in flight: got %d want %d", w.Code, http.StatusNoContent)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error shutting down: %v", err)
+	}
+	if !pw.flushed {
+		t.Error("points writer was not flushed")
+	}
+	if got := len(pw.Points); got != 1 {
+		t.Errorf("unexpected points written: got %d want 1", got)
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// PointSink receives the points of a write to a bucket once they are parsed
+// and validated. The points are named by the encoded org and bucket.
+type PointSink interface {
+	Write(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error
+}
+
+// PointSinkFunc is a function that implements PointSink.
+type PointSinkFunc func(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error
+
+// Write calls fn.
+func (fn PointSinkFunc) Write(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error {
+	return fn(ctx, orgID, bucketID, points)
+}
+
+// WithPointSink writes points to s rather than to the PointsWriter of the
+// handler, which is the default sink. Errors of s fail the write as errors of
+// the PointsWriter do.
+func WithPointSink(s PointSink) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.pointSink = s
+	}
+}
+
+// PointsWriterSink returns a PointSink writing to the storage writer w, such as
+// the PointsWriter of the handler, to mirror writes with FanOutPointSink.
+func PointsWriterSink(w storage.PointsWriter) PointSink {
+	return PointSinkFunc(func(ctx context.Context, _, _ influxdb.ID, points []models.Point) error {
+		return w.WritePoints(ctx, points)
+	})
+}
+
+// FanOutPointSink returns a PointSink writing to each of sinks in order. It
+// stops at the first error, so that with storage first the other sinks only
+// receive points that were stored.
+func FanOutPointSink(sinks ...PointSink) PointSink {
+	return PointSinkFunc(func(ctx context.Context, orgID, bucketID influxdb.ID, points []models.Point) error {
+		for _, s := range sinks {
+			if err := s.Write(ctx, orgID, bucketID, points); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
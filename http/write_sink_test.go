@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_pointSink(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name       string
+		storageErr error
+		code       int
+		stored     int
+		mirrored   int
+	}{
+		{
+			name:     "mirrored to every sink",
+			code:     http.StatusNoContent,
+			stored:   2,
+			mirrored: 2,
+		},
+		{
+			name:       "storage error stops the fan out",
+			storageErr: errors.New("disk full"),
+			code:       http.StatusInternalServerError,
+			// the mock keeps the points it fails to write.
+			stored: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{Err: tt.storageErr}
+
+			var mirrored []models.Point
+			mirror := PointSinkFunc(func(_ context.Context, oid, bid influxdb.ID, points []models.Point) error {
+				if oid.String() != orgID || bid.String() != bucketID {
+					t.Errorf("unexpected org and bucket: got %s and %s", oid, bid)
+				}
+				mirrored = append(mirrored, points...)
+				return nil
+			})
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b),
+				WithPointSink(FanOutPointSink(PointsWriterSink(pw), mirror)))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader("m1 f1=1\nm1 f1=2"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := len(pw.Points); got != tt.stored {
+				t.Errorf("unexpected points stored: got %d want %d", got, tt.stored)
+			}
+			if got := len(mirrored); got != tt.mirrored {
+				t.Errorf("unexpected points mirrored: got %d want %d", got, tt.mirrored)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// headerResolvedOrgID is the response header of the org a write
+	// resolved to.
+	headerResolvedOrgID = "X-Influx-Org-ID"
+	// headerResolvedBucketID is the response header of the bucket a write
+	// resolved to.
+	headerResolvedBucketID = "X-Influx-Bucket-ID"
+)
+
+// WithResolvedTargetHeaders sets the X-Influx-Org-ID and X-Influx-Bucket-ID
+// response headers of a write to the org and bucket it resolved to, once
+// resolved, so that clients can confirm where their points go. This is most
+// useful for v1 writes, whose bucket is found through the dbrp mappings.
+func WithResolvedTargetHeaders() WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.targetHeaders = true
+	}
+}
+
+// setTargetHeader sets the response header of a resolved org or bucket when
+// the handler sets them.
+func (h *WriteHandler) setTargetHeader(w http.ResponseWriter, header string, id influxdb.ID) {
+	if h.targetHeaders {
+		w.Header().Set(header, id.String())
+	}
+}
+
+// setResolvedBucket records the bucket a write resolved to on its span, its
+// access log entry and, when the handler sets them, its response headers.
+func (h *WriteHandler) setResolvedBucket(ctx context.Context, span opentracing.Span, w http.ResponseWriter, id influxdb.ID) {
+	span.SetTag("bucket_id", id.String())
+	setAccessLogBucket(ctx, id)
+	h.setTargetHeader(w, headerResolvedBucketID, id)
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_resolvedTargetHeaders(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg(orgID), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket(orgID, bucketID), nil
+	}
+	newHandler := func(opts ...WriteHandlerOption) http.Handler {
+		b := &APIBackend{
+			HTTPErrorHandler:    DefaultErrorHandler,
+			OrganizationService: orgs,
+			BucketService:       buckets,
+			PointsWriter:        &mock.PointsWriter{},
+			WriteEventRecorder:  &metric.NopEventRecorder{},
+		}
+		h := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), opts...)
+		return httpmock.NewAuthMiddlewareHandler(h, bucketWritePermission(orgID, bucketID))
+	}
+	v1Handler := func(opts ...WriteHandlerOption) http.Handler {
+		h, _ := newV1WriteHandler(t, opts...)
+		return httpmock.NewAuthMiddlewareHandler(h, bucketWritePermission(v1OrgID, v1BucketID))
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		query    string
+		orgID    string
+		bucketID string
+	}{
+		{
+			name:     "v2 write",
+			handler:  newHandler(WithResolvedTargetHeaders()),
+			query:    "org=org&bucket=bucket",
+			orgID:    orgID,
+			bucketID: bucketID,
+		},
+		{
+			name:     "v1 write",
+			handler:  v1Handler(WithResolvedTargetHeaders()),
+			query:    "db=telegraf",
+			orgID:    v1OrgID,
+			bucketID: v1BucketID,
+		},
+		{
+			name:    "disabled by default",
+			handler: newHandler(),
+			query:   "org=org&bucket=bucket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != http.StatusNoContent {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusNoContent, w.Body.String())
+			}
+			if got := w.Header().Get("X-Influx-Org-ID"); got != tt.orgID {
+				t.Errorf("unexpected org id header: got %q want %q", got, tt.orgID)
+			}
+			if got := w.Header().Get("X-Influx-Bucket-ID"); got != tt.bucketID {
+				t.Errorf("unexpected bucket id header: got %q want %q", got, tt.bucketID)
+			}
+		})
+	}
+}
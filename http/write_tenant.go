@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// tenantStrategy is how the org and bucket of a write are resolved, picked
+// from the parameters of the write so that obvious v1 and v2 writes are
+// resolved in a single way.
+type tenantStrategy int
+
+const (
+	// tenantV2 resolves the org and bucket parameters.
+	tenantV2 tenantStrategy = iota
+	// tenantV1 resolves the dbrp mapping of the db and rp parameters.
+	tenantV1
+	// tenantEither resolves writes with both a bucket and a db parameter
+	// both ways at once, preferring the bucket.
+	tenantEither
+	// tenantToken resolves the bucket the token of the write is scoped to.
+	tenantToken
+)
+
+// writeTenantStrategy returns how the org and bucket of req are resolved.
+func writeTenantStrategy(req *writeRequest) tenantStrategy {
+	switch {
+	case req.TokenScoped:
+		return tenantToken
+	case req.Database == "":
+		return tenantV2
+	case req.Bucket == "":
+		return tenantV1
+	}
+	return tenantEither
+}
+
+// findTenant resolves the org of the write req of r and, unless it is left
+// to be found or created by its name, its bucket.
+func (h *WriteHandler) findTenant(ctx context.Context, r *http.Request, req *writeRequest, auth influxdb.Authorizer) (*influxdb.Organization, *influxdb.Bucket, error) {
+	switch writeTenantStrategy(req) {
+	case tenantToken:
+		return h.findTenantFromToken(ctx, auth)
+	case tenantV1:
+		return h.findTenantV1(ctx, r, req)
+	case tenantEither:
+		return h.findTenantEither(ctx, r, req)
+	}
+	org, err := h.findOrgV2(ctx, r)
+	return org, nil, err
+}
+
+// findTenantEither resolves a write with both a bucket and a db parameter as
+// a v2 write and as a v1 write concurrently. The bucket is preferred when it
+// exists, the dbrp mapping of the database is used otherwise. When neither
+// resolves the write is resolved as a v2 write, the bucket being left to be
+// found or created by its name.
+func (h *WriteHandler) findTenantEither(ctx context.Context, r *http.Request, req *writeRequest) (*influxdb.Organization, *influxdb.Bucket, error) {
+	type tenant struct {
+		org    *influxdb.Organization
+		bucket *influxdb.Bucket
+		err    error
+	}
+	v1 := make(chan tenant, 1)
+	go func() {
+		org, bucket, err := h.findTenantV1(ctx, r, req)
+		v1 <- tenant{org: org, bucket: bucket, err: err}
+	}()
+
+	org, err := h.findOrgV2(ctx, r)
+	if err != nil {
+		if t := <-v1; t.err == nil {
+			return t.org, t.bucket, nil
+		}
+		return nil, nil, err
+	}
+	bucket, err := h.findBucket(ctx, org.ID, req.Bucket)
+	if err == nil {
+		return org, bucket, nil
+	}
+	if t := <-v1; t.err == nil {
+		return t.org, t.bucket, nil
+	}
+	return org, nil, nil
+}
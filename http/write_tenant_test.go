@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteTenantStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		req  writeRequest
+		want tenantStrategy
+	}{
+		{name: "org and bucket", req: writeRequest{Org: "org", Bucket: "bucket"}, want: tenantV2},
+		{name: "db", req: writeRequest{Database: "telegraf"}, want: tenantV1},
+		{name: "db and rp", req: writeRequest{Database: "telegraf", RetentionPolicy: "autogen"}, want: tenantV1},
+		{name: "org and db", req: writeRequest{Org: "org", Database: "telegraf"}, want: tenantV1},
+		{name: "bucket and db", req: writeRequest{Bucket: "bucket", Database: "telegraf"}, want: tenantEither},
+		{name: "token scoped", req: writeRequest{TokenScoped: true}, want: tenantToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writeTenantStrategy(&tt.req); got != tt.want {
+				t.Errorf("unexpected strategy: got %d want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHandler_handleWrite_tenant(t *testing.T) {
+	const (
+		orgID      = "043e0780ee2b1000"
+		bucketID   = "04504b356e23b000"
+		v1BucketID = "04504b356e23b001"
+	)
+
+	tests := []struct {
+		name   string
+		query  string
+		code   int
+		bucket string
+		// v1 and v2 are whether the dbrp mappings and the org and bucket
+		// parameters are looked up. The dbrp mappings of a write resolved by
+		// its bucket first may or may not have been looked up, as raced.
+		v1, v2, raced bool
+	}{
+		{
+			name:   "v2",
+			query:  "org=myorg&bucket=mybucket",
+			code:   http.StatusNoContent,
+			bucket: bucketID,
+			v2:     true,
+		},
+		{
+			name:   "v1",
+			query:  "db=telegraf&rp=autogen",
+			code:   http.StatusNoContent,
+			bucket: v1BucketID,
+			v1:     true,
+		},
+		{
+			name:   "ambiguous with an existing bucket",
+			query:  "org=myorg&bucket=mybucket&db=telegraf",
+			code:   http.StatusNoContent,
+			bucket: bucketID,
+			v2:     true,
+			raced:  true,
+		},
+		{
+			name:   "ambiguous with an unknown bucket",
+			query:  "org=myorg&bucket=unknown&db=telegraf",
+			code:   http.StatusNoContent,
+			bucket: v1BucketID,
+			v1:     true,
+			v2:     true,
+		},
+		{
+			name:  "ambiguous with neither",
+			query: "org=myorg&bucket=unknown&db=unknown",
+			code:  http.StatusNotFound,
+			v1:    true,
+			v2:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v1Lookups, v2Lookups int32
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationByIDF = func(context.Context, influxdb.ID) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				atomic.AddInt32(&v2Lookups, 1)
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketByIDFn = func(_ context.Context, id influxdb.ID) (*influxdb.Bucket, error) {
+				return testBucket(orgID, id.String()), nil
+			}
+			buckets.FindBucketFn = func(_ context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				atomic.AddInt32(&v2Lookups, 1)
+				if f.Name == nil || *f.Name != "mybucket" {
+					return nil, &influxdb.Error{Code: influxdb.ENotFound, Msg: "bucket not found"}
+				}
+				return testBucket(orgID, bucketID), nil
+			}
+			dbrps := &mock.DBRPMappingServiceV2{
+				FindManyFn: func(_ context.Context, f influxdb.DBRPMappingFilterV2, _ ...influxdb.FindOptions) ([]*influxdb.DBRPMappingV2, int, error) {
+					atomic.AddInt32(&v1Lookups, 1)
+					if *f.Database != "telegraf" {
+						return nil, 0, nil
+					}
+					return []*influxdb.DBRPMappingV2{{
+						Database:        "telegraf",
+						RetentionPolicy: "autogen",
+						Default:         true,
+						OrganizationID:  influxtesting.MustIDBase16(orgID),
+						BucketID:        influxtesting.MustIDBase16(v1BucketID),
+					}}, 1, nil
+				},
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				DBRPService:         dbrps,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			auth := bucketWritePermission(orgID, bucketID)
+			auth.Permissions = append(auth.Permissions, bucketWritePermission(orgID, v1BucketID).Permissions...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, auth)
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader("m f=1 1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if n := atomic.LoadInt32(&v1Lookups); (n > 0) != tt.v1 && !tt.raced {
+				t.Errorf("unexpected dbrp lookups: %d", n)
+			}
+			if n := atomic.LoadInt32(&v2Lookups); (n > 0) != tt.v2 {
+				t.Errorf("unexpected org and bucket lookups: %d", n)
+			}
+			if tt.bucket == "" {
+				return
+			}
+			if len(pw.Points) != 1 {
+				t.Fatalf("unexpected points written: %d", len(pw.Points))
+			}
+			_, got := tsdb.DecodeNameSlice(pw.Points[0].Name())
+			if got.String() != tt.bucket {
+				t.Errorf("unexpected bucket: got %s want %s", got, tt.bucket)
+			}
+		})
+	}
+}
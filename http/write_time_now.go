@@ -0,0 +1,22 @@
+package http
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// paramSetTimeNow is the parameter of writes, such as backfills from hosts
+// whose clocks cannot be trusted, timestamping every point with the time the
+// server received it rather than with the timestamp of its line.
+const paramSetTimeNow = "set-time-now"
+
+// applyTimeNow sets the timestamp of every point to now, truncated to
+// precision. Points of a series that only differed by their timestamps
+// overwrite each other.
+func applyTimeNow(points []models.Point, now time.Time, precision string) {
+	now = now.Truncate(time.Duration(models.GetPrecisionMultiplier(precision)))
+	for _, p := range points {
+		p.SetTime(now)
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_setTimeNow(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name   string
+		params string
+		code   int
+		now    bool
+	}{
+		{
+			name:   "server time",
+			params: "&set-time-now=true",
+			code:   http.StatusNoContent,
+			now:    true,
+		},
+		{
+			name:   "server time in seconds",
+			params: "&set-time-now=true&precision=s",
+			code:   http.StatusNoContent,
+			now:    true,
+		},
+		{
+			name: "line timestamps by default",
+			code: http.StatusNoContent,
+		},
+		{
+			name:   "invalid value",
+			params: "&set-time-now=maybe",
+			code:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			body := "m f=1 1\nm f=2 2\nm,t=a f=3"
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID+tt.params, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			before := time.Now().Add(-time.Second)
+			handler.ServeHTTP(w, r)
+			after := time.Now()
+
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if tt.code != http.StatusNoContent {
+				return
+			}
+			if got := len(pw.Points); got != 3 {
+				t.Fatalf("unexpected points written: got %d want 3", got)
+			}
+			if !tt.now {
+				if got := pw.Points[0].UnixNano(); got != 1 {
+					t.Errorf("unexpected timestamp: got %d want 1", got)
+				}
+				return
+			}
+
+			ts := pw.Points[0].Time()
+			if ts.Before(before) || ts.After(after) {
+				t.Errorf("unexpected timestamp %v, want the time of the write", ts)
+			}
+			for i, p := range pw.Points {
+				if !p.Time().Equal(ts) {
+					t.Errorf("point %d: unexpected timestamp: got %v want %v", i, p.Time(), ts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/storage"
+)
+
+// WithWriteTimeout bounds the time the points writer is given to store the
+// points of a write, leaving the time taken to read the body unbounded. A
+// write that exceeds it fails with a 504 Gateway Timeout. The writer must
+// honour the context it is passed for the timeout to take effect. Zero, the
+// default, means no timeout.
+func WithWriteTimeout(d time.Duration) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.writeTimeout = d
+	}
+}
+
+// writePoints calls write with ctx bounded by the write timeout of the
+// handler, returning an error with the op of the caller when write fails.
+func (h *WriteHandler) writePoints(ctx context.Context, op string, write func(ctx context.Context) error) error {
+	wctx := ctx
+	if h.writeTimeout > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithTimeout(ctx, h.writeTimeout)
+		defer cancel()
+	}
+
+	err := write(wctx)
+	if err == nil {
+		return nil
+	}
+	// only the write deadline, not that of the request, is a timeout.
+	if wctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &influxdb.Error{
+			Code: influxdb.ETimeout,
+			Op:   op,
+			Msg:  fmt.Sprintf("writing points timed out after %s", h.writeTimeout),
+			Err:  err,
+		}
+	}
+	if errors.Is(err, storage.ErrPointsWriterBusy) {
+		return &influxdb.Error{
+			Code: influxdb.EUnavailable,
+			Op:   op,
+			Msg:  "points writer is busy, retry later",
+			Err:  err,
+		}
+	}
+	return &influxdb.Error{
+		Code: influxdb.EInternal,
+		Op:   op,
+		Msg:  msgUnexpectedWriteError,
+		Err:  err,
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_writeTimeout(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+	)
+
+	tests := []struct {
+		name  string
+		opts  []WriteHandlerOption
+		delay time.Duration
+		code  int
+	}{
+		{
+			name:  "write within the timeout",
+			opts:  []WriteHandlerOption{WithWriteTimeout(time.Second)},
+			delay: time.Millisecond,
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "slow write",
+			opts:  []WriteHandlerOption{WithWriteTimeout(10 * time.Millisecond)},
+			delay: time.Minute,
+			code:  http.StatusGatewayTimeout,
+		},
+		{
+			name:  "no timeout by default",
+			delay: 50 * time.Millisecond,
+			code:  http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				return testBucket(orgID, bucketID), nil
+			}
+			pw := &mock.PointsWriter{
+				WritePointsFn: func(ctx context.Context, _ []models.Point) error {
+					select {
+					case <-time.After(tt.delay):
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				},
+			}
+
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(orgID, bucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+orgID+"&bucket="+bucketID, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if tt.code == http.StatusGatewayTimeout {
+				if got, want := w.Header().Get("X-Platform-Error-Code"), influxdb.ETimeout; got != want {
+					t.Errorf("unexpected error code: got %q want %q", got, want)
+				}
+			}
+		})
+	}
+}
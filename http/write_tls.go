@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	headerForwardedProto = "X-Forwarded-Proto"
+
+	msgTLSRequired = "writes must be sent over TLS; use https"
+)
+
+// WriteTLS is the TLS requirement of the write routes.
+type WriteTLS struct {
+	// TrustForwardedProto accepts requests whose X-Forwarded-Proto header is
+	// https as sent over TLS, for handlers behind a proxy terminating TLS.
+	// It is only to be set when every request comes through a trusted proxy
+	// that sets the header, as clients may set it themselves.
+	TrustForwardedProto bool
+}
+
+// WithRequireTLS rejects the writes of the write, batch and prometheus
+// routes that are not sent over TLS with a 426 Upgrade Required, so that
+// plaintext writes are never accepted. Without this option writes are
+// accepted however they are sent.
+func WithRequireTLS(tls WriteTLS) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.requireTLS = &tls
+	}
+}
+
+// sentOverTLS returns whether r was sent over TLS, to the handler or to the
+// trusted proxy in front of it.
+func (c *WriteTLS) sentOverTLS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return c.TrustForwardedProto && strings.EqualFold(r.Header.Get(headerForwardedProto), "https")
+}
+
+// withRequireTLS rejects the requests not sent over TLS before next, under
+// WithRequireTLS.
+func (h *WriteHandler) withRequireTLS(next http.Handler) http.Handler {
+	if h.requireTLS == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.requireTLS.sentOverTLS(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Upgrade", "TLS/1.2, HTTP/1.1")
+		w.Header().Set("Connection", "Upgrade")
+		h.HandleHTTPError(r.Context(), &influxdb.Error{
+			Code: influxdb.EUpgradeRequired,
+			Op:   opWriteHandler,
+			Msg:  msgTLSRequired,
+		}, w)
+	})
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+)
+
+func TestWriteHandler_requireTLS(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []WriteHandlerOption
+		tls    bool
+		proto  string
+		code   int
+		points int
+	}{
+		{
+			name:   "plaintext allowed by default",
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name: "plaintext rejected",
+			opts: []WriteHandlerOption{WithRequireTLS(WriteTLS{})},
+			code: http.StatusUpgradeRequired,
+		},
+		{
+			name:   "tls accepted",
+			opts:   []WriteHandlerOption{WithRequireTLS(WriteTLS{})},
+			tls:    true,
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name:  "forwarded proto not trusted",
+			opts:  []WriteHandlerOption{WithRequireTLS(WriteTLS{})},
+			proto: "https",
+			code:  http.StatusUpgradeRequired,
+		},
+		{
+			name:   "trusted forwarded proto",
+			opts:   []WriteHandlerOption{WithRequireTLS(WriteTLS{TrustForwardedProto: true})},
+			proto:  "HTTPS",
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name:  "trusted forwarded plaintext",
+			opts:  []WriteHandlerOption{WithRequireTLS(WriteTLS{TrustForwardedProto: true})},
+			proto: "http",
+			code:  http.StatusUpgradeRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeHandler, pw := newV1WriteHandler(t, tt.opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader("m1 f1=1"))
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				r.Header.Set(headerForwardedProto, tt.proto)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+			if tt.code == http.StatusUpgradeRequired {
+				if got, want := w.Body.String(), `{"code":"upgrade required","message":"`+msgTLSRequired+`"}`; got != want {
+					t.Errorf("unexpected body: got %s want %s", got, want)
+				}
+				if w.Header().Get("Upgrade") == "" {
+					t.Error("expected an Upgrade header")
+				}
+			}
+		})
+	}
+}
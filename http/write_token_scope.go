@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	opWriteTokenScope = "http/findTenantFromToken"
+
+	msgTokenNotScoped = "org and bucket required; the token is not scoped to a single bucket"
+)
+
+// WithTokenScopedTenant lets writes that omit org, orgID and bucket write to
+// the bucket their token is scoped to: the one bucket it may write to, as
+// found by authorizations. Writes of other tokens, and of sessions, still
+// require an org and bucket. Without an AuthorizationService the option does
+// nothing.
+func WithTokenScopedTenant(authorizations influxdb.AuthorizationService) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.tokenScopeAuthorizations = authorizations
+	}
+}
+
+// findTenantFromToken resolves the org and bucket of a TokenScoped write from
+// the write permissions of its token.
+func (h *WriteHandler) findTenantFromToken(ctx context.Context, auth influxdb.Authorizer) (*influxdb.Organization, *influxdb.Bucket, error) {
+	if _, ok := auth.(*influxdb.Authorization); !ok {
+		return nil, nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteTokenScope,
+			Msg:  msgTokenNotScoped,
+		}
+	}
+
+	a, err := h.tokenScopeAuthorizations.FindAuthorizationByID(ctx, auth.Identifier())
+	if err != nil {
+		return nil, nil, &influxdb.Error{
+			Op:  opWriteTokenScope,
+			Err: err,
+		}
+	}
+
+	var scope *influxdb.Resource
+	for i, p := range a.Permissions {
+		r := &a.Permissions[i].Resource
+		if p.Action != influxdb.WriteAction || r.Type != influxdb.BucketsResourceType {
+			continue
+		}
+		if r.ID == nil || r.OrgID == nil || (scope != nil && *scope.ID != *r.ID) {
+			return nil, nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Op:   opWriteTokenScope,
+				Msg:  msgTokenNotScoped,
+			}
+		}
+		scope = r
+	}
+	if scope == nil {
+		return nil, nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Op:   opWriteTokenScope,
+			Msg:  msgTokenNotScoped,
+		}
+	}
+
+	bucket, err := h.findBucket(ctx, *scope.OrgID, scope.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return &influxdb.Organization{ID: *scope.OrgID}, bucket, nil
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/http/metric"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/influxdata/influxdb/v2/mock"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHandler_handleWrite_tokenScopedTenant(t *testing.T) {
+	const (
+		orgID    = "043e0780ee2b1000"
+		bucketID = "04504b356e23b000"
+		otherID  = "04504b356e23b001"
+	)
+
+	twoBuckets := bucketWritePermission(orgID, bucketID)
+	twoBuckets.Permissions = append(twoBuckets.Permissions, bucketWritePermission(orgID, otherID).Permissions...)
+
+	tests := []struct {
+		name   string
+		opts   bool
+		auth   influxdb.Authorizer
+		query  string
+		code   int
+		points int
+	}{
+		{
+			name:   "scoped token",
+			opts:   true,
+			auth:   bucketWritePermission(orgID, bucketID),
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name:   "org and bucket take precedence",
+			opts:   true,
+			auth:   bucketWritePermission(orgID, bucketID),
+			query:  "?org=" + orgID + "&bucket=" + bucketID,
+			code:   http.StatusNoContent,
+			points: 1,
+		},
+		{
+			name: "token of two buckets",
+			opts: true,
+			auth: twoBuckets,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "session",
+			opts: true,
+			auth: &influxdb.Session{UserID: influxtesting.MustIDBase16(orgID)},
+			code: http.StatusBadRequest,
+		},
+		{
+			// the org defaults to the org of the token, the bucket is still
+			// required.
+			name: "disabled by default",
+			auth: bucketWritePermission(orgID, bucketID),
+			code: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := mock.NewOrganizationService()
+			orgs.FindOrganizationF = func(context.Context, influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+				return testOrg(orgID), nil
+			}
+			buckets := mock.NewBucketService()
+			buckets.FindBucketFn = func(_ context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+				if f.ID == nil || f.OrganizationID == nil || f.ID.String() != bucketID || f.OrganizationID.String() != orgID {
+					t.Errorf("unexpected bucket filter: %v", f)
+				}
+				return testBucket(orgID, bucketID), nil
+			}
+			auths := mock.NewAuthorizationService()
+			auths.FindAuthorizationByIDFn = func(context.Context, influxdb.ID) (*influxdb.Authorization, error) {
+				return tt.auth.(*influxdb.Authorization), nil
+			}
+			pw := &mock.PointsWriter{}
+
+			var opts []WriteHandlerOption
+			if tt.opts {
+				opts = append(opts, WithTokenScopedTenant(auths))
+			}
+			b := &APIBackend{
+				HTTPErrorHandler:    DefaultErrorHandler,
+				OrganizationService: orgs,
+				BucketService:       buckets,
+				PointsWriter:        pw,
+				WriteEventRecorder:  &metric.NopEventRecorder{},
+			}
+			writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b), opts...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, tt.auth)
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write"+tt.query, strings.NewReader("m1 f1=1"))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := len(pw.Points); got != tt.points {
+				t.Errorf("unexpected points written: got %d want %d", got, tt.points)
+			}
+			if tt.opts && tt.code == http.StatusBadRequest && !strings.Contains(w.Body.String(), msgTokenNotScoped) {
+				t.Errorf("unexpected error: %s", w.Body.String())
+			}
+		})
+	}
+}
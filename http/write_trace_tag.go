@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// WithTraceIDTag tags every point of a sampled write with the id of its trace
+// in tag, such as "trace_id", so that a spike of a metric can be followed to
+// the writes behind it, as with exemplars. The id is that of the Jaeger span
+// of the write, continuing the trace of the client when it sends one, see
+// WithTracer. Writes that are not sampled, whose traces are not recorded, are
+// written as is. Each trace adds its own series, so the sampling rate bounds
+// the cardinality the tag adds.
+func WithTraceIDTag(tag string) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.traceIDTag = tag
+	}
+}
+
+// tagTraceID tags points with the trace id of ctx, unless WithTraceIDTag is
+// not set or the trace of ctx is not sampled.
+func (h *WriteHandler) tagTraceID(ctx context.Context, points []models.Point) {
+	if h.traceIDTag == "" {
+		return
+	}
+	id, sampled, ok := tracing.InfoFromContext(ctx)
+	if !ok || !sampled {
+		return
+	}
+	for _, p := range points {
+		p.AddTag(h.traceIDTag, id)
+	}
+}
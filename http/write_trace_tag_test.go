@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestWriteHandler_handleWrite_traceIDTag(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		opts        []WriteHandlerOption
+		traceparent string
+		want        string
+	}{
+		{
+			name:        "sampled trace",
+			opts:        []WriteHandlerOption{WithTraceIDTag("trace_id")},
+			traceparent: "00-" + traceID + "-00f067aa0ba902b7-01",
+			want:        traceID,
+		},
+		{
+			name:        "unsampled trace",
+			opts:        []WriteHandlerOption{WithTraceIDTag("trace_id")},
+			traceparent: "00-" + traceID + "-00f067aa0ba902b7-00",
+		},
+		{
+			name: "untraced write",
+			opts: []WriteHandlerOption{WithTraceIDTag("trace_id")},
+		},
+		{
+			name:        "disabled",
+			traceparent: "00-" + traceID + "-00f067aa0ba902b7-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// writes the client does not trace are not sampled.
+			tracer, closer := jaeger.NewTracer("influxd", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+			defer closer.Close()
+
+			writeHandler, pw := newV1WriteHandler(t, append(tt.opts, WithTracer(tracer))...)
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission(v1OrgID, v1BucketID))
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?org="+v1OrgID+"&bucket="+v1BucketID, strings.NewReader("m,host=a f=1\nm,host=b f=2"))
+			if tt.traceparent != "" {
+				r.Header.Set("traceparent", tt.traceparent)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != http.StatusNoContent {
+				t.Fatalf("unexpected status code: got %d want %d: %s", got, http.StatusNoContent, w.Body.String())
+			}
+			if len(pw.Points) != 2 {
+				t.Fatalf("unexpected points written: %d", len(pw.Points))
+			}
+			for i, p := range pw.Points {
+				if got := string(p.Tags().Get([]byte("trace_id"))); got != tt.want {
+					t.Errorf("point %d: unexpected trace_id tag: got %q want %q", i, got, tt.want)
+				}
+			}
+		})
+	}
+}
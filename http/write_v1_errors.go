@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+)
+
+// headerV1Error is the header InfluxDB 1.x set to the message of an error.
+const headerV1Error = "X-Influxdb-Error"
+
+// WithV1ErrorResponses responds to failed v1 writes, those addressed by the
+// db and rp parameters, as InfluxDB 1.x did: with a {"error": "..."} body and
+// the message in an X-Influxdb-Error header, so that telegraf and other v1
+// clients parse them. The statuses and the messages of unknown databases,
+// failed authorizations, oversized bodies and malformed lines are those of
+// 1.x. Other writes respond with the errors of the API.
+func WithV1ErrorResponses() WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.v1ErrorResponses = true
+	}
+}
+
+type v1ErrorsContextKey struct{}
+
+// withV1Errors marks the context of v1 writes to next to be responded to
+// with errors in the format of 1.x.
+func (h *WriteHandler) withV1Errors(next http.Handler) http.Handler {
+	if !h.v1ErrorResponses {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if qp := r.URL.Query(); qp.Get("bucket") == "" && qp.Get(paramV1Database) != "" {
+			r = r.WithContext(context.WithValue(r.Context(), v1ErrorsContextKey{}, qp.Get(paramV1Database)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// v1ErrorsDatabase returns the database of a v1 write to be responded to
+// with errors in the format of 1.x.
+func v1ErrorsDatabase(ctx context.Context) (string, bool) {
+	db, ok := ctx.Value(v1ErrorsContextKey{}).(string)
+	return db, ok
+}
+
+// HandleHTTPError responds with err, in the format of 1.x for v1 writes with
+// WithV1ErrorResponses.
+func (h *WriteHandler) HandleHTTPError(ctx context.Context, err error, w http.ResponseWriter) {
+	if db, ok := v1ErrorsDatabase(ctx); ok {
+		writeV1Error(ctx, w, err, v1ErrorMessage(err, db))
+		return
+	}
+	h.HTTPErrorHandler.HandleHTTPError(ctx, err, w)
+}
+
+// v1ErrorMessage returns the message 1.x responded with to a write to db
+// failing with err.
+func v1ErrorMessage(err error, db string) string {
+	var lpe *lineParseError
+	switch code := influxdb.ErrorCode(err); {
+	case errors.As(err, &lpe):
+		return fmt.Sprintf("unable to parse '%s': %v", lpe.Snippet, lpe.Err)
+	case code == influxdb.ENotFound:
+		return fmt.Sprintf("database not found: %q", db)
+	case code == influxdb.EUnauthorized:
+		return "authorization failed"
+	case code == influxdb.EForbidden:
+		return fmt.Sprintf("user is not authorized to write to database %q", db)
+	case code == influxdb.ETooLarge:
+		return http.StatusText(http.StatusRequestEntityTooLarge)
+	}
+	if e, ok := err.(*influxdb.Error); ok {
+		return e.Error()
+	}
+	return "An internal error has occurred"
+}
+
+// writeV1Error responds to a v1 write with an error as 1.x did.
+func writeV1Error(ctx context.Context, w http.ResponseWriter, err error, msg string) {
+	b, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{Error: msg})
+	w.Header().Set(headerV1Error, msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, influxdb.ErrorCode(err)))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+)
+
+func TestWriteHandler_v1ErrorResponses(t *testing.T) {
+	users := V1AuthorizerFunc(func(_ context.Context, username, password string) (influxdb.Authorizer, error) {
+		if username == "writer" && password == "secret" {
+			return bucketWritePermission(v1OrgID, v1BucketID), nil
+		}
+		return nil, errors.New("wrong username or password")
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		auth  influxdb.Authorizer
+		code  int
+		want  string
+	}{
+		{
+			name:  "success",
+			query: "db=telegraf&rp=autogen",
+			body:  "m1 f1=1",
+			code:  http.StatusNoContent,
+		},
+		{
+			name:  "database not found",
+			query: "db=mydb",
+			body:  "m1 f1=1",
+			code:  http.StatusNotFound,
+			want:  `{"error":"database not found: \"mydb\""}`,
+		},
+		{
+			name:  "malformed line",
+			query: "db=telegraf&rp=autogen",
+			body:  "m1 f1=1\nm1 f1=",
+			code:  http.StatusBadRequest,
+			want:  `{"error":"unable to parse 'm1 f1=': missing field value"}`,
+		},
+		{
+			name:  "invalid credentials",
+			query: "db=telegraf&rp=autogen&u=writer&p=letmein",
+			body:  "m1 f1=1",
+			code:  http.StatusUnauthorized,
+			want:  `{"error":"authorization failed"}`,
+		},
+		{
+			name:  "not authorized",
+			query: "db=telegraf&rp=autogen",
+			body:  "m1 f1=1",
+			auth:  bucketWritePermission(v1OrgID, "04504b356e23b001"),
+			code:  http.StatusForbidden,
+			want:  `{"error":"user is not authorized to write to database \"telegraf\""}`,
+		},
+		{
+			name:  "body too large",
+			query: "db=telegraf&rp=autogen",
+			body:  "m1 f1=1\nm1 f1=2\nm1 f1=3",
+			code:  http.StatusRequestEntityTooLarge,
+			want:  `{"error":"Request Entity Too Large"}`,
+		},
+		{
+			name:  "v2 writes keep the errors of the API",
+			query: "org=" + v1OrgID + "&bucket=" + v1BucketID,
+			body:  "m1 f1=",
+			code:  http.StatusBadRequest,
+			want:  `{"code":"invalid","message":"unable to parse line 1 \"m1 f1=\": missing field value","line":1,"snippet":"m1 f1="}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeHandler, _ := newV1WriteHandler(t, WithV1ErrorResponses(), WithV1Authorizer(users), WithMaxBatchSizeBytes(16))
+			auth := tt.auth
+			if auth == nil {
+				auth = bucketWritePermission(v1OrgID, v1BucketID)
+			}
+			handler := httpmock.NewAuthMiddlewareHandler(writeHandler, auth)
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:9999/api/v2/write?"+tt.query, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Code; got != tt.code {
+				t.Errorf("unexpected status code: got %d want %d: %s", got, tt.code, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("unexpected body:\ngot  %s\nwant %s", got, tt.want)
+			}
+			if strings.HasPrefix(tt.want, `{"error"`) && w.Header().Get(headerV1Error) == "" {
+				t.Errorf("expected an %s header", headerV1Error)
+			}
+		})
+	}
+}
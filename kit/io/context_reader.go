@@ -0,0 +1,28 @@
+package io
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader stops reading from the wrapped io.Reader once its context is
+// done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// NewContextReader returns an io.Reader that returns the context's error
+// instead of reading once ctx is done. A Read that is already blocked on the
+// wrapped reader is not interrupted, but no further reads are issued after
+// the context is canceled.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
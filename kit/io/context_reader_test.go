@@ -0,0 +1,35 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextReader_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ioutil.ReadAll(NewContextReader(ctx, bytes.NewBufferString("howdy")))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestContextReader_Happy(t *testing.T) {
+	out, err := ioutil.ReadAll(NewContextReader(context.Background(), bytes.NewBufferString("howdy")))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("howdy"), out)
+}
+
+func TestContextReader_Deadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	out, err := ioutil.ReadAll(NewContextReader(ctx, bytes.NewBufferString("howdy")))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("howdy"), out)
+}
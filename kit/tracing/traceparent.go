@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+// headerTraceparent is the header of the W3C trace context, see
+// https://www.w3.org/TR/trace-context/.
+const headerTraceparent = "traceparent"
+
+// ParseTraceparent parses a W3C traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, into a Jaeger span
+// context so that traces of W3C instrumented clients are continued.
+func ParseTraceparent(v string) (jaeger.SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent")
+	}
+	// later versions may append fields, but version 00 has exactly four.
+	if parts[0] == "00" && len(parts) != 4 {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent")
+	}
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent")
+	}
+
+	high, err := strconv.ParseUint(traceID[:16], 16, 64)
+	if err != nil {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent trace id")
+	}
+	low, err := strconv.ParseUint(traceID[16:], 16, 64)
+	if err != nil {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent trace id")
+	}
+	parent, err := strconv.ParseUint(spanID, 16, 64)
+	if err != nil {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent parent id")
+	}
+	f, err := strconv.ParseUint(flags, 16, 8)
+	if err != nil {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent flags")
+	}
+	if high == 0 && low == 0 || parent == 0 {
+		return jaeger.SpanContext{}, errors.New("invalid traceparent")
+	}
+
+	sampled := f&0x01 == 0x01
+	return jaeger.NewSpanContext(jaeger.TraceID{High: high, Low: low}, jaeger.SpanID(parent), 0, sampled, nil), nil
+}
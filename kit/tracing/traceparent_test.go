@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		value   string
+		traceID string
+		spanID  string
+		sampled bool
+		wantErr bool
+	}{
+		{
+			name:    "sampled",
+			value:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "f067aa0ba902b7",
+			sampled: true,
+		},
+		{
+			name:    "not sampled",
+			value:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "f067aa0ba902b7",
+		},
+		{
+			name:    "future version with extra fields",
+			value:   "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "f067aa0ba902b7",
+			sampled: true,
+		},
+		{name: "version 00 with extra fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "zero parent id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{name: "short trace id", value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01", wantErr: true},
+		{name: "not hex", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", sc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sc.TraceID().String(); got != test.traceID {
+				t.Errorf("unexpected trace id: got %s want %s", got, test.traceID)
+			}
+			if got := sc.SpanID().String(); got != test.spanID {
+				t.Errorf("unexpected span id: got %s want %s", got, test.spanID)
+			}
+			if got := sc.IsSampled(); got != test.sampled {
+				t.Errorf("unexpected sampled: got %v want %v", got, test.sampled)
+			}
+		})
+	}
+}
+
+func TestExtractFromHTTPRequestWithTracer_traceparent(t *testing.T) {
+	reporter := jaeger.NewInMemoryReporter()
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	request, err := http.NewRequest(http.MethodPost, "http://localhost/api/v2/write", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	span, _ := ExtractFromHTTPRequestWithTracer(tracer, request, "WriteHandler")
+	span.Finish()
+
+	sc := span.Context().(jaeger.SpanContext)
+	if got, want := sc.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("unexpected trace id: got %s want %s", got, want)
+	}
+	if got, want := sc.ParentID().String(), "f067aa0ba902b7"; got != want {
+		t.Errorf("unexpected parent id: got %s want %s", got, want)
+	}
+	if got := reporter.SpansSubmitted(); got != 1 {
+		t.Errorf("unexpected spans reported: got %d want 1", got)
+	}
+}
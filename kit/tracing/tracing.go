@@ -54,9 +54,23 @@ func InjectToHTTPRequest(span opentracing.Span, req *http.Request) {
 // Returns the request with updated tracing context.
 // Easier than adding this boilerplate everywhere.
 func ExtractFromHTTPRequest(req *http.Request, handlerName string) (opentracing.Span, *http.Request) {
-	spanContext, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	return ExtractFromHTTPRequestWithTracer(opentracing.GlobalTracer(), req, handlerName)
+}
+
+// ExtractFromHTTPRequestWithTracer is like ExtractFromHTTPRequest, using
+// tracer rather than the global tracer. A Jaeger tracer also continues
+// traces from a W3C traceparent header.
+func ExtractFromHTTPRequestWithTracer(tracer opentracing.Tracer, req *http.Request, handlerName string) (opentracing.Span, *http.Request) {
+	spanContext, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	if _, ok := tracer.(*jaeger.Tracer); ok && err != nil {
+		if v := req.Header.Get(headerTraceparent); v != "" {
+			if sc, perr := ParseTraceparent(v); perr == nil {
+				spanContext, err = sc, nil
+			}
+		}
+	}
 	if err != nil {
-		span, ctx := opentracing.StartSpanFromContext(req.Context(), "request")
+		span, ctx := opentracing.StartSpanFromContextWithTracer(req.Context(), tracer, "request")
 		annotateSpan(span, handlerName, req)
 
 		_ = LogError(span, err)
@@ -64,7 +78,7 @@ func ExtractFromHTTPRequest(req *http.Request, handlerName string) (opentracing.
 		return span, req.WithContext(ctx)
 	}
 
-	span := opentracing.StartSpan("request", opentracing.ChildOf(spanContext), ext.RPCServerOption(spanContext))
+	span := tracer.StartSpan("request", opentracing.ChildOf(spanContext), ext.RPCServerOption(spanContext))
 	annotateSpan(span, handlerName, req)
 
 	return span, req.WithContext(opentracing.ContextWithSpan(req.Context(), span))
@@ -7,7 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/influxdata/influxdb/v2"
 	"go.uber.org/zap"
@@ -239,11 +242,47 @@ func (a *API) Err(w http.ResponseWriter, r *http.Request, err error) {
 
 	if eb, ok := v.(ErrBody); ok {
 		w.Header().Set(PlatformErrorCodeHeader, eb.Code)
+		if acceptsPlainText(r) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			a.Write(w, status, []byte(eb.Msg+"\n"))
+			return
+		}
 	}
 
 	a.Respond(w, r, status, v)
 }
 
+// acceptsPlainText reports whether the request's Accept header prefers a
+// text/plain response over JSON. The media type with the highest q-value is
+// preferred, and of media types with the same q-value the one listed first.
+// JSON is preferred when neither is listed.
+func acceptsPlainText(r *http.Request) bool {
+	var preferred string
+	var preferredQ float64
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediatype, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		if mediatype == "*/*" {
+			mediatype = "application/json"
+		}
+		if mediatype != "text/plain" && mediatype != "application/json" {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > preferredQ {
+			preferred, preferredQ = mediatype, q
+		}
+	}
+	return preferred == "text/plain"
+}
+
 func (a *API) logErr(msg string, fields ...zap.Field) {
 	if a == nil || a.logger == nil {
 		return
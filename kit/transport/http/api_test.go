@@ -225,6 +225,82 @@ func Test_API(t *testing.T) {
 			t.Run(http.StatusText(tt.statusCode), fn)
 		}
 	})
+
+	t.Run("Err negotiates the response format", func(t *testing.T) {
+		expectedErr := &influxdb.Error{
+			Code: influxdb.ENotFound,
+			Msg:  "bucket not found",
+		}
+
+		responder := kithttp.NewAPI()
+		svr := func(w http.ResponseWriter, r *http.Request) {
+			responder.Err(w, r, expectedErr)
+		}
+
+		t.Run("json by default", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "application/json; charset=utf-8").
+				ExpectHeader(kithttp.PlatformErrorCodeHeader, influxdb.ENotFound).
+				ExpectBody(func(body *bytes.Buffer) {
+					var err kithttp.ErrBody
+					require.NoError(t, json.NewDecoder(body).Decode(&err))
+					assert.Equal(t, expectedErr.Msg, err.Msg)
+					assert.Equal(t, expectedErr.Code, err.Code)
+				})
+		})
+
+		t.Run("plain text when accepted", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Headers("Accept", "text/plain").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "text/plain; charset=utf-8").
+				ExpectHeader(kithttp.PlatformErrorCodeHeader, influxdb.ENotFound).
+				ExpectBody(func(body *bytes.Buffer) {
+					assert.Equal(t, expectedErr.Msg+"\n", body.String())
+				})
+		})
+
+		t.Run("json when preferred over plain text", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Headers("Accept", "application/json, text/plain").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "application/json; charset=utf-8")
+		})
+
+		t.Run("json when weighted over plain text", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Headers("Accept", "text/plain;q=0.1, application/json").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "application/json; charset=utf-8")
+		})
+
+		t.Run("plain text when weighted over json", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Headers("Accept", "application/json;q=0.5, */*;q=0.1, text/plain;q=0.9").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "text/plain; charset=utf-8")
+		})
+
+		t.Run("json when plain text is not acceptable", func(t *testing.T) {
+			testttp.
+				Get(t, "/foo").
+				Headers("Accept", "text/plain;q=0").
+				Do(http.HandlerFunc(svr)).
+				ExpectStatus(http.StatusNotFound).
+				ExpectHeader("Content-Type", "application/json; charset=utf-8")
+		})
+	})
 }
 
 func expectInfluxdbError(t *testing.T, expectedCode string, err error) {
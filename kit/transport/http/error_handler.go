@@ -83,6 +83,8 @@ var influxDBErrorToStatusCode = map[string]int{
 	influxdb.EUnauthorized:        http.StatusUnauthorized,
 	influxdb.EMethodNotAllowed:    http.StatusMethodNotAllowed,
 	influxdb.ETooLarge:            http.StatusRequestEntityTooLarge,
+	influxdb.ETimeout:             http.StatusGatewayTimeout,
+	influxdb.EUpgradeRequired:     http.StatusUpgradeRequired,
 }
 
 var httpStatusCodeToInfluxDBError = map[int]string{}
@@ -193,6 +193,12 @@ func filterOrganizationsFn(filter influxdb.OrganizationFilter) func(o *influxdb.
 		}
 	}
 
+	if filter.NamePrefix != nil {
+		return func(o *influxdb.Organization) bool {
+			return strings.HasPrefix(o.Name, *filter.NamePrefix)
+		}
+	}
+
 	return func(o *influxdb.Organization) bool { return true }
 }
 
@@ -268,7 +274,7 @@ func (s *Service) FindOrganizations(ctx context.Context, filter influxdb.Organiz
 	filterFn := filterOrganizationsFn(filter)
 	err := s.kv.View(ctx, func(tx Tx) error {
 		return forEachOrganization(ctx, tx, descending, func(o *influxdb.Organization) bool {
-			if filterFn(o) { // TODO: Currently filterFn is useless here as we have finished all filtering jobs before. Keep it for future changes.
+			if filterFn(o) {
 				if count >= offset {
 					os = append(os, o)
 				}
@@ -1073,6 +1073,13 @@ func skipWhitespace(buf []byte, i int) int {
 	return i
 }
 
+// ScanLine returns the end position in buf of the line of line protocol
+// starting at i, and the line. Newlines within quoted string fields do not
+// end a line.
+func ScanLine(buf []byte, i int) (int, []byte) {
+	return scanLine(buf, i)
+}
+
 // scanLine returns the end position in buf and the next line found within
 // buf.
 func scanLine(buf []byte, i int) (int, []byte) {
@@ -74,6 +74,9 @@ type OrganizationFilter struct {
 	Name   *string
 	ID     *ID
 	UserID *ID
+	// NamePrefix restricts the organizations to those whose name starts
+	// with it. It is ignored when Name or ID is set.
+	NamePrefix *string
 }
 
 func ErrInternalOrgServiceError(op string, err error) *Error {
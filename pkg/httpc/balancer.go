@@ -0,0 +1,153 @@
+package httpc
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHostCooldown is how long a client created WithAddrs skips a host
+// after failing to connect to it.
+const DefaultHostCooldown = 30 * time.Second
+
+// WithAddrs sets the hosts of the client, such as the replicas of a replica
+// set. Reads, GET and HEAD requests, are spread round-robin across the hosts,
+// skipping hosts that recently failed to connect for the cooldown set by
+// WithHostCooldown. A read is sent to the next host when the connection to
+// one fails. Other requests are sent to the first host. The hosts must serve
+// the API under the same path.
+func WithAddrs(addrs ...string) ClientOptFn {
+	return func(opt *clientOpt) error {
+		if len(addrs) == 0 {
+			return errors.New("must provide a non empty host address")
+		}
+		opt.addr = addrs[0]
+		opt.addrs = addrs
+		return nil
+	}
+}
+
+// WithHostCooldown sets how long a client created WithAddrs skips a host
+// after failing to connect to it.
+func WithHostCooldown(d time.Duration) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.hostCooldown = d
+		return nil
+	}
+}
+
+// balancer is a doer spreading reads across hosts.
+type balancer struct {
+	doer     doer
+	hosts    []*balancedHost
+	cooldown time.Duration
+	now      func() time.Time
+
+	next uint32
+}
+
+type balancedHost struct {
+	url *url.URL
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func newBalancer(d doer, addrs []string, cooldown time.Duration) (*balancer, error) {
+	if cooldown <= 0 {
+		cooldown = DefaultHostCooldown
+	}
+	b := &balancer{
+		doer:     d,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+	for _, addr := range addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, err
+		}
+		b.hosts = append(b.hosts, &balancedHost{url: u})
+	}
+	return b, nil
+}
+
+// CloseIdleConnections closes the idle connections of the doer of b.
+func (b *balancer) CloseIdleConnections() {
+	if ci, ok := b.doer.(idleCloser); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+func (b *balancer) Do(req *http.Request) (*http.Response, error) {
+	if !isRead(req) {
+		return b.doer.Do(req)
+	}
+
+	var err error
+	for _, h := range b.order() {
+		var resp *http.Response
+		resp, err = b.doer.Do(withHost(req, h.url))
+		if err == nil {
+			h.setDownUntil(time.Time{})
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		h.setDownUntil(b.now().Add(b.cooldown))
+	}
+	return nil, err
+}
+
+// order returns the hosts to try for a read, the next host round-robin
+// first. Hosts in their cooldown come last, so that a read fails only once
+// every host failed.
+func (b *balancer) order() []*balancedHost {
+	start := int(atomic.AddUint32(&b.next, 1) - 1)
+	now := b.now()
+
+	up := make([]*balancedHost, 0, len(b.hosts))
+	var down []*balancedHost
+	for i := range b.hosts {
+		h := b.hosts[(start+i)%len(b.hosts)]
+		if h.isDown(now) {
+			down = append(down, h)
+			continue
+		}
+		up = append(up, h)
+	}
+	return append(up, down...)
+}
+
+func (h *balancedHost) isDown(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return now.Before(h.downUntil)
+}
+
+func (h *balancedHost) setDownUntil(t time.Time) {
+	h.mu.Lock()
+	h.downUntil = t
+	h.mu.Unlock()
+}
+
+// isRead reports whether req only reads, so that it can be sent to any host
+// and retried.
+func isRead(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+func withHost(req *http.Request, u *url.URL) *http.Request {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = u.Scheme
+	r.URL.Host = u.Host
+	r.Host = u.Host
+	return r
+}
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sync/atomic"
+	"time"
 
 	"github.com/influxdata/influxdb/v2"
+	"go.uber.org/zap"
 )
 
 type (
@@ -22,8 +25,17 @@ type (
 	doer interface {
 		Do(*http.Request) (*http.Response, error)
 	}
+
+	// idleCloser is a doer that can close its idle connections, such as an
+	// http.Client.
+	idleCloser interface {
+		CloseIdleConnections()
+	}
 )
 
+// ErrClientClosed is returned by the requests of a Client once it is closed.
+var ErrClientClosed = errors.New("client is closed")
+
 // Client is a basic http client that can make cReqs with out having to juggle
 // the token and so forth. It provides sane defaults for checking response
 // statuses, sets auth token when provided, and sets the content type to
@@ -39,6 +51,28 @@ type Client struct {
 	authFn   func(*http.Request) error
 	respFn   func(*http.Response) error
 	statusFn func(*http.Response) error
+
+	debugBodyBytes int
+
+	// inflight holds a token for each request in flight, nil when requests
+	// are not capped.
+	inflight chan struct{}
+
+	requestTimeout time.Duration
+	timings        *requestTimingsOpt
+	log            *zap.Logger
+
+	unmarshalJSON func([]byte, interface{}) error
+
+	insecureSkipVerify bool
+
+	// ownsTransport is set when the transport of the client was built for
+	// it rather than shared with other clients, so that Close may close its
+	// idle connections.
+	ownsTransport bool
+
+	// closed is set to 1 by Close.
+	closed uint32
 }
 
 // New creates a new httpc client.
@@ -64,6 +98,20 @@ func New(opts ...ClientOptFn) (*Client, error) {
 	if opt.doer == nil {
 		opt.doer = defaultHTTPClient(u.Scheme, opt.insecureSkipVerify)
 	}
+	ownsTransport := opt.ownsDoer
+	if opt.connectTimeout > 0 {
+		if opt.doer, err = withConnectTimeout(opt.doer, opt.connectTimeout); err != nil {
+			return nil, err
+		}
+		ownsTransport = true
+	}
+	if len(opt.addrs) > 1 {
+		b, err := newBalancer(opt.doer, opt.addrs, opt.hostCooldown)
+		if err != nil {
+			return nil, err
+		}
+		opt.doer = b
+	}
 
 	return &Client{
 		addr:           *u,
@@ -72,6 +120,15 @@ func New(opts ...ClientOptFn) (*Client, error) {
 		authFn:         opt.authFn,
 		statusFn:       opt.statusFn,
 		writerFns:      opt.writerFns,
+		debugBodyBytes: opt.debugBodyBytes,
+		inflight:       opt.inflight,
+		requestTimeout: opt.requestTimeout,
+		timings:        opt.timings,
+		log:            opt.log,
+		unmarshalJSON:  opt.unmarshalJSON,
+
+		insecureSkipVerify: opt.insecureSkipVerify,
+		ownsTransport:      ownsTransport,
 	}, nil
 }
 
@@ -121,6 +178,10 @@ func (c *Client) PutJSON(v interface{}, urlPath ...string) *Req {
 
 // Req constructs a request.
 func (c *Client) Req(method string, bFn BodyFn, urlPath ...string) *Req {
+	if atomic.LoadUint32(&c.closed) == 1 {
+		return &Req{err: ErrClientClosed}
+	}
+
 	bodyF := BodyEmpty
 	if bFn != nil {
 		bodyF = bFn
@@ -171,15 +232,39 @@ func (c *Client) Req(method string, bFn BodyFn, urlPath ...string) *Req {
 	}
 
 	cr := &Req{
-		client:   c.doer,
-		req:      req,
-		authFn:   c.authFn,
-		respFn:   c.respFn,
-		statusFn: c.statusFn,
+		client:         c.doer,
+		req:            req,
+		authFn:         c.authFn,
+		respFn:         c.respFn,
+		statusFn:       c.statusFn,
+		debugBodyBytes: c.debugBodyBytes,
+		inflight:       c.inflight,
+		requestTimeout: c.requestTimeout,
+		timings:        c.timings,
+		log:            c.log,
+		unmarshalJSON:  c.unmarshalJSON,
 	}
 	return cr.Headers(headers)
 }
 
+// Close makes the requests the client makes after it fail with
+// ErrClientClosed. Requests in flight are not interrupted. The idle
+// connections of the transport are closed only when the transport is the
+// client's own, as built by WithConnectTimeout or set by
+// WithOwnedHTTPClient. Transports shared with other
+// clients, such as http.DefaultTransport, the transport of WithHTTPClient or
+// that of the client a clone was made from, are left open.
+func (c *Client) Close() error {
+	atomic.StoreUint32(&c.closed, 1)
+	if !c.ownsTransport {
+		return nil
+	}
+	if ci, ok := c.doer.(idleCloser); ok {
+		ci.CloseIdleConnections()
+	}
+	return nil
+}
+
 // Clone creates a new *Client type from an existing client. This may be
 // useful if you want to have a shared base client, then take a specific
 // client from that base and tack on some extra goodies like specific headers
@@ -192,6 +277,7 @@ func (c *Client) Clone(opts ...ClientOptFn) (*Client, error) {
 		withDoer(c.doer),
 		WithRespFn(c.respFn),
 		WithStatusFn(c.statusFn),
+		WithInsecureSkipVerify(c.insecureSkipVerify),
 	}
 	for h, vals := range c.defaultHeaders {
 		for _, v := range vals {
@@ -201,6 +287,24 @@ func (c *Client) Clone(opts ...ClientOptFn) (*Client, error) {
 	for _, fn := range c.writerFns {
 		existingOpts = append(existingOpts, WithWriterFn(fn))
 	}
+	if c.debugBodyBytes > 0 {
+		existingOpts = append(existingOpts, WithDebugResponses(c.debugBodyBytes))
+	}
+	if c.inflight != nil {
+		existingOpts = append(existingOpts, withInflight(c.inflight))
+	}
+	if c.requestTimeout > 0 {
+		existingOpts = append(existingOpts, WithRequestTimeout(c.requestTimeout))
+	}
+	if c.timings != nil {
+		existingOpts = append(existingOpts, WithRequestTimings(c.timings.fn))
+	}
+	if c.log != nil {
+		existingOpts = append(existingOpts, WithLogger(c.log))
+	}
+	if c.unmarshalJSON != nil {
+		existingOpts = append(existingOpts, WithJSONUnmarshal(c.unmarshalJSON))
+	}
 
 	return New(append(existingOpts, opts...)...)
 }
@@ -7,14 +7,23 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestClient(t *testing.T) {
@@ -502,3 +511,811 @@ func decodeFromContentType(r *http.Request) (echoResp, error) {
 
 	return e, nil
 }
+
+func TestClient_UserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ClientOptFn
+		want []string
+	}{
+		{
+			name: "sets user agent",
+			opts: []ClientOptFn{WithUserAgent("agent/1.0")},
+			want: []string{"agent/1.0"},
+		},
+		{
+			name: "last user agent wins",
+			opts: []ClientOptFn{WithUserAgent("default/1.0"), WithUserAgent("agent/1.0")},
+			want: []string{"agent/1.0"},
+		},
+		{
+			name: "user agent header adds a user agent",
+			opts: []ClientOptFn{WithUserAgent("default/1.0"), WithUserAgentHeader("agent/1.0")},
+			want: []string{"default/1.0", "agent/1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(append(tt.opts, WithAddr("http://example.com"))...)
+			require.NoError(t, err)
+			fakeDoer := &fakeDoer{
+				doFn: func(r *http.Request) (*http.Response, error) {
+					return stubResp(http.StatusOK, r)
+				},
+			}
+			client.doer = fakeDoer
+
+			require.NoError(t, client.Get("/").Do(context.TODO()))
+			require.Len(t, fakeDoer.args, 1)
+			assert.Equal(t, tt.want, fakeDoer.args[0].Header.Values("User-Agent"))
+		})
+	}
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request)
+	}{
+		{
+			name: "before the response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			},
+		},
+		{
+			name: "while reading the body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = io.WriteString(w, `{"partial": `)
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		for _, ctxErr := range []error{context.Canceled, context.DeadlineExceeded} {
+			t.Run(tt.name+"/"+ctxErr.Error(), func(t *testing.T) {
+				svr := httptest.NewServer(http.HandlerFunc(tt.handler))
+				defer svr.Close()
+
+				client, err := New(WithAddr(svr.URL))
+				require.NoError(t, err)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				if ctxErr == context.DeadlineExceeded {
+					ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+				} else {
+					time.AfterFunc(50*time.Millisecond, cancel)
+				}
+				defer cancel()
+
+				done := make(chan error, 1)
+				go func() {
+					var v map[string]interface{}
+					done <- client.Get("/").DecodeJSON(&v).Do(ctx)
+				}()
+
+				select {
+				case err := <-done:
+					assert.True(t, errors.Is(err, ctxErr), "unexpected error: %v", err)
+				case <-time.After(5 * time.Second):
+					t.Fatal("request did not return after the context was done")
+				}
+			})
+		}
+	}
+}
+
+func TestClient_DebugResponses(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Proxy", "gateway")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"code":"unauthorized","message":"unauthorized access"}`)
+	}))
+	defer svr.Close()
+
+	statusFn := func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &influxdb.Error{Code: influxdb.EUnauthorized, Msg: string(b)}
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithStatusFn(statusFn))
+		require.NoError(t, err)
+
+		err = client.Get("/").Do(context.Background())
+		var rerr *ResponseError
+		assert.False(t, errors.As(err, &rerr), "unexpected response error: %v", err)
+	})
+
+	t.Run("attaches the response", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithStatusFn(statusFn), WithDebugResponses(14))
+		require.NoError(t, err)
+
+		err = client.Get("/").Do(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, influxdb.EUnauthorized, influxdb.ErrorCode(err))
+
+		var rerr *ResponseError
+		require.True(t, errors.As(err, &rerr), "unexpected error: %v", err)
+		assert.Equal(t, http.StatusUnauthorized, rerr.StatusCode)
+		assert.Equal(t, `{"code":"unaut`, string(rerr.Body))
+		assert.Equal(t, "gateway", rerr.Header.Get("X-Proxy"))
+		assert.Empty(t, rerr.Header.Get("Set-Cookie"))
+		assert.NotContains(t, err.Error(), "secret")
+	})
+
+	t.Run("clones keep the option", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithStatusFn(statusFn), WithDebugResponses(0))
+		require.NoError(t, err)
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+
+		err = clone.Get("/").Do(context.Background())
+		var rerr *ResponseError
+		require.True(t, errors.As(err, &rerr), "unexpected error: %v", err)
+		assert.Len(t, rerr.Body, len(`{"code":"unauthorized","message":"unauthorized access"}`))
+	})
+}
+
+func TestClient_Addrs(t *testing.T) {
+	newServer := func(name string, hits *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits = append(*hits, name+" "+r.Method)
+		}))
+	}
+
+	t.Run("reads are spread round-robin", func(t *testing.T) {
+		var hits []string
+		a, b := newServer("a", &hits), newServer("b", &hits)
+		defer a.Close()
+		defer b.Close()
+
+		client, err := New(WithAddrs(a.URL, b.URL))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, client.Get("/").Do(context.Background()))
+		}
+		require.NoError(t, client.PostJSON(map[string]string{}, "/").Do(context.Background()))
+		assert.Equal(t, []string{"a GET", "b GET", "a GET", "a POST"}, hits)
+	})
+
+	t.Run("hosts failing to connect are skipped for the cooldown", func(t *testing.T) {
+		var hits []string
+		down, up := newServer("down", &hits), newServer("up", &hits)
+		down.Close()
+		defer up.Close()
+
+		client, err := New(WithAddrs(down.URL, up.URL), WithHostCooldown(time.Minute))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, client.Get("/").Do(context.Background()))
+		}
+		assert.Equal(t, []string{"up GET", "up GET", "up GET"}, hits)
+
+		b := client.doer.(*balancer)
+		assert.True(t, b.hosts[0].isDown(time.Now()))
+		assert.False(t, b.hosts[0].isDown(time.Now().Add(2*time.Minute)))
+	})
+
+	t.Run("reads fail once every host failed", func(t *testing.T) {
+		var hits []string
+		a, b := newServer("a", &hits), newServer("b", &hits)
+		a.Close()
+		b.Close()
+
+		client, err := New(WithAddrs(a.URL, b.URL))
+		require.NoError(t, err)
+		assert.Error(t, client.Get("/").Do(context.Background()))
+	})
+
+	t.Run("a single host is not balanced", func(t *testing.T) {
+		client, err := New(WithAddrs("http://example.com"))
+		require.NoError(t, err)
+		_, ok := client.doer.(*balancer)
+		assert.False(t, ok)
+	})
+}
+
+func TestClient_MaxConcurrentRequests(t *testing.T) {
+	const limit = 3
+
+	var (
+		mu            sync.Mutex
+		inflight, max int
+	)
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if inflight++; inflight > max {
+			max = inflight
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+	}))
+	defer svr.Close()
+
+	client, err := New(WithAddr(svr.URL), WithMaxConcurrentRequests(limit))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.Get("/").Do(context.Background()))
+		}()
+	}
+
+	// give the requests time to pile up at the cap before releasing them.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, limit, max)
+
+	t.Run("waiting respects the context", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithMaxConcurrentRequests(1))
+		require.NoError(t, err)
+		client.inflight <- struct{}{}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, client.Get("/").Do(ctx))
+
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+		assert.Equal(t, context.DeadlineExceeded, clone.Get("/").Do(ctx), "expected clones to share the cap")
+	})
+}
+
+func TestClient_RequestID(t *testing.T) {
+	var got string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-Id")
+	}))
+	defer svr.Close()
+
+	client, err := New(WithAddr(svr.URL))
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	require.NoError(t, client.Get("/").Do(ctx))
+	assert.Equal(t, "host/abc-000001", got)
+
+	require.NoError(t, client.Get("/").Header("X-Request-Id", "explicit").Do(ctx))
+	assert.Equal(t, "explicit", got, "expected a request id set on the request to be kept")
+
+	require.NoError(t, client.Get("/").Do(context.Background()))
+	assert.Empty(t, got)
+}
+
+func TestClient_GzipResponses(t *testing.T) {
+	var acceptEncoding string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		status, body := http.StatusOK, `{"name":"gzipped"}`
+		if r.URL.Path == "/error" {
+			status, body = http.StatusBadRequest, `{"code":"invalid","message":"gzipped error"}`
+		}
+		if r.URL.Path == "/empty" {
+			status = http.StatusNoContent
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+		if r.URL.Path == "/empty" {
+			return
+		}
+		gw := gzip.NewWriter(w)
+		_, _ = gw.Write([]byte(body))
+		require.NoError(t, gw.Close())
+	}))
+	defer svr.Close()
+
+	statusFn := func(resp *http.Response) error {
+		if resp.StatusCode < 400 {
+			return nil
+		}
+		var ierr influxdb.Error
+		if err := json.NewDecoder(resp.Body).Decode(&ierr); err != nil {
+			return err
+		}
+		return &ierr
+	}
+
+	tests := []struct {
+		name           string
+		opts           []ClientOptFn
+		acceptEncoding string
+	}{
+		{
+			name:           "gzip requested by the client",
+			opts:           []ClientOptFn{WithGzipResponses()},
+			acceptEncoding: "gzip",
+		},
+		{
+			name:           "gzip requested by the transport",
+			acceptEncoding: "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(append(tt.opts, WithAddr(svr.URL), WithStatusFn(statusFn))...)
+			require.NoError(t, err)
+
+			var resp struct{ Name string }
+			require.NoError(t, client.Get("/").DecodeJSON(&resp).Do(context.Background()))
+			assert.Equal(t, tt.acceptEncoding, acceptEncoding)
+			assert.Equal(t, "gzipped", resp.Name)
+
+			var raw string
+			err = client.Get("/").Decode(func(resp *http.Response) error {
+				assert.Empty(t, resp.Header.Get("Content-Encoding"))
+				b, err := ioutil.ReadAll(resp.Body)
+				raw = string(b)
+				return err
+			}).Do(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, `{"name":"gzipped"}`, raw)
+
+			err = client.Get("/error").Do(context.Background())
+			require.Error(t, err)
+			assert.Equal(t, "gzipped error", influxdb.ErrorMessage(err))
+
+			// an empty body labeled as gzip reads as empty.
+			err = client.Get("/empty").DecodeReader(func(r io.Reader) error {
+				b, err := ioutil.ReadAll(r)
+				assert.Empty(t, b)
+				return err
+			}).Do(context.Background())
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer svr.Close()
+	defer close(release)
+
+	t.Run("request bounded by the client timeout", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithRequestTimeout(20*time.Millisecond))
+		require.NoError(t, err)
+
+		start := time.Now()
+		err = client.Get("/").Do(context.Background())
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+		assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+	})
+
+	t.Run("shorter deadline of the caller", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithRequestTimeout(time.Minute))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err = client.Get("/").Do(ctx)
+		require.Error(t, err)
+		assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+	})
+
+	t.Run("kept by clones", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL), WithRequestTimeout(20*time.Millisecond))
+		require.NoError(t, err)
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+
+		err = clone.Get("/").Do(context.Background())
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	})
+}
+
+func TestClient_RequestTimings(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer svr.Close()
+
+	var (
+		paths   []string
+		timings []RequestTimings
+	)
+	client, err := New(WithAddr(svr.URL), WithRequestTimings(func(req *http.Request, rt RequestTimings) {
+		paths = append(paths, req.URL.Path)
+		timings = append(timings, rt)
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Get("/first").Do(context.Background()))
+	require.NoError(t, client.Get("/second").Do(context.Background()))
+
+	require.Equal(t, []string{"/first", "/second"}, paths)
+	for _, rt := range timings {
+		assert.True(t, rt.WroteRequest > 0, "unexpected timings: %+v", rt)
+		assert.True(t, rt.FirstResponseByte >= rt.WroteRequest+10*time.Millisecond, "unexpected timings: %+v", rt)
+		assert.True(t, rt.Total >= rt.FirstResponseByte, "unexpected timings: %+v", rt)
+	}
+	assert.False(t, timings[0].ConnReused)
+	assert.True(t, timings[1].ConnReused)
+
+	t.Run("kept by clones", func(t *testing.T) {
+		paths = nil
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+		require.NoError(t, clone.Get("/clone").Do(context.Background()))
+		assert.Equal(t, []string{"/clone"}, paths)
+	})
+
+	t.Run("failed requests", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		var got *RequestTimings
+		client, err := New(WithAddr(closed.URL), WithRequestTimings(func(_ *http.Request, rt RequestTimings) {
+			got = &rt
+		}))
+		require.NoError(t, err)
+
+		require.Error(t, client.Get("/").Do(context.Background()))
+		require.NotNil(t, got)
+		assert.Zero(t, got.FirstResponseByte)
+		assert.True(t, got.Total > 0)
+	})
+}
+
+func TestClient_Config(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		client, err := New(WithAddr("http://localhost:8086"))
+		require.NoError(t, err)
+
+		assert.Equal(t, Config{
+			Addr:      "http://localhost:8086",
+			Transport: http.DefaultTransport,
+		}, client.Config())
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		hc := &http.Client{Timeout: time.Minute, Transport: &http.Transport{}}
+		client, err := New(
+			WithAddrs("https://a:8086", "https://b:8086"),
+			WithHTTPClient(hc),
+			WithInsecureSkipVerify(true),
+			WithRequestTimeout(10*time.Second),
+			WithMaxConcurrentRequests(4),
+		)
+		require.NoError(t, err)
+
+		want := Config{
+			Addr:                  "https://a:8086",
+			Addrs:                 []string{"https://a:8086", "https://b:8086"},
+			InsecureSkipVerify:    true,
+			RequestTimeout:        10 * time.Second,
+			MaxConcurrentRequests: 4,
+			Timeout:               time.Minute,
+			Transport:             hc.Transport,
+		}
+		assert.Equal(t, want, client.Config())
+
+		clone, err := client.Clone(WithAddr("https://a:8086"))
+		require.NoError(t, err)
+		assert.Equal(t, want, clone.Config())
+	})
+}
+
+func TestClient_IfNoneMatch(t *testing.T) {
+	const etag = `"v1"`
+	var methods []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"dashboard"}`))
+	}))
+	defer svr.Close()
+
+	client, err := New(WithAddr(svr.URL))
+	require.NoError(t, err)
+
+	t.Run("request", func(t *testing.T) {
+		var (
+			tag  string
+			body struct{ Name string }
+		)
+		require.NoError(t, client.Get("/").IfNoneMatch(&tag).DecodeJSON(&body).Do(context.Background()))
+		assert.Equal(t, etag, tag)
+		assert.Equal(t, "dashboard", body.Name)
+
+		body.Name = ""
+		err := client.Get("/").IfNoneMatch(&tag).DecodeJSON(&body).Do(context.Background())
+		assert.True(t, errors.Is(err, ErrNotModified), "unexpected error: %v", err)
+		assert.Empty(t, body.Name)
+		assert.Equal(t, etag, tag)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		methods = nil
+		tag := etag
+		ctx := WithETag(context.Background(), &tag)
+
+		err := client.Get("/").Do(ctx)
+		assert.True(t, errors.Is(err, ErrNotModified), "unexpected error: %v", err)
+
+		// only reads are conditional.
+		require.NoError(t, client.PostJSON(map[string]string{}, "/").Do(ctx))
+		assert.Equal(t, []string{"GET " + etag, "POST "}, methods)
+	})
+
+	t.Run("unconditional requests", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}))
+		defer svr.Close()
+		client, err := New(WithAddr(svr.URL), WithStatusFn(func(resp *http.Response) error {
+			return errors.New("unexpected status " + resp.Status)
+		}))
+		require.NoError(t, err)
+
+		err = client.Get("/").Do(context.Background())
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrNotModified))
+	})
+}
+
+func TestClient_WithToken(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer svr.Close()
+
+	client, err := New(WithAddr(svr.URL), WithAuthToken("default"))
+	require.NoError(t, err)
+
+	authOf := func(ctx context.Context) string {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, client.Get("/").Decode(func(resp *http.Response) error {
+			_, err := io.Copy(&buf, resp.Body)
+			return err
+		}).Do(ctx))
+		return buf.String()
+	}
+
+	ctx := context.Background()
+	assert.Equal(t, "Token tenant-a", authOf(WithToken(ctx, "tenant-a")))
+	assert.Equal(t, "Token tenant-b", authOf(WithToken(ctx, "tenant-b")))
+	assert.Equal(t, "Token default", authOf(ctx), "expected the token of the client without one in the context")
+}
+
+// idleClosingDoer counts the calls to CloseIdleConnections.
+type idleClosingDoer struct {
+	doer
+	closed int
+}
+
+func (d *idleClosingDoer) CloseIdleConnections() { d.closed++ }
+
+func TestClient_Close(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer svr.Close()
+
+	d := &idleClosingDoer{doer: svr.Client()}
+	client, err := New(WithAddr(svr.URL), withDoer(d))
+	require.NoError(t, err)
+	clone, err := client.Clone(WithAddr(svr.URL))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Get("/").Do(context.Background()))
+	require.NoError(t, client.Close())
+	assert.Equal(t, 0, d.closed, "expected the idle connections of a shared transport to stay open")
+
+	assert.Equal(t, ErrClientClosed, client.Get("/").Do(context.Background()))
+	assert.NoError(t, clone.Get("/").Do(context.Background()), "expected clones to stay usable")
+
+	// closedConns returns a server sending on the returned channel when a
+	// connection to it is closed.
+	closedConns := func(t *testing.T) (*httptest.Server, chan struct{}) {
+		closed := make(chan struct{}, 10)
+		svr := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		svr.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed {
+				closed <- struct{}{}
+			}
+		}
+		svr.Start()
+		return svr, closed
+	}
+
+	t.Run("own transport", func(t *testing.T) {
+		svr, closed := closedConns(t)
+		defer svr.Close()
+
+		client, err := New(WithAddr(svr.URL), WithConnectTimeout(time.Second))
+		require.NoError(t, err)
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+		require.NoError(t, client.Get("/").Do(context.Background()))
+
+		require.NoError(t, clone.Close())
+		select {
+		case <-closed:
+			t.Fatal("expected closing a clone to leave the transport of the client open")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		require.NoError(t, client.Close())
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the idle connections of the transport of the client to be closed")
+		}
+	})
+
+	t.Run("owned http client", func(t *testing.T) {
+		svr, closed := closedConns(t)
+		defer svr.Close()
+
+		client, err := New(WithAddr(svr.URL), WithOwnedHTTPClient(&http.Client{Transport: &http.Transport{}}))
+		require.NoError(t, err)
+		require.NoError(t, client.Get("/").Do(context.Background()))
+		require.NoError(t, client.Close())
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the idle connections of the owned http client to be closed")
+		}
+	})
+
+	t.Run("balanced", func(t *testing.T) {
+		svr, closed := closedConns(t)
+		defer svr.Close()
+
+		client, err := New(WithAddr(svr.URL), WithAddrs(svr.URL, svr.URL), WithConnectTimeout(time.Second))
+		require.NoError(t, err)
+		require.NoError(t, client.Get("/").Do(context.Background()))
+		require.NoError(t, client.Close())
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the idle connections of the hosts to be closed")
+		}
+	})
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer svr.Close()
+
+	core, logs := observer.New(zap.DebugLevel)
+	client, err := New(WithAddr(svr.URL), WithAuthToken("secret-token"), WithLogger(zap.New(core)))
+	require.NoError(t, err)
+
+	require.NoError(t, client.
+		Get("/query").
+		QueryParams([2]string{"u", "admin"}, [2]string{"p", "secret-password"}).
+		Do(context.Background()))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, int64(http.StatusAccepted), fields["status"])
+	assert.Contains(t, fields["url"], "/query?")
+	assert.Contains(t, fields["url"], "u=admin")
+
+	line := fmt.Sprint(fields)
+	assert.NotContains(t, line, "secret-token")
+	assert.NotContains(t, line, "secret-password")
+
+	t.Run("clones log", func(t *testing.T) {
+		clone, err := client.Clone(WithAddr(svr.URL))
+		require.NoError(t, err)
+		require.NoError(t, clone.Get("/").Do(context.Background()))
+		assert.Equal(t, 2, logs.Len())
+	})
+}
+
+func TestClient_WithJSONUnmarshal(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"example"}`))
+	}))
+	defer svr.Close()
+
+	var calls int
+	unmarshal := func(data []byte, v interface{}) error {
+		calls++
+		return json.Unmarshal(data, v)
+	}
+	client, err := New(WithAddr(svr.URL), WithJSONUnmarshal(unmarshal))
+	require.NoError(t, err)
+
+	var got struct{ Name string }
+	require.NoError(t, client.Get("/").DecodeJSON(&got).Do(context.Background()))
+	assert.Equal(t, "example", got.Name)
+	assert.Equal(t, 1, calls)
+
+	clone, err := client.Clone(WithAddr(svr.URL))
+	require.NoError(t, err)
+	require.NoError(t, clone.Get("/").DecodeJSON(&got).Do(context.Background()))
+	assert.Equal(t, 2, calls, "expected clones to decode with the function")
+
+	t.Run("encoding/json by default", func(t *testing.T) {
+		client, err := New(WithAddr(svr.URL))
+		require.NoError(t, err)
+		var got struct{ Name string }
+		require.NoError(t, client.Get("/").DecodeJSON(&got).Do(context.Background()))
+		assert.Equal(t, "example", got.Name)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestClient_WithConnectTimeout(t *testing.T) {
+	t.Run("blackholed address fails promptly", func(t *testing.T) {
+		// a non routable address, where dials hang rather than being refused.
+		client, err := New(
+			WithAddr("http://10.255.255.1:8086"),
+			WithConnectTimeout(100*time.Millisecond),
+			WithRequestTimeout(time.Minute),
+		)
+		require.NoError(t, err)
+
+		start := time.Now()
+		err = client.Get("/health").Do(context.Background())
+		require.Error(t, err)
+		assert.Less(t, int64(time.Since(start)), int64(5*time.Second), "expected the dial to time out promptly: %v", err)
+	})
+
+	t.Run("requests may outlast the connect timeout", func(t *testing.T) {
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer svr.Close()
+
+		client, err := New(WithAddr(svr.URL), WithConnectTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, client.Get("/").Do(context.Background()))
+
+		hc := client.doer.(*http.Client)
+		assert.True(t, hc.Transport != http.DefaultTransport, "expected a transport of its own")
+	})
+
+	t.Run("requires an http client", func(t *testing.T) {
+		_, err := New(WithAddrs("http://a:8086", "http://b:8086"), withDoer(&balancer{}), WithConnectTimeout(time.Second))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,56 @@
+package httpc
+
+import (
+	"net/http"
+	"time"
+)
+
+// Config is the effective configuration of a Client, such as for a health
+// endpoint to report how its clients are set up. It is a copy: changing it
+// does not change the client.
+type Config struct {
+	// Addr is the address requests are built against.
+	Addr string
+	// Addrs are the hosts requests are spread across, for a client created
+	// WithAddrs with more than one host.
+	Addrs []string
+	// InsecureSkipVerify is the setting of WithInsecureSkipVerify. A client
+	// given its own http client or transport verifies TLS as that transport
+	// does.
+	InsecureSkipVerify bool
+	// RequestTimeout is the bound of WithRequestTimeout, zero when requests
+	// are only bounded by their context.
+	RequestTimeout time.Duration
+	// MaxConcurrentRequests is the cap of WithMaxConcurrentRequests, zero
+	// when requests are not capped.
+	MaxConcurrentRequests int
+	// Timeout is the Timeout of the http client sending the requests.
+	Timeout time.Duration
+	// Transport is the transport of the http client sending the requests,
+	// nil for http.DefaultTransport or when the requests are not sent by an
+	// *http.Client.
+	Transport http.RoundTripper
+}
+
+// Config returns the effective configuration of c.
+func (c *Client) Config() Config {
+	cfg := Config{
+		Addr:                  c.addr.String(),
+		InsecureSkipVerify:    c.insecureSkipVerify,
+		RequestTimeout:        c.requestTimeout,
+		MaxConcurrentRequests: cap(c.inflight),
+	}
+
+	d := c.doer
+	if b, ok := d.(*balancer); ok {
+		for _, h := range b.hosts {
+			cfg.Addrs = append(cfg.Addrs, h.url.String())
+		}
+		d = b.doer
+	}
+	if hc, ok := d.(*http.Client); ok {
+		cfg.Timeout = hc.Timeout
+		cfg.Transport = hc.Transport
+	}
+	return cfg
+}
@@ -0,0 +1,68 @@
+package httpc
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ConnectTimeoutTransport is implemented by the transports wrapping an
+// *http.Transport, such as those injecting tracing headers, so that
+// WithConnectTimeout can bound the dials of the transport they wrap.
+type ConnectTimeoutTransport interface {
+	// WithConnectTimeout returns a copy of the transport dialing with the
+	// timeout d.
+	WithConnectTimeout(d time.Duration) (http.RoundTripper, error)
+}
+
+// WithConnectTimeout bounds establishing the connections of the client to d,
+// independently of WithRequestTimeout, so that an unreachable host fails
+// requests promptly while the requests themselves, such as large writes, may
+// take long. It replaces the dialer of the transport of the client with one
+// timing out after d, and so gives the client a connection pool of its own,
+// whose idle connections Client.Close closes. A non-positive d keeps the
+// dialer of the transport.
+func WithConnectTimeout(d time.Duration) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.connectTimeout = d
+		return nil
+	}
+}
+
+// withConnectTimeout returns a copy of the http client d dialing with the
+// timeout t.
+func withConnectTimeout(d doer, t time.Duration) (doer, error) {
+	hc, ok := d.(*http.Client)
+	if !ok {
+		return nil, errors.New("connect timeout requires requests to be sent by an *http.Client")
+	}
+	rt, err := ConnectTimeout(hc.Transport, t)
+	if err != nil {
+		return nil, err
+	}
+	c := *hc
+	c.Transport = rt
+	return &c, nil
+}
+
+// ConnectTimeout returns a copy of the transport rt, http.DefaultTransport
+// when nil, dialing with the timeout d. The rt must be an *http.Transport or
+// a ConnectTimeoutTransport.
+func ConnectTimeout(rt http.RoundTripper, d time.Duration) (http.RoundTripper, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	switch t := rt.(type) {
+	case ConnectTimeoutTransport:
+		return t.WithConnectTimeout(d)
+	case *http.Transport:
+		t = t.Clone()
+		t.DialContext = (&net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		return t, nil
+	}
+	return nil, errors.New("connect timeout requires an *http.Transport")
+}
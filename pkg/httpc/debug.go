@@ -0,0 +1,95 @@
+package httpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// DefaultDebugBodyBytes is the size of the body snippet kept by
+// WithDebugResponses when no size is given.
+const DefaultDebugBodyBytes = 1024
+
+// redactedHeaders are never copied into a ResponseError.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// WithDebugResponses attaches the status, headers and up to maxBodyBytes of
+// the body of a failed response to the error returned for it, as a
+// *ResponseError. It is meant for troubleshooting, as error messages then
+// carry response details. A non-positive maxBodyBytes uses
+// DefaultDebugBodyBytes.
+func WithDebugResponses(maxBodyBytes int) ClientOptFn {
+	return func(opt *clientOpt) error {
+		if maxBodyBytes <= 0 {
+			maxBodyBytes = DefaultDebugBodyBytes
+		}
+		opt.debugBodyBytes = maxBodyBytes
+		return nil
+	}
+}
+
+// ResponseError describes the response that caused Err. Clients configured
+// WithDebugResponses attach it to the errors of failed responses.
+type ResponseError struct {
+	Err        error
+	StatusCode int
+	Header     http.Header
+	// Body is the start of the response body.
+	Body []byte
+}
+
+// Error returns the message of Err along with the response details.
+func (e *ResponseError) Error() string {
+	details := fmt.Sprintf("response status %d, headers %v, body %q", e.StatusCode, e.Header, e.Body)
+	if e.Err == nil {
+		return details
+	}
+	return e.Err.Error() + " (" + details + ")"
+}
+
+// Unwrap returns the underlying error.
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// newResponseError attaches resp to err. Platform errors keep their code
+// and message with the ResponseError as their underlying error, so that
+// influxdb.ErrorCode and friends still work.
+func newResponseError(err error, resp *http.Response, body []byte) error {
+	header := resp.Header.Clone()
+	for _, h := range redactedHeaders {
+		header.Del(h)
+	}
+
+	if perr, ok := err.(*influxdb.Error); ok {
+		cp := *perr
+		cp.Err = &ResponseError{Err: perr.Err, StatusCode: resp.StatusCode, Header: header, Body: body}
+		return &cp
+	}
+	return &ResponseError{Err: err, StatusCode: resp.StatusCode, Header: header, Body: body}
+}
+
+// snippetBuffer keeps the first max bytes written to it.
+type snippetBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *snippetBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		b.Buffer.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+// teeReadCloser reads through a tee while closing the original body.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
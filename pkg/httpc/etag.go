@@ -0,0 +1,51 @@
+package httpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// ErrNotModified is returned by conditional requests when the server answers
+// with a 304 Not Modified, so that the caller can reuse what it has cached
+// for the ETag it sent.
+var ErrNotModified = errors.New("not modified")
+
+type etagContextKey struct{}
+
+// WithETag returns a copy of ctx making the GET requests made with it
+// conditional on etag, as with Req.IfNoneMatch. This makes the find methods
+// of clients built on a Client conditional without changing their signature.
+func WithETag(ctx context.Context, etag *string) context.Context {
+	return context.WithValue(ctx, etagContextKey{}, etag)
+}
+
+// IfNoneMatch makes the request conditional: when *etag is not empty it is
+// sent in an If-None-Match header, and a 304 Not Modified response fails the
+// request with ErrNotModified without decoding it. Once the request succeeds
+// *etag is set to the ETag of the response, empty when it has none.
+func (r *Req) IfNoneMatch(etag *string) *Req {
+	if r.err != nil {
+		return r
+	}
+	r.etag = etag
+	return r
+}
+
+// conditionalETag returns the ETag a request with ctx is conditional on, nil
+// when it is not conditional.
+func (r *Req) conditionalETag(ctx context.Context) *string {
+	if r.etag != nil {
+		return r.etag
+	}
+	if r.req.Method != http.MethodGet && r.req.Method != http.MethodHead {
+		return nil
+	}
+	etag, _ := ctx.Value(etagContextKey{}).(*string)
+	return etag
+}
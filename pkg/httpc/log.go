@@ -0,0 +1,79 @@
+package httpc
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const redacted = "REDACTED"
+
+// redactedParams are the query parameters whose values are never logged, as
+// they carry the credentials of v1 requests.
+var redactedParams = []string{"p", "password", "token"}
+
+// WithLogger logs every request of the client at debug level to log, with
+// its method, URL, status and duration, so that failing calls can be
+// diagnosed. The Authorization and cookie headers and the credentials of
+// the URL are redacted, so that tokens are never logged.
+func WithLogger(log *zap.Logger) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.log = log
+		return nil
+	}
+}
+
+// logRequest logs req, answered by resp or failed with err, at debug level.
+func (r *Req) logRequest(req *http.Request, resp *http.Response, err error, took time.Duration) {
+	if r.log == nil {
+		return
+	}
+	if ce := r.log.Check(zap.DebugLevel, "Request"); ce != nil {
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("url", redactURL(req.URL)),
+			zap.Any("headers", redactHeaders(req.Header)),
+			zap.Duration("took", took),
+		}
+		if resp != nil {
+			fields = append(fields, zap.Int("status", resp.StatusCode))
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// redactURL returns u without the password of its user info and the values
+// of its redactedParams.
+func redactURL(u *url.URL) string {
+	cp := *u
+	if _, ok := cp.User.Password(); ok {
+		cp.User = url.UserPassword(cp.User.Username(), redacted)
+	}
+	if cp.RawQuery != "" {
+		q := cp.Query()
+		for _, p := range redactedParams {
+			if _, ok := q[p]; ok {
+				q.Set(p, redacted)
+			}
+		}
+		cp.RawQuery = q.Encode()
+	}
+	return cp.String()
+}
+
+// redactHeaders returns a copy of header with the values of the
+// redactedHeaders replaced.
+func redactHeaders(header http.Header) http.Header {
+	cp := header.Clone()
+	for _, h := range redactedHeaders {
+		if cp.Get(h) != "" {
+			cp.Set(h, redacted)
+		}
+	}
+	return cp
+}
@@ -7,6 +7,8 @@ import (
 	"net"
 	"net/http"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 // ClientOptFn are options to set different parameters on the Client.
@@ -16,11 +18,21 @@ type clientOpt struct {
 	addr               string
 	insecureSkipVerify bool
 	doer               doer
+	ownsDoer           bool
 	headers            http.Header
 	authFn             func(*http.Request) error
 	respFn             func(*http.Response) error
 	statusFn           func(*http.Response) error
 	writerFns          []WriteCloserFn
+	debugBodyBytes     int
+	addrs              []string
+	hostCooldown       time.Duration
+	inflight           chan struct{}
+	requestTimeout     time.Duration
+	connectTimeout     time.Duration
+	timings            *requestTimingsOpt
+	log                *zap.Logger
+	unmarshalJSON      func([]byte, interface{}) error
 }
 
 // WithAddr sets the host address on the client.
@@ -67,9 +79,52 @@ func WithContentType(ct string) ClientOptFn {
 	return WithHeader(headerContentType, ct)
 }
 
+// WithMaxConcurrentRequests caps the number of requests of the client in
+// flight at n. A request made at the cap waits for another to finish, or for
+// its context to be done. The cap is shared with clones of the client. A
+// non-positive n does not cap requests.
+func WithMaxConcurrentRequests(n int) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.inflight = nil
+		if n > 0 {
+			opt.inflight = make(chan struct{}, n)
+		}
+		return nil
+	}
+}
+
+// WithRequestTimeout bounds every request of the client, including reading
+// its response, to d, so that no request hangs forever on a server that
+// stops responding. A shorter deadline of the context of a request takes
+// precedence. A non-positive d does not bound requests.
+func WithRequestTimeout(d time.Duration) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.requestTimeout = d
+		return nil
+	}
+}
+
+// WithJSONUnmarshal decodes the JSON responses of Req.DecodeJSON with fn, a
+// function compatible with json.Unmarshal such as that of a faster codec,
+// rather than with encoding/json.
+func WithJSONUnmarshal(fn func(data []byte, v interface{}) error) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.unmarshalJSON = fn
+		return nil
+	}
+}
+
+func withInflight(inflight chan struct{}) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.inflight = inflight
+		return nil
+	}
+}
+
 func withDoer(d doer) ClientOptFn {
 	return func(opt *clientOpt) error {
 		opt.doer = d
+		opt.ownsDoer = false
 		return nil
 	}
 }
@@ -86,7 +141,54 @@ func WithHeader(header, val string) ClientOptFn {
 	}
 }
 
+// WithGzipResponses asks the server for gzipped responses, which the client
+// decompresses before they are checked and decoded. Without it the
+// transport of the default http client asks for and decompresses gzip on
+// its own, but a custom header or transport may not.
+func WithGzipResponses() ClientOptFn {
+	return func(opt *clientOpt) error {
+		if opt.headers == nil {
+			opt.headers = make(http.Header)
+		}
+		opt.headers.Set(headerAcceptEncoding, "gzip")
+		return nil
+	}
+}
+
+// WithDefaultHeaders adds headers to all requests created by the client.
+// An Authorization header is replaced by the auth of the client, such as
+// WithAuthToken, when it sets one.
+func WithDefaultHeaders(headers http.Header) ClientOptFn {
+	return func(opt *clientOpt) error {
+		if opt.headers == nil {
+			opt.headers = make(http.Header)
+		}
+		for header, vals := range headers {
+			for _, v := range vals {
+				opt.headers.Add(header, v)
+			}
+		}
+		return nil
+	}
+}
+
+// WithUserAgent sets the user agent for the http client requests. It replaces
+// any user agent set by a previous option.
+func WithUserAgent(userAgent string) ClientOptFn {
+	return func(opt *clientOpt) error {
+		if opt.headers == nil {
+			opt.headers = make(http.Header)
+		}
+		opt.headers.Set(headerUserAgent, userAgent)
+		return nil
+	}
+}
+
 // WithUserAgentHeader sets the user agent for the http client requests.
+//
+// Deprecated: WithUserAgentHeader adds to any user agent set before it, such
+// as the default one of http.NewHTTPClient. Use WithUserAgent, which replaces
+// it.
 func WithUserAgentHeader(userAgent string) ClientOptFn {
 	return WithHeader("User-Agent", userAgent)
 }
@@ -95,6 +197,18 @@ func WithUserAgentHeader(userAgent string) ClientOptFn {
 func WithHTTPClient(c *http.Client) ClientOptFn {
 	return func(opt *clientOpt) error {
 		opt.doer = c
+		opt.ownsDoer = false
+		return nil
+	}
+}
+
+// WithOwnedHTTPClient sets the raw http client on the httpc Client like
+// WithHTTPClient, for an http client built for the httpc Client alone.
+// Client.Close closes the idle connections of its transport.
+func WithOwnedHTTPClient(c *http.Client) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.doer = c
+		opt.ownsDoer = true
 		return nil
 	}
 }
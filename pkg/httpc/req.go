@@ -10,14 +10,19 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/middleware"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"go.uber.org/zap"
 )
 
 const (
 	headerContentType     = "Content-Type"
 	headerContentEncoding = "Content-Encoding"
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerUserAgent       = "User-Agent"
 )
 
 // Req is a request type.
@@ -31,6 +36,27 @@ type Req struct {
 	respFn   func(*http.Response) error
 	statusFn func(*http.Response) error
 
+	// debugBodyBytes is the size of the body snippet attached to errors,
+	// zero disables attaching responses.
+	debugBodyBytes int
+
+	inflight chan struct{}
+
+	// requestTimeout bounds the request, zero when it is unbounded.
+	requestTimeout time.Duration
+
+	// timings traces the request when it is not nil.
+	timings *requestTimingsOpt
+
+	// log logs the request when it is not nil.
+	log *zap.Logger
+
+	// unmarshalJSON decodes JSON responses, encoding/json when nil.
+	unmarshalJSON func([]byte, interface{}) error
+
+	// etag makes the request conditional when it is not nil.
+	etag *string
+
 	err error
 }
 
@@ -49,8 +75,13 @@ func (r *Req) Auth(authFn func(r *http.Request) error) *Req {
 }
 
 // ContentType sets the Content-Type header to the provided content type on the request.
+// It replaces the content type of the body of the request.
 func (r *Req) ContentType(contentType string) *Req {
-	return r.Header("Content-Type", contentType)
+	if r.err != nil {
+		return r
+	}
+	r.req.Header.Set(headerContentType, contentType)
+	return r
 }
 
 // Decode sets the decoding functionality for the request. All Decode calls are called
@@ -73,10 +104,27 @@ func (r *Req) DecodeGob(v interface{}) *Req {
 }
 
 // DecodeJSON sets the decoding functionality to decode json for the request.
+// It decodes with the function of WithJSONUnmarshal when the client has one.
 func (r *Req) DecodeJSON(v interface{}) *Req {
+	unmarshal := r.unmarshalJSON
 	return r.Decode(func(resp *http.Response) error {
 		r := decodeReader(resp.Body, resp.Header)
-		return json.NewDecoder(r).Decode(v)
+		if unmarshal == nil {
+			return json.NewDecoder(r).Decode(v)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return unmarshal(b, v)
+	})
+}
+
+// DecodeReader sets the decoding functionality for the request to read the
+// response body, decompressed according to its Content-Encoding.
+func (r *Req) DecodeReader(fn func(io.Reader) error) *Req {
+	return r.Decode(func(resp *http.Response) error {
+		return fn(decodeReader(resp.Body, resp.Header))
 	})
 }
 
@@ -140,8 +188,10 @@ func (r *Req) Do(ctx context.Context) error {
 		return r.err
 	}
 
-	if err := r.authFn(r.req); err != nil {
-		return err
+	if !contextAuth(ctx, r.req) {
+		if err := r.authFn(r.req); err != nil {
+			return err
+		}
 	}
 
 	// TODO(@jsteenb2): wrap do with retry/backoff policy.
@@ -152,6 +202,12 @@ func (r *Req) do(ctx context.Context) error {
 	span, ctx := tracing.StartSpanFromContextWithOperationName(ctx, r.req.URL.String())
 	defer span.Finish()
 
+	if r.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.requestTimeout)
+		defer cancel()
+	}
+
 	u := r.req.URL
 	span.LogKV(
 		"scheme", u.Scheme,
@@ -162,7 +218,40 @@ func (r *Req) do(ctx context.Context) error {
 
 	tracing.InjectToHTTPRequest(span, r.req)
 
-	resp, err := r.client.Do(r.req.WithContext(ctx))
+	// propagate the id of the request being served, so that the logs of
+	// both servers can be correlated.
+	if id := middleware.GetReqID(ctx); id != "" && r.req.Header.Get(middleware.RequestIDHeader) == "" {
+		r.req.Header.Set(middleware.RequestIDHeader, id)
+	}
+
+	etag := r.conditionalETag(ctx)
+	if etag != nil && *etag != "" {
+		r.req.Header.Set(headerIfNoneMatch, *etag)
+	}
+
+	if r.inflight != nil {
+		select {
+		case r.inflight <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// released once the response body is drained below.
+		defer func() { <-r.inflight }()
+	}
+
+	// the request carries ctx so canceling ctx aborts the call in flight,
+	// including reading the response body.
+	reqCtx := ctx
+	var trace *requestTrace
+	if r.timings != nil {
+		reqCtx, trace = newRequestTrace(ctx)
+	}
+	start := time.Now()
+	resp, err := r.client.Do(r.req.WithContext(reqCtx))
+	r.logRequest(r.req, resp, err, time.Since(start))
+	if trace != nil {
+		trace.finish(r.timings, span, r.req)
+	}
 	if err != nil {
 		return err
 	}
@@ -176,6 +265,39 @@ func (r *Req) do(ctx context.Context) error {
 		"response_byte", resp.ContentLength,
 	)
 
+	if etag != nil && resp.StatusCode == http.StatusNotModified {
+		return ErrNotModified
+	}
+
+	// bodies the transport left compressed, as it does when the request
+	// sets its own Accept-Encoding, are decompressed for every check and
+	// decoder, including the debug snippet.
+	decompressResponse(resp)
+
+	var snippet *snippetBuffer
+	if r.debugBodyBytes > 0 {
+		snippet = &snippetBuffer{max: r.debugBodyBytes}
+		resp.Body = teeReadCloser{Reader: io.TeeReader(resp.Body, snippet), Closer: resp.Body}
+	}
+
+	if err := r.handleResp(resp); err != nil {
+		// reading the body fails once ctx is done, report why rather
+		// than the failure to read or decode it.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if snippet != nil {
+			return newResponseError(err, resp, snippet.Bytes())
+		}
+		return err
+	}
+	if etag != nil {
+		*etag = resp.Header.Get(headerETag)
+	}
+	return nil
+}
+
+func (r *Req) handleResp(resp *http.Response) error {
 	if r.respFn != nil {
 		if err := r.respFn(resp); err != nil {
 			return err
@@ -211,6 +333,41 @@ func StatusIn(code int, rest ...int) func(*http.Response) error {
 	}
 }
 
+// decompressResponse replaces the body of a gzipped resp with its
+// decompressed content and removes its Content-Encoding.
+func decompressResponse(resp *http.Response) {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get(headerContentEncoding)), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{rc: resp.Body}
+	resp.Header.Del(headerContentEncoding)
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipBody decompresses a gzipped body on its first read, so that an empty
+// body, such as that of a HEAD request, reads as empty.
+type gzipBody struct {
+	rc  io.ReadCloser
+	gr  *gzip.Reader
+	err error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.gr == nil && b.err == nil {
+		b.gr, b.err = gzip.NewReader(b.rc)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.gr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.rc.Close()
+}
+
 var encodingReaders = map[string]func(io.Reader) io.Reader{
 	"gzip": func(r io.Reader) io.Reader {
 		if gr, err := gzip.NewReader(r); err == nil {
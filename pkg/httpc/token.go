@@ -0,0 +1,27 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+)
+
+type tokenContextKey struct{}
+
+// WithToken returns a copy of ctx authenticating the requests made with it
+// by token rather than by the auth of the client, such as WithAuthToken.
+// This lets a single client, and the services built on it, make calls as
+// different tokens, such as those of the tenants of a proxy.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// contextAuth sets the Authorization header of req to the token of ctx,
+// returning whether ctx has one.
+func contextAuth(ctx context.Context, req *http.Request) bool {
+	token, ok := ctx.Value(tokenContextKey{}).(string)
+	if !ok {
+		return false
+	}
+	req.Header.Set("Authorization", "Token "+token)
+	return true
+}
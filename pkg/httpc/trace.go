@@ -0,0 +1,102 @@
+package httpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// RequestTimings are the timings of the phases of a request, measured from
+// when the client sent it. A phase the request did not reach, such as writing
+// the request after failing to dial, is zero.
+type RequestTimings struct {
+	// ConnWait is how long the request waited for a connection, either
+	// idle in the pool or newly dialed. A long wait with a reused
+	// connection points to contention for the pool.
+	ConnWait time.Duration
+	// ConnReused reports whether the connection was idle in the pool.
+	ConnReused bool
+	// WroteRequest is when the request, including its body, was written.
+	WroteRequest time.Duration
+	// FirstResponseByte is when the first byte of the response arrived, so
+	// that FirstResponseByte less WroteRequest is the time spent by the
+	// server.
+	FirstResponseByte time.Duration
+	// Total is when the response headers were read or the request failed.
+	Total time.Duration
+}
+
+// WithRequestTimings traces the connection and transfer of every request of
+// the client, logging the RequestTimings to its span and passing them to fn
+// when fn is not nil. The timings are taken once the response headers are
+// read, before the body is. Requests are not traced by default, as tracing is
+// not free.
+func WithRequestTimings(fn func(*http.Request, RequestTimings)) ClientOptFn {
+	return func(opt *clientOpt) error {
+		opt.timings = &requestTimingsOpt{fn: fn}
+		return nil
+	}
+}
+
+type requestTimingsOpt struct {
+	fn func(*http.Request, RequestTimings)
+}
+
+// requestTrace records the RequestTimings of a request. The hooks of a
+// trace may run on the goroutines of the transport.
+type requestTrace struct {
+	mu      sync.Mutex
+	start   time.Time
+	getConn time.Time
+	timings RequestTimings
+}
+
+func newRequestTrace(ctx context.Context) (context.Context, *requestTrace) {
+	t := &requestTrace{start: time.Now()}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			t.getConn = time.Now()
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timings.ConnWait = time.Since(t.getConn)
+			t.timings.ConnReused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			t.timings.WroteRequest = time.Since(t.start)
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timings.FirstResponseByte = time.Since(t.start)
+			t.mu.Unlock()
+		},
+	}), t
+}
+
+// finish reports the timings of req, once its response headers are read or
+// it failed.
+func (t *requestTrace) finish(opt *requestTimingsOpt, span opentracing.Span, req *http.Request) {
+	t.mu.Lock()
+	t.timings.Total = time.Since(t.start)
+	timings := t.timings
+	t.mu.Unlock()
+
+	span.LogKV(
+		"conn_wait", timings.ConnWait.String(),
+		"conn_reused", timings.ConnReused,
+		"wrote_request", timings.WroteRequest.String(),
+		"first_response_byte", timings.FirstResponseByte.String(),
+	)
+	if opt.fn != nil {
+		opt.fn(req, timings)
+	}
+}
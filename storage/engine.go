@@ -488,9 +488,23 @@ func (e *Engine) CreateCursorIterator(ctx context.Context) (cursors.CursorIterat
 //
 // Appropriate errors are returned in those cases.
 func (e *Engine) WritePoints(ctx context.Context, points []models.Point) error {
+	return e.WritePointsWithOptions(ctx, points, WriteOptions{})
+}
+
+// WritePointsWithOptions writes the provided points to the engine, as
+// WritePoints does, honoring opts.
+func (e *Engine) WritePointsWithOptions(ctx context.Context, points []models.Point, opts WriteOptions) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if opts.RejectOutOfRange {
+		for _, p := range points {
+			if err := models.CheckTime(p.Time()); err != nil {
+				return err
+			}
+		}
+	}
+
 	collection, j := tsdb.NewSeriesCollection(points), 0
 
 	// dropPoint should be called whenever there is reason to drop a point from
@@ -490,6 +490,30 @@ func TestEngine_WriteConflictingBatch(t *testing.T) {
 	}
 }
 
+func TestEngine_WritePointsWithOptions_rejectOutOfRange(t *testing.T) {
+	engine := NewDefaultEngine()
+	defer engine.Close()
+	engine.MustOpen()
+
+	name := tsdb.EncodeNameString(engine.org, engine.bucket)
+	pt := models.MustNewPoint(
+		name,
+		models.NewTags(map[string]string{models.FieldKeyTagKey: "value", models.MeasurementTagKey: "cpu", "host": "server"}),
+		map[string]interface{}{"value": 1.0},
+		time.Unix(1, 2),
+	)
+	opts := storage.WriteOptions{RejectOutOfRange: true}
+
+	if err := engine.Engine.WritePointsWithOptions(context.TODO(), []models.Point{pt}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	pt.SetTime(time.Unix(0, math.MaxInt64))
+	if err := engine.Engine.WritePointsWithOptions(context.TODO(), []models.Point{pt}, opts); err != models.ErrTimeOutOfRange {
+		t.Fatalf("got %v, expected %v", err, models.ErrTimeOutOfRange)
+	}
+}
+
 // BenchmarkWritePoints_100K demonstrates the impact that batch size has on
 // writing a fixed number of points into storage. In this case 100K points are
 // written according to varying batch sizes.
@@ -2,12 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
 )
 
 // PointsWriter describes the ability to write points into a storage engine.
@@ -15,6 +17,52 @@ type PointsWriter interface {
 	WritePoints(context.Context, []models.Point) error
 }
 
+// WriteOptions control how a batch of points is written.
+type WriteOptions struct {
+	// Precision is the precision the timestamps of the points were already
+	// truncated to when they were parsed. Writers do not truncate again.
+	Precision string
+
+	// Consistency is the consistency level requested for the write. The
+	// storage engine runs on a single node and ignores it.
+	Consistency string
+
+	// RejectOutOfRange rejects the whole write when a point has a timestamp
+	// outside models.MinNanoTime and models.MaxNanoTime. By default such
+	// timestamps are not checked.
+	RejectOutOfRange bool
+}
+
+// PointsWriterWithOptions is a PointsWriter that accepts WriteOptions.
+type PointsWriterWithOptions interface {
+	PointsWriter
+	WritePointsWithOptions(ctx context.Context, points []models.Point, opts WriteOptions) error
+}
+
+// ErrPointsWriterBusy is returned by a TryPointsWriter too busy to accept a
+// write.
+var ErrPointsWriterBusy = errors.New("points writer is busy")
+
+// TryPointsWriter is a PointsWriter that refuses writes rather than blocking
+// when it is saturated, such as a writer backed by a queue that is full.
+type TryPointsWriter interface {
+	PointsWriter
+	// TryWritePoints writes points unless the writer is busy, in which case
+	// it returns ErrPointsWriterBusy without writing any of them.
+	TryWritePoints(ctx context.Context, points []models.Point) error
+	// TryWritePointsWithOptions is TryWritePoints for a write with opts.
+	TryWritePointsWithOptions(ctx context.Context, points []models.Point, opts WriteOptions) error
+}
+
+// WritePointsWithOptions writes points with opts when w accepts WriteOptions
+// and falls back to w.WritePoints otherwise.
+func WritePointsWithOptions(ctx context.Context, w PointsWriter, points []models.Point, opts WriteOptions) error {
+	if ow, ok := w.(PointsWriterWithOptions); ok {
+		return ow.WritePointsWithOptions(ctx, points, opts)
+	}
+	return w.WritePoints(ctx, points)
+}
+
 // LoggingPointsWriter wraps an underlying points writer but writes logs to
 // another bucket when an error occurs.
 type LoggingPointsWriter struct {
@@ -75,6 +123,77 @@ func (w *LoggingPointsWriter) WritePoints(ctx context.Context, p []models.Point)
 	return err
 }
 
+// RetryPointsWriter wraps an underlying points writer, retrying the writes
+// that fail with a transient error, per IsTransientWriteError, after an
+// exponential backoff. Other errors, such as points the engine rejects, fail
+// at once.
+type RetryPointsWriter struct {
+	underlying  PointsWriter
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// NewRetryPointsWriter returns a RetryPointsWriter making at most maxAttempts
+// attempts of each write to w, waiting baseDelay before the first retry and
+// twice as long before each next one. A maxAttempts under 2 disables retries.
+func NewRetryPointsWriter(w PointsWriter, maxAttempts int, baseDelay time.Duration) *RetryPointsWriter {
+	return &RetryPointsWriter{
+		underlying:  w,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+	}
+}
+
+// WritePoints writes p to the underlying PointsWriter, retrying transient
+// failures.
+func (w *RetryPointsWriter) WritePoints(ctx context.Context, p []models.Point) error {
+	return w.WritePointsWithOptions(ctx, p, WriteOptions{})
+}
+
+// WritePointsWithOptions writes p with opts to the underlying PointsWriter,
+// retrying transient failures.
+func (w *RetryPointsWriter) WritePointsWithOptions(ctx context.Context, p []models.Point, opts WriteOptions) error {
+	delay := w.baseDelay
+	for attempt := 1; ; attempt++ {
+		err := WritePointsWithOptions(ctx, w.underlying, p, opts)
+		if err == nil || attempt >= w.maxAttempts || !IsTransientWriteError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// IsTransientWriteError reports whether a write failing with err may succeed
+// when retried shortly after: the cache of the engine being full until it is
+// snapshotted, errors reporting themselves as temporary and unavailable
+// services. A busy TryPointsWriter is not transient, the caller is to back
+// off.
+func IsTransientWriteError(err error) bool {
+	if errors.Is(err, ErrPointsWriterBusy) {
+		return false
+	}
+	var (
+		cacheErr tsm1.CacheMemorySizeLimitExceededError
+		tempErr  interface{ Temporary() bool }
+	)
+	switch {
+	case errors.As(err, &cacheErr):
+		return true
+	case errors.As(err, &tempErr):
+		return tempErr.Temporary()
+	}
+	var ierr *influxdb.Error
+	return errors.As(err, &ierr) && influxdb.ErrorCode(ierr) == influxdb.EUnavailable
+}
+
 type BufferedPointsWriter struct {
 	buf []models.Point
 	n   int
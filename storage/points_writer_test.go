@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/tsm1"
 )
 
 func TestLoggingPointsWriter(t *testing.T) {
@@ -132,6 +133,122 @@ func TestLoggingPointsWriter(t *testing.T) {
 	})
 }
 
+type optionsPointsWriter struct {
+	mock.PointsWriter
+	opts []storage.WriteOptions
+}
+
+func (w *optionsPointsWriter) WritePointsWithOptions(ctx context.Context, p []models.Point, opts storage.WriteOptions) error {
+	w.opts = append(w.opts, opts)
+	return w.WritePoints(ctx, p)
+}
+
+func TestWritePointsWithOptions(t *testing.T) {
+	opts := storage.WriteOptions{Precision: "s", RejectOutOfRange: true}
+	points := mockPoints(1, 2, `a humidity=1 11`)
+
+	t.Run("writers accepting options receive them", func(t *testing.T) {
+		pw := &optionsPointsWriter{}
+		if err := storage.WritePointsWithOptions(context.Background(), pw, points, opts); err != nil {
+			t.Fatal(err)
+		}
+		if len(pw.opts) != 1 || pw.opts[0] != opts {
+			t.Errorf("unexpected options: %+v", pw.opts)
+		}
+		if len(pw.Points) != len(points) {
+			t.Errorf("unexpected points written: %d", len(pw.Points))
+		}
+	})
+
+	t.Run("other writers fall back to WritePoints", func(t *testing.T) {
+		pw := &mock.PointsWriter{}
+		if err := storage.WritePointsWithOptions(context.Background(), pw, points, opts); err != nil {
+			t.Fatal(err)
+		}
+		if pw.WritePointsCalled() != 1 || len(pw.Points) != len(points) {
+			t.Errorf("expected a single write of every point, got %d calls and %d points", pw.WritePointsCalled(), len(pw.Points))
+		}
+	})
+}
+
+func TestRetryPointsWriter(t *testing.T) {
+	points := mockPoints(1, 2, `a humidity=1 11`)
+	transient := tsm1.ErrCacheMemorySizeLimitExceeded(2, 1)
+
+	// failing returns a writer failing its first n writes with err and its
+	// number of calls.
+	failing := func(n int, err error) (*mock.PointsWriter, *int) {
+		var calls int
+		return &mock.PointsWriter{
+			WritePointsFn: func(ctx context.Context, p []models.Point) error {
+				if calls++; calls <= n {
+					return err
+				}
+				return nil
+			},
+		}, &calls
+	}
+
+	t.Run("transient errors are retried", func(t *testing.T) {
+		pw, calls := failing(2, transient)
+		w := storage.NewRetryPointsWriter(pw, 3, time.Millisecond)
+		if err := w.WritePoints(context.Background(), points); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := *calls; got != 3 {
+			t.Errorf("unexpected number of attempts: got %d want 3", got)
+		}
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		pw, calls := failing(5, transient)
+		w := storage.NewRetryPointsWriter(pw, 3, time.Millisecond)
+		if err := w.WritePoints(context.Background(), points); !errors.As(err, new(tsm1.CacheMemorySizeLimitExceededError)) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := *calls; got != 3 {
+			t.Errorf("unexpected number of attempts: got %d want 3", got)
+		}
+	})
+
+	t.Run("other errors fail fast", func(t *testing.T) {
+		for _, err := range []error{
+			&influxdb.Error{Code: influxdb.EInvalid, Msg: "partial write"},
+			storage.ErrPointsWriterBusy,
+		} {
+			pw, calls := failing(1, err)
+			w := storage.NewRetryPointsWriter(pw, 3, time.Millisecond)
+			if got := w.WritePoints(context.Background(), points); got != err {
+				t.Errorf("unexpected error: got %v want %v", got, err)
+			}
+			if got := *calls; got != 1 {
+				t.Errorf("expected a single attempt for %v, got %d", err, got)
+			}
+		}
+	})
+
+	t.Run("unavailable services are retried", func(t *testing.T) {
+		pw, _ := failing(1, &influxdb.Error{Code: influxdb.EUnavailable, Msg: "engine is busy"})
+		w := storage.NewRetryPointsWriter(pw, 2, time.Millisecond)
+		if err := w.WritePoints(context.Background(), points); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("backoff stops with the context", func(t *testing.T) {
+		pw, calls := failing(5, transient)
+		w := storage.NewRetryPointsWriter(pw, 5, time.Hour)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := w.WritePoints(ctx, points); err != context.DeadlineExceeded {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := *calls; got != 1 {
+			t.Errorf("unexpected number of attempts: got %d want 1", got)
+		}
+	})
+}
+
 func TestBufferedPointsWriter(t *testing.T) {
 	t.Run("large empty write on empty buffer", func(t *testing.T) {
 		pw := &mock.PointsWriter{}
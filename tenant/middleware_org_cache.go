@@ -0,0 +1,134 @@
+package tenant
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// OrgCache is an Organization Service middleware caching the organizations
+// found by ID, as the write path finds the organization of every write.
+type OrgCache struct {
+	orgService influxdb.OrganizationService
+
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	entries  map[influxdb.ID]*list.Element
+	evictor  *list.List
+}
+
+type orgCacheEntry struct {
+	org     influxdb.Organization
+	expires time.Time
+}
+
+var _ influxdb.OrganizationService = (*OrgCache)(nil)
+
+// NewOrgCache returns a caching service middleware for the Organization
+// Service. At most size organizations found by ID are kept for up to ttl,
+// the least recently used being evicted first. Updates and deletes made
+// through it evict the organizations they change, others are only seen once
+// ttl expires or the organization is passed to Invalidate.
+func NewOrgCache(s influxdb.OrganizationService, size int, ttl time.Duration) *OrgCache {
+	return &OrgCache{
+		orgService: s,
+		capacity:   size,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    make(map[influxdb.ID]*list.Element),
+		evictor:    list.New(),
+	}
+}
+
+// Invalidate evicts the cached organization id.
+func (c *OrgCache) Invalidate(id influxdb.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ele, ok := c.entries[id]; ok {
+		c.remove(ele)
+	}
+}
+
+func (c *OrgCache) FindOrganizationByID(ctx context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+	if o, ok := c.get(id); ok {
+		return o, nil
+	}
+	o, err := c.orgService.FindOrganizationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.add(o)
+	return o, nil
+}
+
+func (c *OrgCache) FindOrganization(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+	return c.orgService.FindOrganization(ctx, filter)
+}
+
+func (c *OrgCache) FindOrganizations(ctx context.Context, filter influxdb.OrganizationFilter, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	return c.orgService.FindOrganizations(ctx, filter, opt...)
+}
+
+func (c *OrgCache) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	return c.orgService.CreateOrganization(ctx, o)
+}
+
+func (c *OrgCache) UpdateOrganization(ctx context.Context, id influxdb.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	defer c.Invalidate(id)
+	return c.orgService.UpdateOrganization(ctx, id, upd)
+}
+
+func (c *OrgCache) DeleteOrganization(ctx context.Context, id influxdb.ID) error {
+	defer c.Invalidate(id)
+	return c.orgService.DeleteOrganization(ctx, id)
+}
+
+func (c *OrgCache) get(id influxdb.ID) (*influxdb.Organization, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ele, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := ele.Value.(*orgCacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(ele)
+		return nil, false
+	}
+	c.evictor.MoveToFront(ele)
+	o := entry.org
+	return &o, true
+}
+
+// add caches o, evicting the least recently used organization if the cache
+// is full.
+func (c *OrgCache) add(o *influxdb.Organization) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &orgCacheEntry{org: *o, expires: c.now().Add(c.ttl)}
+	if ele, ok := c.entries[o.ID]; ok {
+		ele.Value = entry
+		c.evictor.MoveToFront(ele)
+		return
+	}
+
+	c.entries[o.ID] = c.evictor.PushFront(entry)
+	for c.evictor.Len() > c.capacity {
+		c.remove(c.evictor.Back())
+	}
+}
+
+func (c *OrgCache) remove(ele *list.Element) {
+	c.evictor.Remove(ele)
+	delete(c.entries, ele.Value.(*orgCacheEntry).org.ID)
+}
@@ -0,0 +1,121 @@
+package tenant_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/tenant"
+	influxdbtesting "github.com/influxdata/influxdb/v2/testing"
+)
+
+func TestOrganizationCacheService(t *testing.T) {
+	influxdbtesting.OrganizationService(initBoltOrganizationCacheService, t)
+}
+
+func initBoltOrganizationCacheService(f influxdbtesting.OrganizationFields, t *testing.T) (influxdb.OrganizationService, string, func()) {
+	orgSvc, s, closer := initBoltOrganizationService(f, t)
+	return tenant.NewOrgCache(orgSvc, 10, time.Minute), s, closer
+}
+
+func TestOrgCache(t *testing.T) {
+	newCache := func(size int, ttl time.Duration) (*tenant.OrgCache, map[influxdb.ID]int) {
+		var mu sync.Mutex
+		finds := make(map[influxdb.ID]int)
+		orgs := mock.NewOrganizationService()
+		orgs.FindOrganizationByIDF = func(_ context.Context, id influxdb.ID) (*influxdb.Organization, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			finds[id]++
+			return &influxdb.Organization{ID: id, Name: id.String()}, nil
+		}
+		return tenant.NewOrgCache(orgs, size, ttl), finds
+	}
+
+	find := func(t *testing.T, c *tenant.OrgCache, id influxdb.ID) {
+		t.Helper()
+		o, err := c.FindOrganizationByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.ID != id {
+			t.Fatalf("unexpected org: got %s want %s", o.ID, id)
+		}
+	}
+
+	t.Run("caches finds by id", func(t *testing.T) {
+		c, finds := newCache(10, time.Minute)
+		find(t, c, 1)
+		find(t, c, 1)
+		if finds[1] != 1 {
+			t.Errorf("expected the org to be found once: got %d", finds[1])
+		}
+	})
+
+	t.Run("returns copies", func(t *testing.T) {
+		c, _ := newCache(10, time.Minute)
+		o, _ := c.FindOrganizationByID(context.Background(), 1)
+		o.Name = "changed"
+		if o, _ := c.FindOrganizationByID(context.Background(), 1); o.Name == "changed" {
+			t.Error("expected the cached org not to be changed by the caller")
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		c, finds := newCache(10, time.Minute)
+		find(t, c, 1)
+		c.Invalidate(1)
+		find(t, c, 1)
+		if finds[1] != 2 {
+			t.Errorf("expected the invalidated org to be found again: got %d finds", finds[1])
+		}
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		c, finds := newCache(10, 10*time.Millisecond)
+		find(t, c, 1)
+		time.Sleep(20 * time.Millisecond)
+		find(t, c, 1)
+		if finds[1] != 2 {
+			t.Errorf("expected the expired org to be found again: got %d finds", finds[1])
+		}
+	})
+
+	t.Run("evicts the least recently used", func(t *testing.T) {
+		c, finds := newCache(2, time.Minute)
+		find(t, c, 1)
+		find(t, c, 2)
+		find(t, c, 1)
+		find(t, c, 3)
+		find(t, c, 1)
+		find(t, c, 2)
+		if finds[1] != 1 || finds[2] != 2 {
+			t.Errorf("unexpected finds: %v", finds)
+		}
+	})
+
+	t.Run("concurrent finds", func(t *testing.T) {
+		c, _ := newCache(4, time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					id := influxdb.ID(1 + (i+j)%6)
+					if _, err := c.FindOrganizationByID(context.Background(), id); err != nil {
+						t.Error(err)
+						return
+					}
+					if j%10 == 0 {
+						c.Invalidate(id)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
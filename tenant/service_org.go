@@ -2,6 +2,7 @@ package tenant
 
 import (
 	"context"
+	"strings"
 
 	"github.com/influxdata/influxdb/v2"
 	icontext "github.com/influxdata/influxdb/v2/context"
@@ -92,7 +93,7 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 		// find orgs by the urm's resource ids.
 		for _, urm := range urms {
 			o, err := s.FindOrganizationByID(ctx, urm.ResourceID)
-			if err == nil {
+			if err == nil && matchesNamePrefix(filter, o) {
 				// if there is an error then this is a crufty urm and we should just move on
 				orgs = append(orgs, o)
 			}
@@ -101,6 +102,10 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 		return orgs, len(orgs), nil
 	}
 
+	if filter.NamePrefix != nil {
+		return s.findOrganizationsByPrefix(ctx, *filter.NamePrefix, opt...)
+	}
+
 	err := s.store.View(ctx, func(tx kv.Tx) error {
 		os, err := s.store.ListOrgs(ctx, tx, opt...)
 		if err != nil {
@@ -117,6 +122,50 @@ func (s *OrgSvc) FindOrganizations(ctx context.Context, filter influxdb.Organiza
 	return orgs, len(orgs), err
 }
 
+// findOrganizationsByPrefix scans every organization for the ones whose name
+// starts with prefix and applies the offset and limit of opt to the matches.
+func (s *OrgSvc) findOrganizationsByPrefix(ctx context.Context, prefix string, opt ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+	var orgs []*influxdb.Organization
+	err := s.store.View(ctx, func(tx kv.Tx) error {
+		page := influxdb.FindOptions{Limit: influxdb.MaxPageSize}
+		for {
+			os, err := s.store.ListOrgs(ctx, tx, page)
+			if err != nil {
+				return err
+			}
+			for _, o := range os {
+				if strings.HasPrefix(o.Name, prefix) {
+					orgs = append(orgs, o)
+				}
+			}
+			if len(os) < page.Limit {
+				return nil
+			}
+			page.Offset += len(os)
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(opt) > 0 {
+		if opt[0].Offset >= len(orgs) {
+			orgs = nil
+		} else {
+			orgs = orgs[opt[0].Offset:]
+		}
+		if opt[0].Limit > 0 && len(orgs) > opt[0].Limit {
+			orgs = orgs[:opt[0].Limit]
+		}
+	}
+
+	return orgs, len(orgs), nil
+}
+
+func matchesNamePrefix(filter influxdb.OrganizationFilter, o *influxdb.Organization) bool {
+	return filter.NamePrefix == nil || strings.HasPrefix(o.Name, *filter.NamePrefix)
+}
+
 // Creates a new organization and sets b.ID with the new identifier.
 func (s *OrgSvc) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
 	err := s.store.Update(ctx, func(tx kv.Tx) error {
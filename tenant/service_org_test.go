@@ -2,6 +2,8 @@ package tenant_test
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/influxdata/influxdb/v2"
@@ -63,3 +65,45 @@ func initOrganizationService(s kv.Store, f influxdbtesting.OrganizationFields, t
 		}
 	}
 }
+
+func TestFindOrganizationsByNamePrefix(t *testing.T) {
+	s, close, err := NewTestInmemStore(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close()
+
+	storage := tenant.NewStore(s)
+	svc := tenant.NewService(storage)
+	for _, name := range []string{"team-a", "other", "team-b", "team-c"} {
+		if err := svc.CreateOrganization(context.Background(), &influxdb.Organization{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(orgs []*influxdb.Organization) []string {
+		ns := make([]string, 0, len(orgs))
+		for _, o := range orgs {
+			ns = append(ns, o.Name)
+		}
+		sort.Strings(ns)
+		return ns
+	}
+
+	prefix := "team-"
+	orgs, n, err := svc.FindOrganizations(context.Background(), influxdb.OrganizationFilter{NamePrefix: &prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"team-a", "team-b", "team-c"}; n != len(want) || !reflect.DeepEqual(names(orgs), want) {
+		t.Fatalf("unexpected orgs -want/+got:\n\t- %v\n\t+ %v (%d)", want, names(orgs), n)
+	}
+
+	orgs, n, err = svc.FindOrganizations(context.Background(), influxdb.OrganizationFilter{NamePrefix: &prefix}, influxdb.FindOptions{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(orgs) != 1 || orgs[0].Name == "other" {
+		t.Fatalf("expected a single org matching the prefix, got %v (%d)", names(orgs), n)
+	}
+}